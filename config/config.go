@@ -2,23 +2,93 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
 )
 
-const DefaultLogLevel = slog.LevelInfo
+const (
+	DefaultLogLevel = slog.LevelInfo
 
-type Config struct {
-	Devices              []sensor.DeviceConfig `json:"devices"`
-	SensorTimeoutSeconds int                   `json:"sensor_timeout_seconds"`
-	OriginPatterns       []string              `json:"origin_patterns"`
-}
+	DefaultOzoneRunDuration = time.Hour
+
+	DefaultRetentionRawDays    = 30
+	DefaultRetentionRollupDays = 365
+
+	// DefaultLeakDebounceReadings is how many consecutive leak sensor readings must agree before a leak is detected or cleared.
+	DefaultLeakDebounceReadings = 3
+
+	// DefaultColdDoseThresholdF is the water temperature in Fahrenheit the cold dose of a plunge is measured below.
+	DefaultColdDoseThresholdF = 60.0
+)
+
+type (
+	// RetentionConfig controls how long temperature history is kept.
+	// Raw readings older than RawDays are rolled up into hourly aggregates and deleted,
+	// the hourly aggregates are deleted once they are older than RollupDays.
+	// A setting that isn't given uses the default and a negative setting disables that stage.
+	RetentionConfig struct {
+		RawDays    int `json:"raw_days"`
+		RollupDays int `json:"rollup_days"`
+	}
+
+	// LeakDetectionConfig debounces the leak sensor so condensation doesn't raise false alarms.
+	// The state changes once a different reading has been seen for DebounceReadings consecutive readings,
+	// or continuously for DebounceSeconds, whichever comes first. A zero setting turns that condition off.
+	LeakDetectionConfig struct {
+		DebounceReadings int              `json:"debounce_readings"`
+		DebounceSeconds  int              `json:"debounce_seconds"`
+		Zones            []LeakZoneConfig `json:"zones"`
+	}
+
+	// LeakZoneConfig is the policy for a zone named by the leak sensors' zone setting.
+	// ShutOff lists the devices, "pump" and "ozone", that a leak in the zone turns off. An empty list only raises
+	// the alarm, and a zone without a policy shuts off both.
+	LeakZoneConfig struct {
+		Name    string   `json:"name"`
+		ShutOff []string `json:"shut_off"`
+	}
+
+	// ThermostatConfig selects the power device driven by the thermostat and the settings used
+	// until they are changed through the API.
+	ThermostatConfig struct {
+		Device string `json:"device"`
+		thermostat.Settings
+	}
+
+	Config struct {
+		Devices              []sensor.DeviceConfig `json:"devices"`
+		SensorTimeoutSeconds int                   `json:"sensor_timeout_seconds"`
+		OriginPatterns       []string              `json:"origin_patterns"`
+		Retention            RetentionConfig       `json:"retention"`
+		Thermostat           ThermostatConfig      `json:"thermostat"`
+		Notifications        notification.Config   `json:"notifications"`
+		LeakDetection        LeakDetectionConfig   `json:"leak_detection"`
+
+		// ColdDoseThresholdF is the water temperature in Fahrenheit the cold dose of a plunge is measured below.
+		ColdDoseThresholdF float64 `json:"cold_dose_threshold_f"`
+
+		// OzoneRunDuration is how long the ozone generator runs when a duration isn't given, e.g. "45m" or "1h".
+		OzoneRunDuration string        `json:"ozone_run_duration"`
+		OzoneDuration    time.Duration `json:"-"`
+	}
+)
 
 func LoadConfigSettings(filename string) (Config, error) {
-	var config Config
+	config := Config{
+		Thermostat: ThermostatConfig{
+			Device:   sensor.ROLE_CHILLER,
+			Settings: thermostat.DefaultSettings(),
+		},
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return config, err
@@ -36,5 +106,45 @@ func LoadConfigSettings(filename string) (Config, error) {
 		return config, err
 	}
 
+	config.OzoneDuration = DefaultOzoneRunDuration
+	if len(config.OzoneRunDuration) != 0 {
+		config.OzoneDuration, err = time.ParseDuration(config.OzoneRunDuration)
+		if err != nil {
+			return config, fmt.Errorf("invalid ozone_run_duration: %w", err)
+		}
+
+		if config.OzoneDuration < time.Minute {
+			return config, fmt.Errorf("ozone_run_duration must be at least one minute, got %v", config.OzoneDuration)
+		}
+	}
+
+	if config.Retention.RawDays == 0 {
+		config.Retention.RawDays = DefaultRetentionRawDays
+	}
+
+	if config.Retention.RollupDays == 0 {
+		config.Retention.RollupDays = DefaultRetentionRollupDays
+	}
+
+	if config.ColdDoseThresholdF == 0 {
+		config.ColdDoseThresholdF = DefaultColdDoseThresholdF
+	}
+
+	if config.LeakDetection.DebounceReadings < 0 || config.LeakDetection.DebounceSeconds < 0 {
+		return config, fmt.Errorf("leak_detection debounce settings can not be negative")
+	}
+
+	if config.LeakDetection.DebounceReadings == 0 && config.LeakDetection.DebounceSeconds == 0 {
+		config.LeakDetection.DebounceReadings = DefaultLeakDebounceReadings
+	}
+
+	for _, zone := range config.LeakDetection.Zones {
+		for _, device := range zone.ShutOff {
+			if device != interlock.DEVICE_PUMP && device != interlock.DEVICE_OZONE {
+				return config, fmt.Errorf("leak zone %s can not shut off %s, only %s and %s are supported", zone.Name, device, interlock.DEVICE_PUMP, interlock.DEVICE_OZONE)
+			}
+		}
+	}
+
 	return config, nil
 }
@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Validate the rule.
+func (r Rule) Validate() error {
+	if len(r.Name) == 0 {
+		return ErrInvalidName
+	}
+
+	if len(r.Device) == 0 {
+		return ErrInvalidDevice
+	}
+
+	switch r.Condition {
+	case CONDITION_BELOW, CONDITION_ABOVE, CONDITION_TARGET:
+	case CONDITION_OFFLINE:
+		if r.OfflineSeconds <= 0 {
+			return ErrInvalidOffline
+		}
+	default:
+		return ErrInvalidCondition
+	}
+
+	if math.Abs(r.ThresholdF) > MaxThresholdF {
+		return ErrInvalidThreshold
+	}
+
+	if r.CooldownSeconds < 0 {
+		return ErrInvalidCooldown
+	}
+
+	return nil
+}
+
+// Matches reports if the rule applies to the device that took the reading.
+func (r Rule) Matches(reading Reading) bool {
+	return r.Device == reading.Role || r.Device == reading.Name || r.Device == reading.Address
+}
+
+// InCooldown reports if the rule triggered too recently to alert again.
+func (r Rule) InCooldown(now time.Time) bool {
+	if r.LastTriggeredAt.IsZero() {
+		return false
+	}
+
+	return now.Sub(r.LastTriggeredAt) < time.Duration(r.CooldownSeconds)*time.Second
+}
+
+// Evaluate returns an alert for the first matching reading that meets the rule's condition.
+func Evaluate(rule Rule, readings []Reading, now time.Time) (Alert, bool) {
+	if !rule.Enabled || rule.InCooldown(now) {
+		return Alert{}, false
+	}
+
+	for _, reading := range readings {
+		if !rule.Matches(reading) {
+			continue
+		}
+
+		if message, ok := check(rule, reading, now); ok {
+			return Alert{Rule: rule, Reading: reading, Message: message}, true
+		}
+	}
+
+	return Alert{}, false
+}
+
+func check(rule Rule, reading Reading, now time.Time) (string, bool) {
+	name := reading.Name
+	if len(name) == 0 {
+		name = reading.Role
+	}
+
+	if rule.Condition == CONDITION_OFFLINE {
+		offline := now.Sub(reading.LastSeenAt)
+		if offline < time.Duration(rule.OfflineSeconds)*time.Second {
+			return "", false
+		}
+
+		return fmt.Sprintf("%s: %s has not reported a temperature for %v", rule.Name, name, offline.Round(time.Second)), true
+	}
+
+	// the temperature conditions can't be checked without a good reading
+	if !reading.Valid {
+		return "", false
+	}
+
+	switch rule.Condition {
+	case CONDITION_BELOW:
+		if reading.TemperatureF < rule.ThresholdF {
+			return fmt.Sprintf("%s: %s is %.1f°F, below %.1f°F", rule.Name, name, reading.TemperatureF, rule.ThresholdF), true
+		}
+
+	case CONDITION_ABOVE:
+		if reading.TemperatureF > rule.ThresholdF {
+			return fmt.Sprintf("%s: %s is %.1f°F, above %.1f°F", rule.Name, name, reading.TemperatureF, rule.ThresholdF), true
+		}
+
+	case CONDITION_TARGET:
+		if math.Abs(reading.TemperatureF-rule.ThresholdF) <= TargetToleranceF {
+			return fmt.Sprintf("%s: %s reached the target of %.1f°F", rule.Name, name, rule.ThresholdF), true
+		}
+	}
+
+	return "", false
+}
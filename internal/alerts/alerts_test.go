@@ -0,0 +1,119 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	water := Reading{Name: "Water", Role: "water", Address: "28-0001", TemperatureF: 44.0, Valid: true, LastSeenAt: now}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		reading  Reading
+		expected bool
+	}{
+		{
+			name:     "should trigger when below the threshold",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 45, Enabled: true},
+			reading:  water,
+			expected: true,
+		},
+		{
+			name:     "should not trigger when above a below threshold",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 40, Enabled: true},
+			reading:  water,
+			expected: false,
+		},
+		{
+			name:     "should trigger when above the threshold",
+			rule:     Rule{Name: "warm", Device: "Water", Condition: CONDITION_ABOVE, ThresholdF: 40, Enabled: true},
+			reading:  water,
+			expected: true,
+		},
+		{
+			name:     "should trigger within the target tolerance",
+			rule:     Rule{Name: "target", Device: "28-0001", Condition: CONDITION_TARGET, ThresholdF: 44.4, Enabled: true},
+			reading:  water,
+			expected: true,
+		},
+		{
+			name:     "should ignore other devices",
+			rule:     Rule{Name: "cold", Device: "room", Condition: CONDITION_BELOW, ThresholdF: 45, Enabled: true},
+			reading:  water,
+			expected: false,
+		},
+		{
+			name:     "should ignore disabled rules",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 45},
+			reading:  water,
+			expected: false,
+		},
+		{
+			name:     "should not alert during the cooldown",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 45, Enabled: true, CooldownSeconds: 600, LastTriggeredAt: now.Add(-5 * time.Minute)},
+			reading:  water,
+			expected: false,
+		},
+		{
+			name:     "should alert again after the cooldown",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 45, Enabled: true, CooldownSeconds: 600, LastTriggeredAt: now.Add(-15 * time.Minute)},
+			reading:  water,
+			expected: true,
+		},
+		{
+			name:     "should not check the temperature of a failed reading",
+			rule:     Rule{Name: "cold", Device: "water", Condition: CONDITION_BELOW, ThresholdF: 45, Enabled: true},
+			reading:  Reading{Role: "water", Valid: false, LastSeenAt: now},
+			expected: false,
+		},
+		{
+			name:     "should trigger when offline for too long",
+			rule:     Rule{Name: "offline", Device: "water", Condition: CONDITION_OFFLINE, OfflineSeconds: 300, Enabled: true},
+			reading:  Reading{Role: "water", Valid: false, LastSeenAt: now.Add(-6 * time.Minute)},
+			expected: true,
+		},
+		{
+			name:     "should not trigger when recently seen",
+			rule:     Rule{Name: "offline", Device: "water", Condition: CONDITION_OFFLINE, OfflineSeconds: 300, Enabled: true},
+			reading:  Reading{Role: "water", Valid: false, LastSeenAt: now.Add(-time.Minute)},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, triggered := Evaluate(tc.rule, []Reading{tc.reading}, now)
+			if triggered != tc.expected {
+				t.Errorf("expected triggered %v, got %v", tc.expected, triggered)
+			}
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	t.Run("should require an offline duration", func(t *testing.T) {
+		r := Rule{Name: "offline", Device: "water", Condition: CONDITION_OFFLINE}
+		if err := r.Validate(); err != ErrInvalidOffline {
+			t.Errorf("expected %v, got %v", ErrInvalidOffline, err)
+		}
+	})
+
+	t.Run("should reject a threshold the database can't store", func(t *testing.T) {
+		for _, threshold := range []float64{1000, -1000, 999.995} {
+			r := Rule{Name: "warm", Device: "water", Condition: CONDITION_ABOVE, ThresholdF: threshold}
+			if err := r.Validate(); err != ErrInvalidThreshold {
+				t.Errorf("expected %v for %v, got %v", ErrInvalidThreshold, threshold, err)
+			}
+		}
+	})
+
+	t.Run("should reject an unknown condition", func(t *testing.T) {
+		r := Rule{Name: "rule", Device: "water", Condition: "between"}
+		if err := r.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected %v, got %v", ErrInvalidCondition, err)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package alerts
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CONDITION_BELOW   = "below"
+	CONDITION_ABOVE   = "above"
+	CONDITION_OFFLINE = "offline"
+	CONDITION_TARGET  = "target"
+
+	// TargetToleranceF is how close the temperature must be to a target to trigger the rule.
+	TargetToleranceF = 0.5
+
+	// MaxThresholdF is the largest threshold, positive or negative, the database can store.
+	MaxThresholdF = 999.99
+)
+
+var (
+	ErrInvalidName      = errors.New("name is required")
+	ErrInvalidDevice    = errors.New("device is required")
+	ErrInvalidCondition = errors.New("condition must be one of 'below', 'above', 'offline' or 'target'")
+	ErrInvalidOffline   = errors.New("offline_seconds must be greater than zero for an offline rule")
+	ErrInvalidCooldown  = errors.New("cooldown_seconds can't be negative")
+	ErrInvalidThreshold = errors.New("threshold_f must be between -999.99 and 999.99")
+)
+
+type (
+	// Rule raises an alert when a temperature device meets the condition.
+	Rule struct {
+		ID        uuid.UUID
+		Name      string
+		Device    string // Device matches the role, name or address of a temperature device.
+		Condition string
+
+		// ThresholdF is the temperature used by the below, above and target conditions.
+		ThresholdF float64
+
+		// OfflineSeconds is how long a device must go without a successful reading to trigger an offline rule.
+		OfflineSeconds int
+
+		// CooldownSeconds is the minimum time between two alerts from the rule.
+		CooldownSeconds int
+
+		Enabled         bool
+		OneShot         bool // OneShot rules are disabled once they trigger.
+		LastTriggeredAt time.Time
+	}
+
+	// Reading is the latest state of a temperature device.
+	Reading struct {
+		Name         string
+		Address      string
+		Role         string
+		TemperatureF float64
+		Valid        bool      // Valid is false if the last read failed.
+		LastSeenAt   time.Time // LastSeenAt is the time of the last successful read.
+	}
+
+	// Alert is raised when a rule is triggered.
+	Alert struct {
+		Rule    Rule
+		Reading Reading
+		Message string
+	}
+)
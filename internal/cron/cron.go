@@ -0,0 +1,192 @@
+// Package cron parses standard five field cron expressions.
+//
+//	┌───────────── minute (0 - 59)
+//	│ ┌───────────── hour (0 - 23)
+//	│ │ ┌───────────── day of the month (1 - 31)
+//	│ │ │ ┌───────────── month (1 - 12)
+//	│ │ │ │ ┌───────────── day of the week (0 - 6, Sunday is 0 or 7)
+//	│ │ │ │ │
+//	* * * * *
+//
+// Each field can be a '*', a value, a range 'a-b', a step '*/n' or 'a-b/n', or a comma separated list of these.
+// When both the day of the month and the day of the week are restricted, a time matches if either one does.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+type (
+	field struct {
+		min, max int
+	}
+
+	// Schedule is a parsed cron expression.
+	Schedule struct {
+		minute, hour, dom, month, dow uint64
+		domAny, dowAny                bool
+	}
+)
+
+var fields = []field{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of the month
+	{1, 12}, // month
+	{0, 7},  // day of the week
+}
+
+// Parse the five field cron expression.
+func Parse(expr string) (Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return Schedule{}, fmt.Errorf("%w: expected %d fields, got %d", ErrInvalidExpression, len(fields), len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return Schedule{}, err
+		}
+		bits[i] = b
+	}
+
+	// Sunday can be written as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+// Matches reports if the schedule fires in the minute of the time.
+func (s Schedule) Matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	return s.dayMatches(t)
+}
+
+// Next returns the first minute after the time that matches the schedule,
+// or the zero time if nothing matches in the next five years.
+func (s Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	end := after.AddDate(5, 0, 0)
+
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(expr, ",") {
+		b, err := parseItem(item, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+
+	return bits, nil
+}
+
+func parseItem(item string, f field) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("%w: invalid step '%s'", ErrInvalidExpression, item)
+		}
+	}
+
+	lo, hi := f.min, f.max
+	if rangeExpr != "*" {
+		loExpr, hiExpr, isRange := strings.Cut(rangeExpr, "-")
+
+		var err error
+		lo, err = parseValue(loExpr, f)
+		if err != nil {
+			return 0, err
+		}
+
+		hi = lo
+		if isRange {
+			hi, err = parseValue(hiExpr, f)
+			if err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			// 'a/n' runs from a to the end of the field
+			hi = f.max
+		}
+
+		if hi < lo {
+			return 0, fmt.Errorf("%w: invalid range '%s'", ErrInvalidExpression, item)
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+
+	return bits, nil
+}
+
+func parseValue(expr string, f field) (int, error) {
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%w: '%s' must be between %d and %d", ErrInvalidExpression, expr, f.min, f.max)
+	}
+
+	return v, nil
+}
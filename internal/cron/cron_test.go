@@ -0,0 +1,95 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range invalid {
+		t.Run("should reject '"+expr+"'", func(t *testing.T) {
+			if _, err := Parse(expr); !errors.Is(err, ErrInvalidExpression) {
+				t.Errorf("expected %v, got %v", ErrInvalidExpression, err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// Monday
+	monday := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		t        time.Time
+		expected bool
+	}{
+		{"should match every minute", "* * * * *", monday, true},
+		{"should match 2am every night", "0 2 * * *", monday, true},
+		{"should not match the wrong hour", "0 3 * * *", monday, false},
+		{"should match a list", "0,30 1,2 * * *", monday, true},
+		{"should match a step", "*/15 * * * *", monday.Add(45 * time.Minute), true},
+		{"should not match off the step", "*/15 * * * *", monday.Add(40 * time.Minute), false},
+		{"should match a weekday range", "0 2 * * 1-5", monday, true},
+		{"should not match the weekend", "0 2 * * 0,6", monday, false},
+		{"should treat 7 as Sunday", "0 2 * * 7", monday.AddDate(0, 0, 6), true},
+		{"should match either day when both are restricted", "0 2 15 * 1", monday, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("failed to parse '%s': %v", tc.expr, err)
+			}
+
+			if s.Matches(tc.t) != tc.expected {
+				t.Errorf("expected %v for %v", tc.expected, tc.t)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Run("should find the next night", func(t *testing.T) {
+		s, _ := Parse("0 2 * * *")
+		after := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+		next := s.Next(after)
+		expected := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)
+		if !next.Equal(expected) {
+			t.Errorf("expected %v, got %v", expected, next)
+		}
+	})
+
+	t.Run("should roll over to the next month", func(t *testing.T) {
+		s, _ := Parse("30 6 1 * *")
+		after := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		next := s.Next(after)
+		expected := time.Date(2024, 2, 1, 6, 30, 0, 0, time.UTC)
+		if !next.Equal(expected) {
+			t.Errorf("expected %v, got %v", expected, next)
+		}
+	})
+
+	t.Run("should return zero when nothing matches", func(t *testing.T) {
+		s, _ := Parse("0 0 31 2 *")
+		if next := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+			t.Errorf("expected no next time, got %v", next)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code for a row that breaks a unique constraint.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports if the statement failed because it broke a unique constraint.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
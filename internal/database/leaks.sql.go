@@ -7,16 +7,57 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const acknowledgeLeak = `-- name: AcknowledgeLeak :one
+UPDATE leaks
+SET acknowledged_at = $2,
+    acknowledged_by = $3,
+    acknowledgement_note = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone
+`
+
+type AcknowledgeLeakParams struct {
+	ID                  uuid.UUID
+	AcknowledgedAt      sql.NullTime
+	AcknowledgedBy      uuid.NullUUID
+	AcknowledgementNote sql.NullString
+}
+
+func (q *Queries) AcknowledgeLeak(ctx context.Context, arg AcknowledgeLeakParams) (Leak, error) {
+	row := q.db.QueryRowContext(ctx, acknowledgeLeak,
+		arg.ID,
+		arg.AcknowledgedAt,
+		arg.AcknowledgedBy,
+		arg.AcknowledgementNote,
+	)
+	var i Leak
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DetectedAt,
+		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
+	)
+	return i, err
+}
+
 const clearDetectedLeak = `-- name: ClearDetectedLeak :one
 UPDATE leaks
 SET cleared_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, created_at, updated_at, detected_at, cleared_at
+RETURNING id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone
 `
 
 func (q *Queries) ClearDetectedLeak(ctx context.Context, id uuid.UUID) (Leak, error) {
@@ -28,18 +69,87 @@ func (q *Queries) ClearDetectedLeak(ctx context.Context, id uuid.UUID) (Leak, er
 		&i.UpdatedAt,
 		&i.DetectedAt,
 		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
 	)
 	return i, err
 }
 
+const clearSensorLeaks = `-- name: ClearSensorLeaks :many
+UPDATE leaks
+SET cleared_at = CURRENT_TIMESTAMP
+WHERE sensor_id = $1 AND cleared_at IS NULL
+RETURNING id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone
+`
+
+func (q *Queries) ClearSensorLeaks(ctx context.Context, sensorID string) ([]Leak, error) {
+	rows, err := q.db.QueryContext(ctx, clearSensorLeaks, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Leak
+	for rows.Next() {
+		var i Leak
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DetectedAt,
+			&i.ClearedAt,
+			&i.AcknowledgedAt,
+			&i.AcknowledgedBy,
+			&i.AcknowledgementNote,
+			&i.SensorID,
+			&i.Zone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countLeaks = `-- name: CountLeaks :one
+SELECT COUNT(*) FROM leaks
+WHERE detected_at >= $1 AND detected_at < $2
+`
+
+type CountLeaksParams struct {
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+func (q *Queries) CountLeaks(ctx context.Context, arg CountLeaksParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countLeaks, arg.FromTime, arg.ToTime)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createLeakDetected = `-- name: CreateLeakDetected :one
-INSERT INTO leaks (detected_at)
-VALUES ($1)
-RETURNING id, created_at, updated_at, detected_at, cleared_at
+INSERT INTO leaks (detected_at, sensor_id, zone)
+VALUES ($1, $2, $3)
+RETURNING id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone
 `
 
-func (q *Queries) CreateLeakDetected(ctx context.Context, detectedAt time.Time) (Leak, error) {
-	row := q.db.QueryRowContext(ctx, createLeakDetected, detectedAt)
+type CreateLeakDetectedParams struct {
+	DetectedAt time.Time
+	SensorID   string
+	Zone       string
+}
+
+func (q *Queries) CreateLeakDetected(ctx context.Context, arg CreateLeakDetectedParams) (Leak, error) {
+	row := q.db.QueryRowContext(ctx, createLeakDetected, arg.DetectedAt, arg.SensorID, arg.Zone)
 	var i Leak
 	err := row.Scan(
 		&i.ID,
@@ -47,12 +157,42 @@ func (q *Queries) CreateLeakDetected(ctx context.Context, detectedAt time.Time)
 		&i.UpdatedAt,
 		&i.DetectedAt,
 		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
+	)
+	return i, err
+}
+
+const getActiveLeak = `-- name: GetActiveLeak :one
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
+WHERE sensor_id = $1 AND cleared_at IS NULL
+ORDER BY detected_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveLeak(ctx context.Context, sensorID string) (Leak, error) {
+	row := q.db.QueryRowContext(ctx, getActiveLeak, sensorID)
+	var i Leak
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DetectedAt,
+		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
 	)
 	return i, err
 }
 
 const getLatestLeakDetected = `-- name: GetLatestLeakDetected :one
-SELECT id, created_at, updated_at, detected_at, cleared_at FROM leaks
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
 ORDER BY created_at DESC
 LIMIT 1
 `
@@ -66,6 +206,172 @@ func (q *Queries) GetLatestLeakDetected(ctx context.Context) (Leak, error) {
 		&i.UpdatedAt,
 		&i.DetectedAt,
 		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
+	)
+	return i, err
+}
+
+const getLeak = `-- name: GetLeak :one
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
+WHERE id = $1
+`
+
+func (q *Queries) GetLeak(ctx context.Context, id uuid.UUID) (Leak, error) {
+	row := q.db.QueryRowContext(ctx, getLeak, id)
+	var i Leak
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DetectedAt,
+		&i.ClearedAt,
+		&i.AcknowledgedAt,
+		&i.AcknowledgedBy,
+		&i.AcknowledgementNote,
+		&i.SensorID,
+		&i.Zone,
 	)
 	return i, err
 }
+
+const getLeaks = `-- name: GetLeaks :many
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
+WHERE detected_at >= $1 AND detected_at < $2
+ORDER BY detected_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type GetLeaksParams struct {
+	FromTime  time.Time
+	ToTime    time.Time
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) GetLeaks(ctx context.Context, arg GetLeaksParams) ([]Leak, error) {
+	rows, err := q.db.QueryContext(ctx, getLeaks,
+		arg.FromTime,
+		arg.ToTime,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Leak
+	for rows.Next() {
+		var i Leak
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DetectedAt,
+			&i.ClearedAt,
+			&i.AcknowledgedAt,
+			&i.AcknowledgedBy,
+			&i.AcknowledgementNote,
+			&i.SensorID,
+			&i.Zone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOverlappingLeaks = `-- name: GetOverlappingLeaks :many
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
+WHERE detected_at < $1 AND (cleared_at IS NULL OR cleared_at > $2)
+ORDER BY detected_at ASC
+`
+
+type GetOverlappingLeaksParams struct {
+	ToTime   time.Time
+	FromTime time.Time
+}
+
+func (q *Queries) GetOverlappingLeaks(ctx context.Context, arg GetOverlappingLeaksParams) ([]Leak, error) {
+	rows, err := q.db.QueryContext(ctx, getOverlappingLeaks, arg.ToTime, arg.FromTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Leak
+	for rows.Next() {
+		var i Leak
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DetectedAt,
+			&i.ClearedAt,
+			&i.AcknowledgedAt,
+			&i.AcknowledgedBy,
+			&i.AcknowledgementNote,
+			&i.SensorID,
+			&i.Zone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnacknowledgedLeaks = `-- name: GetUnacknowledgedLeaks :many
+SELECT id, created_at, updated_at, detected_at, cleared_at, acknowledged_at, acknowledged_by, acknowledgement_note, sensor_id, zone FROM leaks
+WHERE acknowledged_at IS NULL
+ORDER BY detected_at ASC
+`
+
+func (q *Queries) GetUnacknowledgedLeaks(ctx context.Context) ([]Leak, error) {
+	rows, err := q.db.QueryContext(ctx, getUnacknowledgedLeaks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Leak
+	for rows.Next() {
+		var i Leak
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DetectedAt,
+			&i.ClearedAt,
+			&i.AcknowledgedAt,
+			&i.AcknowledgedBy,
+			&i.AcknowledgementNote,
+			&i.SensorID,
+			&i.Zone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
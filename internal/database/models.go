@@ -12,6 +12,21 @@ import (
 	"github.com/google/uuid"
 )
 
+type AlertRule struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Name            string
+	Device          string
+	Condition       string
+	ThresholdF      string
+	OfflineSeconds  int32
+	CooldownSeconds int32
+	Enabled         bool
+	OneShot         bool
+	LastTriggeredAt sql.NullTime
+}
+
 type Event struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
@@ -29,11 +44,37 @@ type Filter struct {
 }
 
 type Leak struct {
-	ID         uuid.UUID
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DetectedAt time.Time
-	ClearedAt  sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DetectedAt          time.Time
+	ClearedAt           sql.NullTime
+	AcknowledgedAt      sql.NullTime
+	AcknowledgedBy      uuid.NullUUID
+	AcknowledgementNote sql.NullString
+	SensorID            string
+	Zone                string
+}
+
+type NotificationOutbox struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	EventType     string
+	Severity      string
+	Source        string
+	Title         string
+	Message       string
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Channel       string
+	PhoneNumber   sql.NullString
+	Email         sql.NullString
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	SentAt        sql.NullTime
 }
 
 type Ozone struct {
@@ -47,6 +88,25 @@ type Ozone struct {
 	StatusMessage    sql.NullString
 }
 
+type OzoneSchedule struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Name            string
+	CronExpression  string
+	DurationMinutes int32
+	Enabled         bool
+}
+
+type OzoneScheduleRun struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	ScheduleID   uuid.UUID
+	ScheduledFor time.Time
+	Started      bool
+	SkipReason   sql.NullString
+}
+
 type Plunge struct {
 	ID               uuid.UUID
 	CreatedAt        time.Time
@@ -61,20 +121,132 @@ type Plunge struct {
 	ExpectedDuration int32
 	AvgWaterTemp     string
 	AvgRoomTemp      string
+	UserID           uuid.NullUUID
+	MinWaterTemp     string
+	MaxWaterTemp     string
+	MinRoomTemp      string
+	MaxRoomTemp      string
+	ProtocolID       uuid.NullUUID
+	ProtocolPhases   json.RawMessage
+	Notes            string
+	PerceivedEffort  sql.NullInt32
+	Tags             []string
 }
 
-type Temperature struct {
+type PlungeGoal struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	Kind      string
+	Target    float64
+	BelowTemp float64
+}
+
+type PlungeHeartRate struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PlungeID  uuid.UUID
+	ReadAt    time.Time
+	Bpm       int32
+}
+
+type PlungePhase struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	PlungeID        uuid.UUID
+	Position        int32
+	Name            string
+	Kind            string
+	DurationSeconds int32
+	StartedAt       time.Time
+	EndedAt         time.Time
+	Completed       bool
+}
+
+type PlungeProtocol struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Description string
+	Rounds      int32
+	Phases      json.RawMessage
+}
+
+type PlungeTemperature struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PlungeID  uuid.UUID
+	ReadAt    time.Time
 	WaterTemp sql.NullString
 	RoomTemp  sql.NullString
 }
 
+type RetentionRun struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	RawCutoff        time.Time
+	RollupCutoff     time.Time
+	ReadingsRolledUp int64
+	RollupsWritten   int64
+	RollupsPruned    int64
+	ErrorMessage     sql.NullString
+}
+
+type TemperatureReading struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ReadAt        time.Time
+	DeviceAddress string
+	DeviceName    string
+	DeviceRole    string
+	TemperatureC  string
+	TemperatureF  string
+}
+
+type TemperatureRollup struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	Bucket        time.Time
+	DeviceAddress string
+	DeviceName    string
+	DeviceRole    string
+	MinC          string
+	MaxC          string
+	AvgC          string
+	MinF          string
+	MaxF          string
+	AvgF          string
+	SampleCount   int32
+}
+
+type ThermostatSetting struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	Mode             string
+	SetpointF        string
+	HysteresisF      string
+	MinOnSeconds     int32
+	MinOffSeconds    int32
+	MaxCyclesPerHour int32
+}
+
 type User struct {
-	ID        uuid.UUID
-	Email     string
+	ID          uuid.UUID
+	Email       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ApiKey      string
+	PhoneNumber sql.NullString
+	NotifySms   bool
+	NotifyEmail bool
+	MutedUntil  sql.NullTime
+}
+
+type UserSubscription struct {
+	UserID    uuid.UUID
+	EventType string
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	ApiKey    string
 }
@@ -8,12 +8,172 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPlunges = `-- name: CountPlunges :one
+SELECT COUNT(*) FROM plunges
+WHERE user_id = $1::uuid AND start_time >= $2::timestamp AND start_time < $3::timestamp
+`
+
+type CountPlungesParams struct {
+	UserID   uuid.UUID
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+func (q *Queries) CountPlunges(ctx context.Context, arg CountPlungesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPlunges, arg.UserID, arg.FromTime, arg.ToTime)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deletePlungeHeartRates = `-- name: DeletePlungeHeartRates :exec
+DELETE FROM plunge_heart_rates
+WHERE plunge_id = $1
+`
+
+func (q *Queries) DeletePlungeHeartRates(ctx context.Context, plungeID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePlungeHeartRates, plungeID)
+	return err
+}
+
+const getCompletedPlungeTemperatures = `-- name: GetCompletedPlungeTemperatures :many
+SELECT plunge_temperatures.id, plunge_temperatures.created_at, plunge_temperatures.plunge_id, plunge_temperatures.read_at, plunge_temperatures.water_temp, plunge_temperatures.room_temp
+FROM plunge_temperatures
+JOIN plunges ON plunges.id = plunge_temperatures.plunge_id
+WHERE plunges.user_id = $1::uuid AND plunges.start_time >= $2::timestamp AND plunges.start_time < $3::timestamp AND plunges.end_time IS NOT NULL
+ORDER BY plunge_temperatures.plunge_id, plunge_temperatures.read_at ASC
+`
+
+type GetCompletedPlungeTemperaturesParams struct {
+	UserID   uuid.UUID
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+func (q *Queries) GetCompletedPlungeTemperatures(ctx context.Context, arg GetCompletedPlungeTemperaturesParams) ([]PlungeTemperature, error) {
+	rows, err := q.db.QueryContext(ctx, getCompletedPlungeTemperatures, arg.UserID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlungeTemperature
+	for rows.Next() {
+		var i PlungeTemperature
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PlungeID,
+			&i.ReadAt,
+			&i.WaterTemp,
+			&i.RoomTemp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCompletedPlunges = `-- name: GetCompletedPlunges :many
+SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags FROM plunges
+WHERE user_id = $1::uuid AND start_time >= $2::timestamp AND start_time < $3::timestamp AND end_time IS NOT NULL
+ORDER BY start_time ASC
+`
+
+type GetCompletedPlungesParams struct {
+	UserID   uuid.UUID
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+func (q *Queries) GetCompletedPlunges(ctx context.Context, arg GetCompletedPlungesParams) ([]Plunge, error) {
+	rows, err := q.db.QueryContext(ctx, getCompletedPlunges, arg.UserID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Plunge
+	for rows.Next() {
+		var i Plunge
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.StartTime,
+			&i.StartWaterTemp,
+			&i.StartRoomTemp,
+			&i.EndTime,
+			&i.EndWaterTemp,
+			&i.EndRoomTemp,
+			&i.Running,
+			&i.ExpectedDuration,
+			&i.AvgWaterTemp,
+			&i.AvgRoomTemp,
+			&i.UserID,
+			&i.MinWaterTemp,
+			&i.MaxWaterTemp,
+			&i.MinRoomTemp,
+			&i.MaxRoomTemp,
+			&i.ProtocolID,
+			&i.ProtocolPhases,
+			&i.Notes,
+			&i.PerceivedEffort,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastPlungeTemperature = `-- name: GetLastPlungeTemperature :one
+SELECT id, created_at, plunge_id, read_at, water_temp, room_temp FROM plunge_temperatures
+WHERE plunge_id = $1 AND read_at <= $2
+ORDER BY read_at DESC
+LIMIT 1
+`
+
+type GetLastPlungeTemperatureParams struct {
+	PlungeID uuid.UUID
+	ReadAt   time.Time
+}
+
+func (q *Queries) GetLastPlungeTemperature(ctx context.Context, arg GetLastPlungeTemperatureParams) (PlungeTemperature, error) {
+	row := q.db.QueryRowContext(ctx, getLastPlungeTemperature, arg.PlungeID, arg.ReadAt)
+	var i PlungeTemperature
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PlungeID,
+		&i.ReadAt,
+		&i.WaterTemp,
+		&i.RoomTemp,
+	)
+	return i, err
+}
+
 const getLatestPlunge = `-- name: GetLatestPlunge :one
-SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp FROM plunges 
+SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags FROM plunges 
 ORDER BY created_at DESC
 LIMIT 1
 `
@@ -35,12 +195,22 @@ func (q *Queries) GetLatestPlunge(ctx context.Context) (Plunge, error) {
 		&i.ExpectedDuration,
 		&i.AvgWaterTemp,
 		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
 	)
 	return i, err
 }
 
 const getPlungeByID = `-- name: GetPlungeByID :one
-SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp FROM plunges
+SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags FROM plunges
 WHERE id = $1
 `
 
@@ -61,17 +231,171 @@ func (q *Queries) GetPlungeByID(ctx context.Context, id uuid.UUID) (Plunge, erro
 		&i.ExpectedDuration,
 		&i.AvgWaterTemp,
 		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
 	)
 	return i, err
 }
 
+const getPlungeHeartRates = `-- name: GetPlungeHeartRates :many
+SELECT id, created_at, plunge_id, read_at, bpm FROM plunge_heart_rates
+WHERE plunge_id = $1
+ORDER BY read_at ASC
+`
+
+func (q *Queries) GetPlungeHeartRates(ctx context.Context, plungeID uuid.UUID) ([]PlungeHeartRate, error) {
+	rows, err := q.db.QueryContext(ctx, getPlungeHeartRates, plungeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlungeHeartRate
+	for rows.Next() {
+		var i PlungeHeartRate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PlungeID,
+			&i.ReadAt,
+			&i.Bpm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPlungeLeaderboard = `-- name: GetPlungeLeaderboard :many
+SELECT users.id AS user_id, users.email,
+    COUNT(plunges.id) AS sessions,
+    COALESCE(SUM(EXTRACT(EPOCH FROM plunges.end_time - plunges.start_time)), 0)::float8 AS total_seconds,
+    COALESCE(MAX(EXTRACT(EPOCH FROM plunges.end_time - plunges.start_time)), 0)::float8 AS longest_seconds,
+    MAX(plunges.start_time)::timestamp AS last_plunge
+FROM plunges
+JOIN users ON users.id = plunges.user_id
+WHERE plunges.start_time >= $1::timestamp AND plunges.start_time < $2::timestamp AND plunges.end_time IS NOT NULL
+GROUP BY users.id, users.email
+ORDER BY total_seconds DESC, sessions DESC
+`
+
+type GetPlungeLeaderboardParams struct {
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+type GetPlungeLeaderboardRow struct {
+	UserID         uuid.UUID
+	Email          string
+	Sessions       int64
+	TotalSeconds   float64
+	LongestSeconds float64
+	LastPlunge     time.Time
+}
+
+func (q *Queries) GetPlungeLeaderboard(ctx context.Context, arg GetPlungeLeaderboardParams) ([]GetPlungeLeaderboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPlungeLeaderboard, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPlungeLeaderboardRow
+	for rows.Next() {
+		var i GetPlungeLeaderboardRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Email,
+			&i.Sessions,
+			&i.TotalSeconds,
+			&i.LongestSeconds,
+			&i.LastPlunge,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPlungeTemperatures = `-- name: GetPlungeTemperatures :many
+SELECT id, created_at, plunge_id, read_at, water_temp, room_temp FROM plunge_temperatures
+WHERE plunge_id = $1
+ORDER BY read_at ASC
+`
+
+func (q *Queries) GetPlungeTemperatures(ctx context.Context, plungeID uuid.UUID) ([]PlungeTemperature, error) {
+	rows, err := q.db.QueryContext(ctx, getPlungeTemperatures, plungeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlungeTemperature
+	for rows.Next() {
+		var i PlungeTemperature
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PlungeID,
+			&i.ReadAt,
+			&i.WaterTemp,
+			&i.RoomTemp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPlunges = `-- name: GetPlunges :many
-SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp FROM plunges 
-ORDER BY created_at DESC
+SELECT id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags FROM plunges
+WHERE user_id = $1::uuid AND start_time >= $2::timestamp AND start_time < $3::timestamp
+ORDER BY start_time DESC
+LIMIT $4 OFFSET $5
 `
 
-func (q *Queries) GetPlunges(ctx context.Context) ([]Plunge, error) {
-	rows, err := q.db.QueryContext(ctx, getPlunges)
+type GetPlungesParams struct {
+	UserID    uuid.UUID
+	FromTime  time.Time
+	ToTime    time.Time
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) GetPlunges(ctx context.Context, arg GetPlungesParams) ([]Plunge, error) {
+	rows, err := q.db.QueryContext(ctx, getPlunges,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +417,16 @@ func (q *Queries) GetPlunges(ctx context.Context) ([]Plunge, error) {
 			&i.ExpectedDuration,
 			&i.AvgWaterTemp,
 			&i.AvgRoomTemp,
+			&i.UserID,
+			&i.MinWaterTemp,
+			&i.MaxWaterTemp,
+			&i.MinRoomTemp,
+			&i.MaxRoomTemp,
+			&i.ProtocolID,
+			&i.ProtocolPhases,
+			&i.Notes,
+			&i.PerceivedEffort,
+			pq.Array(&i.Tags),
 		); err != nil {
 			return nil, err
 		}
@@ -107,11 +441,63 @@ func (q *Queries) GetPlunges(ctx context.Context) ([]Plunge, error) {
 	return items, nil
 }
 
+const savePlungeHeartRates = `-- name: SavePlungeHeartRates :execrows
+INSERT INTO plunge_heart_rates (plunge_id, read_at, bpm)
+SELECT $1::uuid, samples.read_at, samples.bpm
+FROM jsonb_to_recordset($2::jsonb) AS samples(read_at TIMESTAMP, bpm INTEGER)
+ON CONFLICT (plunge_id, read_at) DO NOTHING
+`
+
+type SavePlungeHeartRatesParams struct {
+	PlungeID uuid.UUID
+	Samples  json.RawMessage
+}
+
+func (q *Queries) SavePlungeHeartRates(ctx context.Context, arg SavePlungeHeartRatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, savePlungeHeartRates, arg.PlungeID, arg.Samples)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const savePlungeTemperature = `-- name: SavePlungeTemperature :one
+INSERT INTO plunge_temperatures (plunge_id, read_at, water_temp, room_temp)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at, plunge_id, read_at, water_temp, room_temp
+`
+
+type SavePlungeTemperatureParams struct {
+	PlungeID  uuid.UUID
+	ReadAt    time.Time
+	WaterTemp sql.NullString
+	RoomTemp  sql.NullString
+}
+
+func (q *Queries) SavePlungeTemperature(ctx context.Context, arg SavePlungeTemperatureParams) (PlungeTemperature, error) {
+	row := q.db.QueryRowContext(ctx, savePlungeTemperature,
+		arg.PlungeID,
+		arg.ReadAt,
+		arg.WaterTemp,
+		arg.RoomTemp,
+	)
+	var i PlungeTemperature
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PlungeID,
+		&i.ReadAt,
+		&i.WaterTemp,
+		&i.RoomTemp,
+	)
+	return i, err
+}
+
 const startPlunge = `-- name: StartPlunge :one
 INSERT INTO plunges (
-    start_time, start_water_temp, start_room_temp, expected_duration, running) 
-VALUES ( $1, $2, $3, $4, true) 
-RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp
+    start_time, start_water_temp, start_room_temp, expected_duration, user_id, protocol_id, protocol_phases, running) 
+VALUES ( $1, $2, $3, $4, $5, $6, $7, true) 
+RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags
 `
 
 type StartPlungeParams struct {
@@ -119,6 +505,9 @@ type StartPlungeParams struct {
 	StartWaterTemp   string
 	StartRoomTemp    string
 	ExpectedDuration int32
+	UserID           uuid.NullUUID
+	ProtocolID       uuid.NullUUID
+	ProtocolPhases   json.RawMessage
 }
 
 func (q *Queries) StartPlunge(ctx context.Context, arg StartPlungeParams) (Plunge, error) {
@@ -127,6 +516,9 @@ func (q *Queries) StartPlunge(ctx context.Context, arg StartPlungeParams) (Plung
 		arg.StartWaterTemp,
 		arg.StartRoomTemp,
 		arg.ExpectedDuration,
+		arg.UserID,
+		arg.ProtocolID,
+		arg.ProtocolPhases,
 	)
 	var i Plunge
 	err := row.Scan(
@@ -143,6 +535,16 @@ func (q *Queries) StartPlunge(ctx context.Context, arg StartPlungeParams) (Plung
 		&i.ExpectedDuration,
 		&i.AvgWaterTemp,
 		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
 	)
 	return i, err
 }
@@ -150,8 +552,8 @@ func (q *Queries) StartPlunge(ctx context.Context, arg StartPlungeParams) (Plung
 const stopPlunge = `-- name: StopPlunge :one
 UPDATE plunges
 SET end_time = $1, end_water_temp = $2, end_room_temp = $3, running = FALSE, updated_at = CURRENT_TIMESTAMP
-WHERE id = $4
-RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp
+WHERE id = $4 AND running
+RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags
 `
 
 type StopPlungeParams struct {
@@ -183,25 +585,90 @@ func (q *Queries) StopPlunge(ctx context.Context, arg StopPlungeParams) (Plunge,
 		&i.ExpectedDuration,
 		&i.AvgWaterTemp,
 		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
 	)
 	return i, err
 }
 
-const updatePlungeAvgTemp = `-- name: UpdatePlungeAvgTemp :one
+const updatePlungeJournal = `-- name: UpdatePlungeJournal :one
 UPDATE plunges
-SET avg_water_temp = $1, avg_room_temp = $2
-WHERE id = $3
-RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp
+SET notes = $2, perceived_effort = $3, tags = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, created_at, updated_at, start_time, start_water_temp, start_room_temp, end_time, end_water_temp, end_room_temp, running, expected_duration, avg_water_temp, avg_room_temp, user_id, min_water_temp, max_water_temp, min_room_temp, max_room_temp, protocol_id, protocol_phases, notes, perceived_effort, tags
 `
 
-type UpdatePlungeAvgTempParams struct {
-	AvgWaterTemp string
-	AvgRoomTemp  string
-	ID           uuid.UUID
+type UpdatePlungeJournalParams struct {
+	ID              uuid.UUID
+	Notes           string
+	PerceivedEffort sql.NullInt32
+	Tags            []string
+}
+
+func (q *Queries) UpdatePlungeJournal(ctx context.Context, arg UpdatePlungeJournalParams) (Plunge, error) {
+	row := q.db.QueryRowContext(ctx, updatePlungeJournal,
+		arg.ID,
+		arg.Notes,
+		arg.PerceivedEffort,
+		pq.Array(arg.Tags),
+	)
+	var i Plunge
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartTime,
+		&i.StartWaterTemp,
+		&i.StartRoomTemp,
+		&i.EndTime,
+		&i.EndWaterTemp,
+		&i.EndRoomTemp,
+		&i.Running,
+		&i.ExpectedDuration,
+		&i.AvgWaterTemp,
+		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
+	)
+	return i, err
 }
 
-func (q *Queries) UpdatePlungeAvgTemp(ctx context.Context, arg UpdatePlungeAvgTempParams) (Plunge, error) {
-	row := q.db.QueryRowContext(ctx, updatePlungeAvgTemp, arg.AvgWaterTemp, arg.AvgRoomTemp, arg.ID)
+const updatePlungeTemperatureSummary = `-- name: UpdatePlungeTemperatureSummary :one
+UPDATE plunges
+SET avg_water_temp = COALESCE(samples.avg_water_temp, plunges.avg_water_temp),
+    min_water_temp = COALESCE(samples.min_water_temp, plunges.min_water_temp),
+    max_water_temp = COALESCE(samples.max_water_temp, plunges.max_water_temp),
+    avg_room_temp = COALESCE(samples.avg_room_temp, plunges.avg_room_temp),
+    min_room_temp = COALESCE(samples.min_room_temp, plunges.min_room_temp),
+    max_room_temp = COALESCE(samples.max_room_temp, plunges.max_room_temp)
+FROM (
+    SELECT AVG(water_temp) AS avg_water_temp, MIN(water_temp) AS min_water_temp, MAX(water_temp) AS max_water_temp,
+        AVG(room_temp) AS avg_room_temp, MIN(room_temp) AS min_room_temp, MAX(room_temp) AS max_room_temp
+    FROM plunge_temperatures
+    WHERE plunge_temperatures.plunge_id = $1
+) AS samples
+WHERE plunges.id = $1
+RETURNING plunges.*
+`
+
+func (q *Queries) UpdatePlungeTemperatureSummary(ctx context.Context, plungeID uuid.UUID) (Plunge, error) {
+	row := q.db.QueryRowContext(ctx, updatePlungeTemperatureSummary, plungeID)
 	var i Plunge
 	err := row.Scan(
 		&i.ID,
@@ -217,6 +684,16 @@ func (q *Queries) UpdatePlungeAvgTemp(ctx context.Context, arg UpdatePlungeAvgTe
 		&i.ExpectedDuration,
 		&i.AvgWaterTemp,
 		&i.AvgRoomTemp,
+		&i.UserID,
+		&i.MinWaterTemp,
+		&i.MaxWaterTemp,
+		&i.MinRoomTemp,
+		&i.MaxRoomTemp,
+		&i.ProtocolID,
+		&i.ProtocolPhases,
+		&i.Notes,
+		&i.PerceivedEffort,
+		pq.Array(&i.Tags),
 	)
 	return i, err
 }
@@ -0,0 +1,43 @@
+-- name: CreateAlertRule :one
+INSERT INTO alert_rules (
+    name, device, condition, threshold_f, offline_seconds, cooldown_seconds, enabled, one_shot)
+VALUES ( $1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetAlertRules :many
+SELECT * FROM alert_rules
+ORDER BY created_at ASC;
+
+-- name: GetAlertRule :one
+SELECT * FROM alert_rules
+WHERE id = $1;
+
+-- name: GetEnabledAlertRules :many
+SELECT * FROM alert_rules
+WHERE enabled = TRUE
+ORDER BY created_at ASC;
+
+-- name: UpdateAlertRule :one
+UPDATE alert_rules
+SET name = $2,
+    device = $3,
+    condition = $4,
+    threshold_f = $5,
+    offline_seconds = $6,
+    cooldown_seconds = $7,
+    enabled = $8,
+    one_shot = $9,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAlertRule :execrows
+DELETE FROM alert_rules
+WHERE id = $1;
+
+-- name: MarkAlertRuleTriggered :exec
+UPDATE alert_rules
+SET last_triggered_at = $2,
+    enabled = enabled AND NOT one_shot,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
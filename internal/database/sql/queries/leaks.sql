@@ -4,12 +4,57 @@ ORDER BY created_at DESC
 LIMIT 1;
 
 -- name: CreateLeakDetected :one
-INSERT INTO leaks (detected_at)
-VALUES ($1)
+INSERT INTO leaks (detected_at, sensor_id, zone)
+VALUES ($1, $2, $3)
 RETURNING *;
 
+-- name: GetActiveLeak :one
+SELECT * FROM leaks
+WHERE sensor_id = $1 AND cleared_at IS NULL
+ORDER BY detected_at DESC
+LIMIT 1;
+
 -- name: ClearDetectedLeak :one
 UPDATE leaks
 SET cleared_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING *;
+
+-- name: ClearSensorLeaks :many
+UPDATE leaks
+SET cleared_at = CURRENT_TIMESTAMP
+WHERE sensor_id = $1 AND cleared_at IS NULL
+RETURNING *;
+
+-- name: GetLeaks :many
+SELECT * FROM leaks
+WHERE detected_at >= sqlc.arg(from_time) AND detected_at < sqlc.arg(to_time)
+ORDER BY detected_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountLeaks :one
+SELECT COUNT(*) FROM leaks
+WHERE detected_at >= sqlc.arg(from_time) AND detected_at < sqlc.arg(to_time);
+
+-- name: GetOverlappingLeaks :many
+SELECT * FROM leaks
+WHERE detected_at < sqlc.arg(to_time) AND (cleared_at IS NULL OR cleared_at > sqlc.arg(from_time))
+ORDER BY detected_at ASC;
+
+-- name: GetLeak :one
+SELECT * FROM leaks
+WHERE id = $1;
+
+-- name: AcknowledgeLeak :one
+UPDATE leaks
+SET acknowledged_at = $2,
+    acknowledged_by = $3,
+    acknowledgement_note = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUnacknowledgedLeaks :many
+SELECT * FROM leaks
+WHERE acknowledged_at IS NULL
+ORDER BY detected_at ASC;
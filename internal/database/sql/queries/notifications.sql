@@ -0,0 +1,37 @@
+-- name: CreateNotification :one
+INSERT INTO notification_outbox (
+    event_type, severity, source, title, message, payload, occurred_at, channel, phone_number, email, next_attempt_at)
+VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: GetPendingNotifications :many
+SELECT * FROM notification_outbox
+WHERE status = 'pending' AND next_attempt_at <= $1
+ORDER BY next_attempt_at ASC
+LIMIT $2;
+
+-- name: GetNotifications :many
+SELECT * FROM notification_outbox
+WHERE (sqlc.arg(status)::text = '' OR status = sqlc.arg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkNotificationSent :one
+UPDATE notification_outbox
+SET status = 'sent',
+    attempts = attempts + 1,
+    sent_at = $2,
+    last_error = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkNotificationFailed :one
+UPDATE notification_outbox
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    last_error = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
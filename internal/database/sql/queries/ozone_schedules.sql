@@ -0,0 +1,44 @@
+-- name: CreateOzoneSchedule :one
+INSERT INTO ozone_schedules (
+    name, cron_expression, duration_minutes, enabled)
+VALUES ( $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetOzoneSchedules :many
+SELECT * FROM ozone_schedules
+ORDER BY created_at ASC;
+
+-- name: GetOzoneSchedule :one
+SELECT * FROM ozone_schedules
+WHERE id = $1;
+
+-- name: GetEnabledOzoneSchedules :many
+SELECT * FROM ozone_schedules
+WHERE enabled = TRUE
+ORDER BY created_at ASC;
+
+-- name: UpdateOzoneSchedule :one
+UPDATE ozone_schedules
+SET name = $2,
+    cron_expression = $3,
+    duration_minutes = $4,
+    enabled = $5,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteOzoneSchedule :execrows
+DELETE FROM ozone_schedules
+WHERE id = $1;
+
+-- name: CreateOzoneScheduleRun :one
+INSERT INTO ozone_schedule_runs (
+    schedule_id, scheduled_for, started, skip_reason)
+VALUES ( $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetOzoneScheduleRuns :many
+SELECT * FROM ozone_schedule_runs
+WHERE schedule_id = $1
+ORDER BY scheduled_for DESC
+LIMIT $2;
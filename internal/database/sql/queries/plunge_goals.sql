@@ -0,0 +1,19 @@
+-- name: CreatePlungeGoal :one
+INSERT INTO plunge_goals (user_id, name, kind, target, below_temp)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetPlungeGoals :many
+SELECT * FROM plunge_goals
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdatePlungeGoal :one
+UPDATE plunge_goals
+SET name = $3, kind = $4, target = $5, below_temp = $6, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeletePlungeGoal :execrows
+DELETE FROM plunge_goals
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,38 @@
+-- name: CreatePlungeProtocol :one
+INSERT INTO plunge_protocols (
+    name, description, rounds, phases)
+VALUES ( $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPlungeProtocols :many
+SELECT * FROM plunge_protocols
+ORDER BY name ASC;
+
+-- name: GetPlungeProtocol :one
+SELECT * FROM plunge_protocols
+WHERE id = $1;
+
+-- name: UpdatePlungeProtocol :one
+UPDATE plunge_protocols
+SET name = $2,
+    description = $3,
+    rounds = $4,
+    phases = $5,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePlungeProtocol :execrows
+DELETE FROM plunge_protocols
+WHERE id = $1;
+
+-- name: RecordPlungePhase :exec
+INSERT INTO plunge_phases (
+    plunge_id, position, name, kind, duration_seconds, started_at, ended_at, completed)
+VALUES ( $1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (plunge_id, position) DO NOTHING;
+
+-- name: GetPlungePhases :many
+SELECT * FROM plunge_phases
+WHERE plunge_id = $1
+ORDER BY position ASC;
@@ -1,19 +1,46 @@
 -- name: StartPlunge :one
 INSERT INTO plunges (
-    start_time, start_water_temp, start_room_temp, expected_duration, running) 
-VALUES ( $1, $2, $3, $4, true) 
+    start_time, start_water_temp, start_room_temp, expected_duration, user_id, protocol_id, protocol_phases, running) 
+VALUES ( $1, $2, $3, $4, $5, $6, $7, true) 
 RETURNING *;
 
--- name: UpdatePlungeAvgTemp :one
-UPDATE plunges
-SET avg_water_temp = $1, avg_room_temp = $2
-WHERE id = $3
+-- name: SavePlungeTemperature :one
+INSERT INTO plunge_temperatures (plunge_id, read_at, water_temp, room_temp)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
+-- name: GetPlungeTemperatures :many
+SELECT * FROM plunge_temperatures
+WHERE plunge_id = $1
+ORDER BY read_at ASC;
+
+-- name: GetLastPlungeTemperature :one
+SELECT * FROM plunge_temperatures
+WHERE plunge_id = $1 AND read_at <= $2
+ORDER BY read_at DESC
+LIMIT 1;
+
+-- name: UpdatePlungeTemperatureSummary :one
+UPDATE plunges
+SET avg_water_temp = COALESCE(samples.avg_water_temp, plunges.avg_water_temp),
+    min_water_temp = COALESCE(samples.min_water_temp, plunges.min_water_temp),
+    max_water_temp = COALESCE(samples.max_water_temp, plunges.max_water_temp),
+    avg_room_temp = COALESCE(samples.avg_room_temp, plunges.avg_room_temp),
+    min_room_temp = COALESCE(samples.min_room_temp, plunges.min_room_temp),
+    max_room_temp = COALESCE(samples.max_room_temp, plunges.max_room_temp)
+FROM (
+    SELECT AVG(water_temp) AS avg_water_temp, MIN(water_temp) AS min_water_temp, MAX(water_temp) AS max_water_temp,
+        AVG(room_temp) AS avg_room_temp, MIN(room_temp) AS min_room_temp, MAX(room_temp) AS max_room_temp
+    FROM plunge_temperatures
+    WHERE plunge_temperatures.plunge_id = $1
+) AS samples
+WHERE plunges.id = $1
+RETURNING plunges.*;
+
 -- name: StopPlunge :one
 UPDATE plunges
 SET end_time = $1, end_water_temp = $2, end_room_temp = $3, running = FALSE, updated_at = CURRENT_TIMESTAMP
-WHERE id = $4
+WHERE id = $4 AND running
 RETURNING *;
 
 -- name: GetLatestPlunge :one
@@ -22,10 +49,60 @@ ORDER BY created_at DESC
 LIMIT 1;
 
 -- name: GetPlunges :many
-SELECT * FROM plunges 
-ORDER BY created_at DESC;
+SELECT * FROM plunges
+WHERE user_id = sqlc.arg(user_id)::uuid AND start_time >= sqlc.arg(from_time)::timestamp AND start_time < sqlc.arg(to_time)::timestamp
+ORDER BY start_time DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountPlunges :one
+SELECT COUNT(*) FROM plunges
+WHERE user_id = sqlc.arg(user_id)::uuid AND start_time >= sqlc.arg(from_time)::timestamp AND start_time < sqlc.arg(to_time)::timestamp;
+
+-- name: GetCompletedPlunges :many
+SELECT * FROM plunges
+WHERE user_id = sqlc.arg(user_id)::uuid AND start_time >= sqlc.arg(from_time)::timestamp AND start_time < sqlc.arg(to_time)::timestamp AND end_time IS NOT NULL
+ORDER BY start_time ASC;
 
 -- name: GetPlungeByID :one
 SELECT * FROM plunges
 WHERE id = $1;
 
+-- name: GetPlungeLeaderboard :many
+SELECT users.id AS user_id, users.email,
+    COUNT(plunges.id) AS sessions,
+    COALESCE(SUM(EXTRACT(EPOCH FROM plunges.end_time - plunges.start_time)), 0)::float8 AS total_seconds,
+    COALESCE(MAX(EXTRACT(EPOCH FROM plunges.end_time - plunges.start_time)), 0)::float8 AS longest_seconds,
+    MAX(plunges.start_time)::timestamp AS last_plunge
+FROM plunges
+JOIN users ON users.id = plunges.user_id
+WHERE plunges.start_time >= sqlc.arg(from_time)::timestamp AND plunges.start_time < sqlc.arg(to_time)::timestamp AND plunges.end_time IS NOT NULL
+GROUP BY users.id, users.email
+ORDER BY total_seconds DESC, sessions DESC;
+
+-- name: UpdatePlungeJournal :one
+UPDATE plunges
+SET notes = $2, perceived_effort = $3, tags = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePlungeHeartRates :exec
+DELETE FROM plunge_heart_rates
+WHERE plunge_id = $1;
+
+-- name: SavePlungeHeartRates :execrows
+INSERT INTO plunge_heart_rates (plunge_id, read_at, bpm)
+SELECT sqlc.arg(plunge_id)::uuid, samples.read_at, samples.bpm
+FROM jsonb_to_recordset(sqlc.arg(samples)::jsonb) AS samples(read_at TIMESTAMP, bpm INTEGER)
+ON CONFLICT (plunge_id, read_at) DO NOTHING;
+
+-- name: GetPlungeHeartRates :many
+SELECT * FROM plunge_heart_rates
+WHERE plunge_id = $1
+ORDER BY read_at ASC;
+
+-- name: GetCompletedPlungeTemperatures :many
+SELECT plunge_temperatures.id, plunge_temperatures.created_at, plunge_temperatures.plunge_id, plunge_temperatures.read_at, plunge_temperatures.water_temp, plunge_temperatures.room_temp
+FROM plunge_temperatures
+JOIN plunges ON plunges.id = plunge_temperatures.plunge_id
+WHERE plunges.user_id = sqlc.arg(user_id)::uuid AND plunges.start_time >= sqlc.arg(from_time)::timestamp AND plunges.start_time < sqlc.arg(to_time)::timestamp AND plunges.end_time IS NOT NULL
+ORDER BY plunge_temperatures.plunge_id, plunge_temperatures.read_at ASC;
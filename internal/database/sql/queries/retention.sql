@@ -0,0 +1,36 @@
+-- name: RollupTemperatureReadings :one
+WITH moved AS (
+    DELETE FROM temperature_readings
+    WHERE read_at < $1
+    RETURNING read_at, device_address, device_name, device_role, temperature_c, temperature_f
+), rolled AS (
+    INSERT INTO temperature_rollups (
+        bucket, device_address, device_name, device_role, min_c, max_c, avg_c, min_f, max_f, avg_f, sample_count)
+    SELECT date_trunc('hour', read_at), device_address, device_name, device_role,
+        MIN(temperature_c), MAX(temperature_c), AVG(temperature_c),
+        MIN(temperature_f), MAX(temperature_f), AVG(temperature_f),
+        COUNT(*)
+    FROM moved
+    GROUP BY date_trunc('hour', read_at), device_address, device_name, device_role
+    ON CONFLICT (bucket, device_address, device_name, device_role) DO UPDATE SET
+        min_c = LEAST(temperature_rollups.min_c, EXCLUDED.min_c),
+        max_c = GREATEST(temperature_rollups.max_c, EXCLUDED.max_c),
+        avg_c = (temperature_rollups.avg_c * temperature_rollups.sample_count + EXCLUDED.avg_c * EXCLUDED.sample_count) / (temperature_rollups.sample_count + EXCLUDED.sample_count),
+        min_f = LEAST(temperature_rollups.min_f, EXCLUDED.min_f),
+        max_f = GREATEST(temperature_rollups.max_f, EXCLUDED.max_f),
+        avg_f = (temperature_rollups.avg_f * temperature_rollups.sample_count + EXCLUDED.avg_f * EXCLUDED.sample_count) / (temperature_rollups.sample_count + EXCLUDED.sample_count),
+        sample_count = temperature_rollups.sample_count + EXCLUDED.sample_count
+    RETURNING 1
+)
+SELECT (SELECT COUNT(*) FROM moved) AS readings_rolled_up,
+    (SELECT COUNT(*) FROM rolled) AS rollups_written;
+
+-- name: PruneTemperatureRollups :execrows
+DELETE FROM temperature_rollups
+WHERE bucket < $1;
+
+-- name: CreateRetentionRun :one
+INSERT INTO retention_runs (
+    raw_cutoff, rollup_cutoff, readings_rolled_up, rollups_written, rollups_pruned, error_message)
+VALUES ( $1, $2, $3, $4, $5, $6)
+RETURNING *;
@@ -1,10 +1,52 @@
--- name: SaveTemperature :one
-INSERT INTO temperatures (
-    water_temp, room_temp) 
-VALUES ( $1, $2) 
+-- name: SaveTemperatureReading :one
+INSERT INTO temperature_readings (
+    read_at, device_address, device_name, device_role, temperature_c, temperature_f)
+VALUES ( $1, $2, $3, $4, $5, $6)
 RETURNING *;
 
--- name: FindMostRecentTemperatures :one
-SELECT * FROM temperatures
-ORDER BY created_at DESC
+-- name: GetLatestTemperatureByRole :one
+SELECT * FROM temperature_readings
+WHERE device_role = $1
+ORDER BY read_at DESC
 LIMIT 1;
+
+-- name: GetTemperatureReadings :many
+SELECT * FROM temperature_readings
+WHERE (sqlc.arg(device)::text = '' OR device_address = sqlc.arg(device) OR device_name = sqlc.arg(device))
+  AND read_at >= sqlc.arg(from_time) AND read_at < sqlc.arg(to_time)
+ORDER BY read_at ASC, id ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountTemperatureReadings :one
+SELECT COUNT(*) FROM temperature_readings
+WHERE (sqlc.arg(device)::text = '' OR device_address = sqlc.arg(device) OR device_name = sqlc.arg(device))
+  AND read_at >= sqlc.arg(from_time) AND read_at < sqlc.arg(to_time);
+
+-- name: GetTemperatureAggregates :many
+SELECT date_trunc(sqlc.arg(bucket_interval)::text, s.read_at)::timestamp AS bucket,
+    s.device_name,
+    s.device_role,
+    MIN(s.min_c)::float8 AS min_c,
+    (SUM(s.sum_c) / SUM(s.sample_count))::float8 AS avg_c,
+    MAX(s.max_c)::float8 AS max_c,
+    MIN(s.min_f)::float8 AS min_f,
+    (SUM(s.sum_f) / SUM(s.sample_count))::float8 AS avg_f,
+    MAX(s.max_f)::float8 AS max_f,
+    SUM(s.sample_count)::bigint AS sample_count
+FROM (
+    SELECT read_at, device_address, device_name, device_role,
+        temperature_c AS min_c, temperature_c AS max_c, temperature_c AS sum_c,
+        temperature_f AS min_f, temperature_f AS max_f, temperature_f AS sum_f,
+        1 AS sample_count
+    FROM temperature_readings
+    UNION ALL
+    SELECT bucket, device_address, device_name, device_role,
+        min_c, max_c, avg_c * sample_count,
+        min_f, max_f, avg_f * sample_count,
+        sample_count
+    FROM temperature_rollups
+) s
+WHERE (sqlc.arg(device)::text = '' OR s.device_address = sqlc.arg(device) OR s.device_name = sqlc.arg(device))
+  AND s.read_at >= sqlc.arg(from_time) AND s.read_at < sqlc.arg(to_time)
+GROUP BY 1, s.device_name, s.device_role
+ORDER BY 1 ASC, s.device_name ASC;
@@ -0,0 +1,10 @@
+-- name: GetLatestThermostatSettings :one
+SELECT * FROM thermostat_settings
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: SaveThermostatSettings :one
+INSERT INTO thermostat_settings (
+    mode, setpoint_f, hysteresis_f, min_on_seconds, min_off_seconds, max_cycles_per_hour)
+VALUES ( $1, $2, $3, $4, $5, $6)
+RETURNING *;
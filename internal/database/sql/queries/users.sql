@@ -9,3 +9,38 @@ RETURNING *;
 SELECT * FROM users
 WHERE api_key = $1 LIMIT 1;
 
+
+-- name: UpdateUserNotifications :one
+UPDATE users
+SET phone_number = $2,
+    notify_sms = $3,
+    notify_email = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: MuteUser :one
+UPDATE users
+SET muted_until = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserSubscriptions :many
+SELECT event_type FROM user_subscriptions
+WHERE user_id = $1
+ORDER BY event_type ASC;
+
+-- name: DeleteUserSubscriptions :exec
+DELETE FROM user_subscriptions
+WHERE user_id = $1;
+
+-- name: CreateUserSubscriptions :exec
+INSERT INTO user_subscriptions (user_id, event_type)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(event_types)::text[]);
+
+-- name: GetSubscribedUsers :many
+SELECT DISTINCT users.* FROM users
+JOIN user_subscriptions ON user_subscriptions.user_id = users.id
+WHERE user_subscriptions.event_type = $1 OR user_subscriptions.event_type = '*'
+ORDER BY users.email ASC;
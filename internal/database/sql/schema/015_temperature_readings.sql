@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE temperature_readings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    read_at TIMESTAMP NOT NULL,
+    device_address VARCHAR(64) NOT NULL,
+    device_name VARCHAR(100) NOT NULL,
+    device_role VARCHAR(50) NOT NULL,
+    temperature_c NUMERIC(5, 2) NOT NULL,
+    temperature_f NUMERIC(5, 2) NOT NULL
+);
+
+CREATE INDEX temperature_readings_address_read_at_idx ON temperature_readings (device_address, read_at);
+CREATE INDEX temperature_readings_name_read_at_idx ON temperature_readings (device_name, read_at);
+CREATE INDEX temperature_readings_role_read_at_idx ON temperature_readings (device_role, read_at);
+
+INSERT INTO temperature_readings (read_at, device_address, device_name, device_role, temperature_c, temperature_f)
+SELECT created_at, '', 'Water', 'water', (water_temp - 32) * 5 / 9, water_temp
+FROM temperatures
+WHERE water_temp IS NOT NULL;
+
+INSERT INTO temperature_readings (read_at, device_address, device_name, device_role, temperature_c, temperature_f)
+SELECT created_at, '', 'Room', 'room', (room_temp - 32) * 5 / 9, room_temp
+FROM temperatures
+WHERE room_temp IS NOT NULL;
+
+DROP TABLE temperatures;
+
+-- +goose Down
+CREATE TABLE temperatures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    water_temp NUMERIC(4, 1),
+    room_temp NUMERIC(4, 1)
+);
+
+INSERT INTO temperatures (created_at, updated_at, water_temp, room_temp)
+SELECT read_at, read_at,
+    MAX(temperature_f) FILTER (WHERE device_role = 'water'),
+    MAX(temperature_f) FILTER (WHERE device_role = 'room')
+FROM temperature_readings
+GROUP BY read_at;
+
+DROP TABLE temperature_readings;
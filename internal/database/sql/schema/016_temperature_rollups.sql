@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE temperature_rollups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    device_address VARCHAR(64) NOT NULL,
+    device_name VARCHAR(100) NOT NULL,
+    device_role VARCHAR(50) NOT NULL,
+    min_c NUMERIC(5, 2) NOT NULL,
+    max_c NUMERIC(5, 2) NOT NULL,
+    avg_c NUMERIC(5, 2) NOT NULL,
+    min_f NUMERIC(5, 2) NOT NULL,
+    max_f NUMERIC(5, 2) NOT NULL,
+    avg_f NUMERIC(5, 2) NOT NULL,
+    sample_count INTEGER NOT NULL,
+    UNIQUE (bucket, device_address, device_name, device_role)
+);
+
+CREATE INDEX temperature_rollups_bucket_idx ON temperature_rollups (bucket);
+
+CREATE TABLE retention_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    raw_cutoff TIMESTAMP NOT NULL,
+    rollup_cutoff TIMESTAMP NOT NULL,
+    readings_rolled_up BIGINT NOT NULL,
+    rollups_written BIGINT NOT NULL,
+    rollups_pruned BIGINT NOT NULL,
+    error_message VARCHAR(255)
+);
+
+-- +goose Down
+DROP TABLE retention_runs;
+DROP TABLE temperature_rollups;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE thermostat_settings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    mode VARCHAR(10) NOT NULL,
+    setpoint_f NUMERIC(5, 2) NOT NULL,
+    hysteresis_f NUMERIC(4, 2) NOT NULL,
+    min_on_seconds INTEGER NOT NULL,
+    min_off_seconds INTEGER NOT NULL,
+    max_cycles_per_hour INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE thermostat_settings;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    device VARCHAR(100) NOT NULL,
+    condition VARCHAR(20) NOT NULL,
+    threshold_f NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    offline_seconds INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    one_shot BOOLEAN NOT NULL DEFAULT FALSE,
+    last_triggered_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE alert_rules;
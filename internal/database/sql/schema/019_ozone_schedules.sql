@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE ozone_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    cron_expression VARCHAR(100) NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE ozone_schedule_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    schedule_id UUID NOT NULL REFERENCES ozone_schedules (id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    started BOOLEAN NOT NULL,
+    skip_reason VARCHAR(255)
+);
+
+CREATE INDEX ozone_schedule_runs_schedule_id_idx ON ozone_schedule_runs (schedule_id, scheduled_for);
+
+-- +goose Down
+DROP TABLE ozone_schedule_runs;
+DROP TABLE ozone_schedules;
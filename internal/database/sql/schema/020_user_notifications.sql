@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN phone_number VARCHAR(20),
+ADD COLUMN notify_sms BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN notify_email BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN muted_until TIMESTAMP;
+
+CREATE TABLE user_subscriptions (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, event_type)
+);
+
+CREATE INDEX user_subscriptions_event_type_idx ON user_subscriptions (event_type);
+
+-- +goose Down
+DROP TABLE user_subscriptions;
+
+ALTER TABLE users
+DROP COLUMN muted_until,
+DROP COLUMN notify_email,
+DROP COLUMN notify_sms,
+DROP COLUMN phone_number;
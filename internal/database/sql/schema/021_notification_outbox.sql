@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE notification_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    source VARCHAR(100) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    channel VARCHAR(100) NOT NULL,
+    phone_number VARCHAR(20),
+    email VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX notification_outbox_pending_idx ON notification_outbox (status, next_attempt_at);
+CREATE INDEX notification_outbox_created_at_idx ON notification_outbox (created_at);
+
+-- +goose Down
+DROP TABLE notification_outbox;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE leaks
+ADD COLUMN acknowledged_at TIMESTAMP,
+ADD COLUMN acknowledged_by UUID REFERENCES users (id) ON DELETE SET NULL,
+ADD COLUMN acknowledgement_note TEXT;
+
+-- earlier versions opened another leak each time the server started with the sensor wet and only ever
+-- cleared the newest one, the older ones are closed so they can't latch the alarm
+UPDATE leaks SET cleared_at = detected_at
+WHERE cleared_at IS NULL
+AND id <> (SELECT id FROM leaks WHERE cleared_at IS NULL ORDER BY detected_at DESC LIMIT 1);
+
+-- leaks that cleared before the alarm was latched don't need to be acknowledged
+UPDATE leaks SET acknowledged_at = cleared_at WHERE cleared_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE leaks
+DROP COLUMN acknowledgement_note,
+DROP COLUMN acknowledged_by,
+DROP COLUMN acknowledged_at;
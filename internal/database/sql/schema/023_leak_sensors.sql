@@ -0,0 +1,10 @@
+-- +goose Up
+-- leaks recorded before there could be more than one sensor don't know which sensor tripped
+ALTER TABLE leaks
+ADD COLUMN sensor_id TEXT NOT NULL DEFAULT '',
+ADD COLUMN zone TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE leaks
+DROP COLUMN zone,
+DROP COLUMN sensor_id;
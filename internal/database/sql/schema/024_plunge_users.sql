@@ -0,0 +1,12 @@
+-- +goose Up
+-- plunges recorded before they were attributed to a user have no owner
+ALTER TABLE plunges
+ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX plunges_user_id_start_time_idx ON plunges (user_id, start_time);
+
+-- +goose Down
+DROP INDEX plunges_user_id_start_time_idx;
+
+ALTER TABLE plunges
+DROP COLUMN user_id;
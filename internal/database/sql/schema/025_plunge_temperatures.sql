@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE plunge_temperatures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    plunge_id UUID NOT NULL REFERENCES plunges (id) ON DELETE CASCADE,
+    read_at TIMESTAMP NOT NULL,
+    water_temp NUMERIC(5, 2),
+    room_temp NUMERIC(5, 2)
+);
+
+CREATE INDEX plunge_temperatures_plunge_id_read_at_idx ON plunge_temperatures (plunge_id, read_at);
+
+ALTER TABLE plunges
+ADD COLUMN min_water_temp NUMERIC(4,1) NOT NULL DEFAULT 0.0,
+ADD COLUMN max_water_temp NUMERIC(4,1) NOT NULL DEFAULT 0.0,
+ADD COLUMN min_room_temp NUMERIC(4,1) NOT NULL DEFAULT 0.0,
+ADD COLUMN max_room_temp NUMERIC(4,1) NOT NULL DEFAULT 0.0;
+
+-- +goose Down
+ALTER TABLE plunges
+DROP COLUMN min_water_temp,
+DROP COLUMN max_water_temp,
+DROP COLUMN min_room_temp,
+DROP COLUMN max_room_temp;
+
+DROP TABLE plunge_temperatures;
@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE plunge_protocols (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    rounds INTEGER NOT NULL DEFAULT 1,
+    phases JSONB NOT NULL
+);
+
+INSERT INTO plunge_protocols (name, description, rounds, phases) VALUES
+('3 min cold', 'A single three minute immersion.', 1,
+    '[{"name": "immersion", "kind": "cold", "duration_seconds": 180}]'),
+('breathwork + 3 min immersion', 'Two minutes of breathwork before a three minute immersion.', 1,
+    '[{"name": "breathwork", "kind": "breathwork", "duration_seconds": 120}, {"name": "immersion", "kind": "cold", "duration_seconds": 180}]'),
+('contrast', 'Three rounds of one minute cold and three minutes rest.', 3,
+    '[{"name": "cold", "kind": "cold", "duration_seconds": 60}, {"name": "rest", "kind": "rest", "duration_seconds": 180}]');
+
+-- the phases are copied to the plunge when it starts so editing the protocol doesn't change a running plunge
+ALTER TABLE plunges
+ADD COLUMN protocol_id UUID REFERENCES plunge_protocols (id) ON DELETE SET NULL,
+ADD COLUMN protocol_phases JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE plunge_phases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    plunge_id UUID NOT NULL REFERENCES plunges (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    completed BOOLEAN NOT NULL,
+    UNIQUE (plunge_id, position)
+);
+
+-- +goose Down
+DROP TABLE plunge_phases;
+
+ALTER TABLE plunges
+DROP COLUMN protocol_phases,
+DROP COLUMN protocol_id;
+
+DROP TABLE plunge_protocols;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE plunges
+ADD COLUMN notes TEXT NOT NULL DEFAULT '',
+ADD COLUMN perceived_effort INTEGER CHECK (perceived_effort BETWEEN 1 AND 10),
+ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE plunge_heart_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    plunge_id UUID NOT NULL REFERENCES plunges (id) ON DELETE CASCADE,
+    read_at TIMESTAMP NOT NULL,
+    bpm INTEGER NOT NULL,
+    UNIQUE (plunge_id, read_at)
+);
+
+-- +goose Down
+DROP TABLE plunge_heart_rates;
+
+ALTER TABLE plunges
+DROP COLUMN notes,
+DROP COLUMN perceived_effort,
+DROP COLUMN tags;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE plunge_goals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL CHECK (kind IN ('minutes', 'dose', 'sessions')),
+    target DOUBLE PRECISION NOT NULL CHECK (target > 0),
+    below_temp DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX plunge_goals_user_id_idx ON plunge_goals (user_id);
+
+-- +goose Down
+DROP TABLE plunge_goals;
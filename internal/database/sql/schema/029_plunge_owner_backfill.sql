@@ -0,0 +1,8 @@
+-- +goose Up
+-- plunges recorded before they were attributed to a user belong to the only user when there is just one
+UPDATE plunges
+SET user_id = (SELECT id FROM users)
+WHERE user_id IS NULL AND (SELECT COUNT(*) FROM users) = 1;
+
+-- +goose Down
+-- the plunges that were backfilled can't be told apart from the ones the user started
@@ -0,0 +1,14 @@
+-- +goose Up
+-- only one plunge can run at a time, any but the newest that were left running are stopped first
+-- and end when they were expected to, or when they were last updated, so they count as completed
+UPDATE plunges
+SET running = FALSE,
+    end_time = COALESCE(end_time, start_time + expected_duration * INTERVAL '1 second', updated_at),
+    updated_at = CURRENT_TIMESTAMP
+WHERE running
+AND id <> (SELECT id FROM plunges WHERE running ORDER BY created_at DESC LIMIT 1);
+
+CREATE UNIQUE INDEX plunges_running_idx ON plunges ((true)) WHERE running;
+
+-- +goose Down
+DROP INDEX plunges_running_idx;
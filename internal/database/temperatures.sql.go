@@ -7,49 +7,229 @@ package database
 
 import (
 	"context"
-	"database/sql"
+	"time"
 )
 
-const findMostRecentTemperatures = `-- name: FindMostRecentTemperatures :one
-SELECT id, created_at, updated_at, water_temp, room_temp FROM temperatures
-ORDER BY created_at DESC
+const countTemperatureReadings = `-- name: CountTemperatureReadings :one
+SELECT COUNT(*) FROM temperature_readings
+WHERE ($1::text = '' OR device_address = $1 OR device_name = $1)
+  AND read_at >= $2 AND read_at < $3
+`
+
+type CountTemperatureReadingsParams struct {
+	Device   string
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+func (q *Queries) CountTemperatureReadings(ctx context.Context, arg CountTemperatureReadingsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTemperatureReadings, arg.Device, arg.FromTime, arg.ToTime)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getLatestTemperatureByRole = `-- name: GetLatestTemperatureByRole :one
+SELECT id, created_at, read_at, device_address, device_name, device_role, temperature_c, temperature_f FROM temperature_readings
+WHERE device_role = $1
+ORDER BY read_at DESC
 LIMIT 1
 `
 
-func (q *Queries) FindMostRecentTemperatures(ctx context.Context) (Temperature, error) {
-	row := q.db.QueryRowContext(ctx, findMostRecentTemperatures)
-	var i Temperature
+func (q *Queries) GetLatestTemperatureByRole(ctx context.Context, deviceRole string) (TemperatureReading, error) {
+	row := q.db.QueryRowContext(ctx, getLatestTemperatureByRole, deviceRole)
+	var i TemperatureReading
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.WaterTemp,
-		&i.RoomTemp,
+		&i.ReadAt,
+		&i.DeviceAddress,
+		&i.DeviceName,
+		&i.DeviceRole,
+		&i.TemperatureC,
+		&i.TemperatureF,
 	)
 	return i, err
 }
 
-const saveTemperature = `-- name: SaveTemperature :one
-INSERT INTO temperatures (
-    water_temp, room_temp) 
-VALUES ( $1, $2) 
-RETURNING id, created_at, updated_at, water_temp, room_temp
+const getTemperatureAggregates = `-- name: GetTemperatureAggregates :many
+SELECT date_trunc($1::text, s.read_at)::timestamp AS bucket,
+    s.device_name,
+    s.device_role,
+    MIN(s.min_c)::float8 AS min_c,
+    (SUM(s.sum_c) / SUM(s.sample_count))::float8 AS avg_c,
+    MAX(s.max_c)::float8 AS max_c,
+    MIN(s.min_f)::float8 AS min_f,
+    (SUM(s.sum_f) / SUM(s.sample_count))::float8 AS avg_f,
+    MAX(s.max_f)::float8 AS max_f,
+    SUM(s.sample_count)::bigint AS sample_count
+FROM (
+    SELECT read_at, device_address, device_name, device_role,
+        temperature_c AS min_c, temperature_c AS max_c, temperature_c AS sum_c,
+        temperature_f AS min_f, temperature_f AS max_f, temperature_f AS sum_f,
+        1 AS sample_count
+    FROM temperature_readings
+    UNION ALL
+    SELECT bucket, device_address, device_name, device_role,
+        min_c, max_c, avg_c * sample_count,
+        min_f, max_f, avg_f * sample_count,
+        sample_count
+    FROM temperature_rollups
+) s
+WHERE ($2::text = '' OR s.device_address = $2 OR s.device_name = $2)
+  AND s.read_at >= $3 AND s.read_at < $4
+GROUP BY 1, s.device_name, s.device_role
+ORDER BY 1 ASC, s.device_name ASC
 `
 
-type SaveTemperatureParams struct {
-	WaterTemp sql.NullString
-	RoomTemp  sql.NullString
+type GetTemperatureAggregatesParams struct {
+	BucketInterval string
+	Device         string
+	FromTime       time.Time
+	ToTime         time.Time
 }
 
-func (q *Queries) SaveTemperature(ctx context.Context, arg SaveTemperatureParams) (Temperature, error) {
-	row := q.db.QueryRowContext(ctx, saveTemperature, arg.WaterTemp, arg.RoomTemp)
-	var i Temperature
+type GetTemperatureAggregatesRow struct {
+	Bucket      time.Time
+	DeviceName  string
+	DeviceRole  string
+	MinC        float64
+	AvgC        float64
+	MaxC        float64
+	MinF        float64
+	AvgF        float64
+	MaxF        float64
+	SampleCount int64
+}
+
+func (q *Queries) GetTemperatureAggregates(ctx context.Context, arg GetTemperatureAggregatesParams) ([]GetTemperatureAggregatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTemperatureAggregates,
+		arg.BucketInterval,
+		arg.Device,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTemperatureAggregatesRow
+	for rows.Next() {
+		var i GetTemperatureAggregatesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.DeviceName,
+			&i.DeviceRole,
+			&i.MinC,
+			&i.AvgC,
+			&i.MaxC,
+			&i.MinF,
+			&i.AvgF,
+			&i.MaxF,
+			&i.SampleCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemperatureReadings = `-- name: GetTemperatureReadings :many
+SELECT id, created_at, read_at, device_address, device_name, device_role, temperature_c, temperature_f FROM temperature_readings
+WHERE ($1::text = '' OR device_address = $1 OR device_name = $1)
+  AND read_at >= $2 AND read_at < $3
+ORDER BY read_at ASC, id ASC
+LIMIT $4 OFFSET $5
+`
+
+type GetTemperatureReadingsParams struct {
+	Device    string
+	FromTime  time.Time
+	ToTime    time.Time
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) GetTemperatureReadings(ctx context.Context, arg GetTemperatureReadingsParams) ([]TemperatureReading, error) {
+	rows, err := q.db.QueryContext(ctx, getTemperatureReadings,
+		arg.Device,
+		arg.FromTime,
+		arg.ToTime,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemperatureReading
+	for rows.Next() {
+		var i TemperatureReading
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ReadAt,
+			&i.DeviceAddress,
+			&i.DeviceName,
+			&i.DeviceRole,
+			&i.TemperatureC,
+			&i.TemperatureF,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const saveTemperatureReading = `-- name: SaveTemperatureReading :one
+INSERT INTO temperature_readings (
+    read_at, device_address, device_name, device_role, temperature_c, temperature_f)
+VALUES ( $1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, read_at, device_address, device_name, device_role, temperature_c, temperature_f
+`
+
+type SaveTemperatureReadingParams struct {
+	ReadAt        time.Time
+	DeviceAddress string
+	DeviceName    string
+	DeviceRole    string
+	TemperatureC  string
+	TemperatureF  string
+}
+
+func (q *Queries) SaveTemperatureReading(ctx context.Context, arg SaveTemperatureReadingParams) (TemperatureReading, error) {
+	row := q.db.QueryRowContext(ctx, saveTemperatureReading,
+		arg.ReadAt,
+		arg.DeviceAddress,
+		arg.DeviceName,
+		arg.DeviceRole,
+		arg.TemperatureC,
+		arg.TemperatureF,
+	)
+	var i TemperatureReading
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.WaterTemp,
-		&i.RoomTemp,
+		&i.ReadAt,
+		&i.DeviceAddress,
+		&i.DeviceName,
+		&i.DeviceRole,
+		&i.TemperatureC,
+		&i.TemperatureF,
 	)
 	return i, err
 }
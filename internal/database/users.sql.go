@@ -7,13 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     email, api_key
 ) VALUES ( $1, encode(sha256(random()::text::bytea), 'hex'))
-RETURNING id, email, created_at, updated_at, api_key
+RETURNING id, email, created_at, updated_at, api_key, phone_number, notify_sms, notify_email, muted_until
 `
 
 func (q *Queries) CreateUser(ctx context.Context, email string) (User, error) {
@@ -25,12 +29,81 @@ func (q *Queries) CreateUser(ctx context.Context, email string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ApiKey,
+		&i.PhoneNumber,
+		&i.NotifySms,
+		&i.NotifyEmail,
+		&i.MutedUntil,
 	)
 	return i, err
 }
 
+const createUserSubscriptions = `-- name: CreateUserSubscriptions :exec
+INSERT INTO user_subscriptions (user_id, event_type)
+SELECT $1::uuid, unnest($2::text[])
+`
+
+type CreateUserSubscriptionsParams struct {
+	UserID     uuid.UUID
+	EventTypes []string
+}
+
+func (q *Queries) CreateUserSubscriptions(ctx context.Context, arg CreateUserSubscriptionsParams) error {
+	_, err := q.db.ExecContext(ctx, createUserSubscriptions, arg.UserID, pq.Array(arg.EventTypes))
+	return err
+}
+
+const deleteUserSubscriptions = `-- name: DeleteUserSubscriptions :exec
+DELETE FROM user_subscriptions
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserSubscriptions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSubscriptions, userID)
+	return err
+}
+
+const getSubscribedUsers = `-- name: GetSubscribedUsers :many
+SELECT DISTINCT users.id, users.email, users.created_at, users.updated_at, users.api_key, users.phone_number, users.notify_sms, users.notify_email, users.muted_until FROM users
+JOIN user_subscriptions ON user_subscriptions.user_id = users.id
+WHERE user_subscriptions.event_type = $1 OR user_subscriptions.event_type = '*'
+ORDER BY users.email ASC
+`
+
+func (q *Queries) GetSubscribedUsers(ctx context.Context, eventType string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getSubscribedUsers, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ApiKey,
+			&i.PhoneNumber,
+			&i.NotifySms,
+			&i.NotifyEmail,
+			&i.MutedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByApiKey = `-- name: GetUserByApiKey :one
-SELECT id, email, created_at, updated_at, api_key FROM users
+SELECT id, email, created_at, updated_at, api_key, phone_number, notify_sms, notify_email, muted_until FROM users
 WHERE api_key = $1 LIMIT 1
 `
 
@@ -43,6 +116,108 @@ func (q *Queries) GetUserByApiKey(ctx context.Context, apiKey string) (User, err
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ApiKey,
+		&i.PhoneNumber,
+		&i.NotifySms,
+		&i.NotifyEmail,
+		&i.MutedUntil,
+	)
+	return i, err
+}
+
+const getUserSubscriptions = `-- name: GetUserSubscriptions :many
+SELECT event_type FROM user_subscriptions
+WHERE user_id = $1
+ORDER BY event_type ASC
+`
+
+func (q *Queries) GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSubscriptions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var event_type string
+		if err := rows.Scan(&event_type); err != nil {
+			return nil, err
+		}
+		items = append(items, event_type)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const muteUser = `-- name: MuteUser :one
+UPDATE users
+SET muted_until = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, email, created_at, updated_at, api_key, phone_number, notify_sms, notify_email, muted_until
+`
+
+type MuteUserParams struct {
+	ID         uuid.UUID
+	MutedUntil sql.NullTime
+}
+
+func (q *Queries) MuteUser(ctx context.Context, arg MuteUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, muteUser, arg.ID, arg.MutedUntil)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ApiKey,
+		&i.PhoneNumber,
+		&i.NotifySms,
+		&i.NotifyEmail,
+		&i.MutedUntil,
+	)
+	return i, err
+}
+
+const updateUserNotifications = `-- name: UpdateUserNotifications :one
+UPDATE users
+SET phone_number = $2,
+    notify_sms = $3,
+    notify_email = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, email, created_at, updated_at, api_key, phone_number, notify_sms, notify_email, muted_until
+`
+
+type UpdateUserNotificationsParams struct {
+	ID          uuid.UUID
+	PhoneNumber sql.NullString
+	NotifySms   bool
+	NotifyEmail bool
+}
+
+func (q *Queries) UpdateUserNotifications(ctx context.Context, arg UpdateUserNotificationsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserNotifications,
+		arg.ID,
+		arg.PhoneNumber,
+		arg.NotifySms,
+		arg.NotifyEmail,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ApiKey,
+		&i.PhoneNumber,
+		&i.NotifySms,
+		&i.NotifyEmail,
+		&i.MutedUntil,
 	)
 	return i, err
 }
@@ -0,0 +1,55 @@
+// Package debounce filters a noisy boolean reading, such as a leak sensor that flaps on condensation.
+package debounce
+
+import "time"
+
+// Debouncer only changes its state once a different reading has been seen for enough consecutive readings,
+// or continuously for long enough, whichever comes first.
+type Debouncer struct {
+	readings int
+	window   time.Duration
+
+	state   bool
+	pending int       // consecutive readings that differ from the state
+	since   time.Time // when the first of the pending readings was seen
+}
+
+// New creates a Debouncer with its initial state.
+// A zero readings or window turns off that condition, if both are zero every reading changes the state.
+func New(initial bool, readings int, window time.Duration) *Debouncer {
+	if readings <= 0 && window <= 0 {
+		readings = 1
+	}
+
+	return &Debouncer{
+		readings: readings,
+		window:   window,
+		state:    initial,
+	}
+}
+
+// State returns the debounced state.
+func (d *Debouncer) State() bool {
+	return d.state
+}
+
+// Update records a reading taken at now and returns the debounced state and whether it changed.
+func (d *Debouncer) Update(reading bool, now time.Time) (bool, bool) {
+	if reading == d.state {
+		d.pending = 0
+		return d.state, false
+	}
+
+	if d.pending == 0 {
+		d.since = now
+	}
+	d.pending++
+
+	if (d.readings > 0 && d.pending >= d.readings) || (d.window > 0 && now.Sub(d.since) >= d.window) {
+		d.state = reading
+		d.pending = 0
+		return d.state, true
+	}
+
+	return d.state, false
+}
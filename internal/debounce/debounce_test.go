@@ -0,0 +1,75 @@
+package debounce
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	interval := 5 * time.Second
+
+	tests := []struct {
+		name     string
+		readings int
+		window   time.Duration
+		input    []bool
+		expected []bool
+	}{
+		{
+			name:     "should change on every reading without a debounce",
+			input:    []bool{true, false, true},
+			expected: []bool{true, false, true},
+		},
+		{
+			name:     "should ignore a single high reading",
+			readings: 3,
+			input:    []bool{true, false, true, true, false},
+			expected: []bool{false, false, false, false, false},
+		},
+		{
+			name:     "should change after consecutive readings",
+			readings: 3,
+			input:    []bool{true, true, true, false, false, false},
+			expected: []bool{false, false, true, true, true, false},
+		},
+		{
+			name:     "should change once the reading held for the window",
+			window:   10 * time.Second,
+			input:    []bool{true, true, true, false, true},
+			expected: []bool{false, false, true, true, true},
+		},
+		{
+			name:     "should change on whichever condition is met first",
+			readings: 10,
+			window:   5 * time.Second,
+			input:    []bool{true, true},
+			expected: []bool{false, true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New(false, tc.readings, tc.window)
+
+			for i, reading := range tc.input {
+				state, _ := d.Update(reading, start.Add(time.Duration(i)*interval))
+				if state != tc.expected[i] {
+					t.Fatalf("reading %d: expected %v, got %v", i, tc.expected[i], state)
+				}
+			}
+		})
+	}
+
+	t.Run("should report when the state changed", func(t *testing.T) {
+		d := New(false, 2, 0)
+
+		if _, changed := d.Update(true, start); changed {
+			t.Errorf("expected no change after the first reading")
+		}
+
+		if state, changed := d.Update(true, start.Add(interval)); !changed || !state || !d.State() {
+			t.Errorf("expected the state to change to true")
+		}
+	})
+}
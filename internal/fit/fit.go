@@ -0,0 +1,226 @@
+// Package fit reads the heart rate from the record messages of a Garmin FIT activity file.
+//
+// A FIT file is a header, a sequence of definition and data messages, and a CRC. A definition message
+// describes the fields of the data messages that follow it with the same local message type. Only the
+// timestamp and heart rate fields of record messages are decoded, every other message is skipped.
+// Compressed timestamp headers are supported, developer fields are skipped and the CRC isn't checked.
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	mesgRecord       = 20
+	fieldTimestamp   = 253
+	fieldHeartRate   = 3
+	invalidHeartRate = 0xFF
+
+	headerCompressed  = 0x80
+	headerDefinition  = 0x40
+	headerDeveloper   = 0x20
+	headerLocalType   = 0x0F
+	compressedType    = 0x60
+	compressedOffset  = 0x1F
+	architectureBig   = 1
+	minimumHeaderSize = 12
+)
+
+var (
+	ErrNotFIT    = errors.New("not a FIT file")
+	ErrTruncated = errors.New("the FIT file is truncated")
+
+	// epoch is the start of FIT timestamps, 1989-12-31 00:00:00 UTC.
+	epoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+type (
+	// HeartRate is the heart rate in beats per minute at a point in time.
+	HeartRate struct {
+		Time time.Time
+		BPM  int
+	}
+
+	fieldDefinition struct {
+		num  byte
+		size int
+	}
+
+	definition struct {
+		global uint16
+		order  binary.ByteOrder
+		fields []fieldDefinition
+		// developer is the size of the developer fields at the end of each message.
+		developer int
+	}
+
+	decoder struct {
+		data        []byte
+		pos         int
+		definitions map[byte]*definition
+		timestamp   uint32
+	}
+)
+
+// IsFIT reports if the data starts with a FIT file header.
+func IsFIT(data []byte) bool {
+	return len(data) >= minimumHeaderSize && int(data[0]) >= minimumHeaderSize && bytes.Equal(data[8:12], []byte(".FIT"))
+}
+
+// HeartRates returns the heart rate of each record message that has one, in the order they were recorded.
+func HeartRates(data []byte) ([]HeartRate, error) {
+	if !IsFIT(data) {
+		return nil, ErrNotFIT
+	}
+
+	headerSize := int(data[0])
+	end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+	if len(data) < end {
+		return nil, ErrTruncated
+	}
+
+	d := decoder{
+		data:        data[:end],
+		pos:         headerSize,
+		definitions: make(map[byte]*definition),
+	}
+
+	heartRates := make([]HeartRate, 0)
+	for d.pos < len(d.data) {
+		hr, ok, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			heartRates = append(heartRates, hr)
+		}
+	}
+
+	return heartRates, nil
+}
+
+// next reads a message, it returns the heart rate if the message is a record with a valid heart rate.
+func (d *decoder) next() (HeartRate, bool, error) {
+	header, err := d.read(1)
+	if err != nil {
+		return HeartRate{}, false, err
+	}
+
+	if header[0]&headerCompressed != 0 {
+		// the offset is the low 5 bits of the time since the last full timestamp, which may have rolled over
+		offset := uint32(header[0] & compressedOffset)
+		timestamp := d.timestamp&^compressedOffset + offset
+		if offset < d.timestamp&compressedOffset {
+			timestamp += compressedOffset + 1
+		}
+
+		d.timestamp = timestamp
+		return d.message((header[0]&compressedType)>>5, true)
+	}
+
+	local := header[0] & headerLocalType
+	if header[0]&headerDefinition != 0 {
+		return HeartRate{}, false, d.definition(local, header[0]&headerDeveloper != 0)
+	}
+
+	return d.message(local, false)
+}
+
+func (d *decoder) definition(local byte, developer bool) error {
+	fixed, err := d.read(5)
+	if err != nil {
+		return err
+	}
+
+	def := definition{order: binary.LittleEndian}
+	if fixed[1] == architectureBig {
+		def.order = binary.BigEndian
+	}
+
+	def.global = def.order.Uint16(fixed[2:4])
+
+	fields, err := d.read(int(fixed[4]) * 3)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fieldDefinition{num: fields[i], size: int(fields[i+1])})
+	}
+
+	if developer {
+		count, err := d.read(1)
+		if err != nil {
+			return err
+		}
+
+		developerFields, err := d.read(int(count[0]) * 3)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(developerFields); i += 3 {
+			def.developer += int(developerFields[i+1])
+		}
+	}
+
+	d.definitions[local] = &def
+
+	return nil
+}
+
+func (d *decoder) message(local byte, compressed bool) (HeartRate, bool, error) {
+	def, ok := d.definitions[local]
+	if !ok {
+		return HeartRate{}, false, fmt.Errorf("data message for undefined local type %d", local)
+	}
+
+	bpm := invalidHeartRate
+	for _, field := range def.fields {
+		value, err := d.read(field.size)
+		if err != nil {
+			return HeartRate{}, false, err
+		}
+
+		if def.global != mesgRecord {
+			continue
+		}
+
+		switch {
+		case field.num == fieldTimestamp && field.size == 4:
+			d.timestamp = def.order.Uint32(value)
+		case field.num == fieldHeartRate && field.size == 1:
+			bpm = int(value[0])
+		}
+	}
+
+	if _, err := d.read(def.developer); err != nil {
+		return HeartRate{}, false, err
+	}
+
+	// a record without a timestamp of its own is at the time of the last one, it is skipped if there wasn't one
+	if def.global != mesgRecord || bpm == invalidHeartRate || (d.timestamp == 0 && !compressed) {
+		return HeartRate{}, false, nil
+	}
+
+	return HeartRate{
+		Time: epoch.Add(time.Duration(d.timestamp) * time.Second),
+		BPM:  bpm,
+	}, true, nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, ErrTruncated
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
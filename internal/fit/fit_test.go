@@ -0,0 +1,104 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testFile builds a FIT file from the messages, with a 14 byte header and an unchecked CRC.
+func testFile(messages ...[]byte) []byte {
+	body := bytes.Join(messages, nil)
+
+	header := make([]byte, 14)
+	header[0] = 14
+	header[1] = 0x20
+	binary.LittleEndian.PutUint16(header[2:4], 2132)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(body)))
+	copy(header[8:12], ".FIT")
+
+	return append(append(header, body...), 0, 0)
+}
+
+// recordDefinition defines local type 0 as a record with a timestamp, a heart rate and a cadence.
+func recordDefinition() []byte {
+	return []byte{
+		headerDefinition, 0, 0, mesgRecord, 0, 3,
+		fieldTimestamp, 4, 0x86,
+		fieldHeartRate, 1, 0x02,
+		4, 1, 0x02,
+	}
+}
+
+func record(t time.Time, bpm byte) []byte {
+	message := []byte{0}
+	message = binary.LittleEndian.AppendUint32(message, uint32(t.Sub(epoch).Seconds()))
+
+	return append(message, bpm, 90)
+}
+
+func TestHeartRates(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	t.Run("should reject data that isn't a FIT file", func(t *testing.T) {
+		if _, err := HeartRates([]byte("time,bpm\n")); !errors.Is(err, ErrNotFIT) {
+			t.Errorf("expected %v, got %v", ErrNotFIT, err)
+		}
+	})
+
+	t.Run("should reject a truncated file", func(t *testing.T) {
+		data := testFile(recordDefinition(), record(start, 80))
+
+		if _, err := HeartRates(data[:len(data)-4]); !errors.Is(err, ErrTruncated) {
+			t.Errorf("expected %v, got %v", ErrTruncated, err)
+		}
+	})
+
+	t.Run("should read the heart rate of each record", func(t *testing.T) {
+		data := testFile(recordDefinition(), record(start, 80), record(start.Add(time.Second), invalidHeartRate), record(start.Add(2*time.Second), 120))
+
+		heartRates, err := HeartRates(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(heartRates) != 2 || !heartRates[0].Time.Equal(start) || heartRates[0].BPM != 80 || heartRates[1].BPM != 120 {
+			t.Errorf("unexpected heart rates %+v", heartRates)
+		}
+	})
+
+	t.Run("should skip other messages", func(t *testing.T) {
+		// local type 1 is a big endian event message with a developer field
+		event := []byte{headerDefinition | headerDeveloper | 1, 0, architectureBig, 0, 21, 1, fieldTimestamp, 4, 0x86, 1, 0, 2, 0}
+		data := testFile(event, []byte{1, 0, 0, 0, 1, 0xAA, 0xBB}, recordDefinition(), record(start, 95))
+
+		heartRates, err := HeartRates(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(heartRates) != 1 || heartRates[0].BPM != 95 {
+			t.Errorf("unexpected heart rates %+v", heartRates)
+		}
+	})
+
+	t.Run("should read compressed timestamps", func(t *testing.T) {
+		// local type 1 is a record with only a heart rate
+		compressed := []byte{headerDefinition | 1, 0, 0, mesgRecord, 0, 1, fieldHeartRate, 1, 0x02}
+		last := uint32(start.Sub(epoch).Seconds())
+		offset := byte((last + 20) & compressedOffset)
+
+		data := testFile(recordDefinition(), record(start, 80), compressed, []byte{headerCompressed | 1<<5 | offset, 130})
+
+		heartRates, err := HeartRates(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(heartRates) != 2 || !heartRates[1].Time.Equal(start.Add(20*time.Second)) || heartRates[1].BPM != 130 {
+			t.Errorf("unexpected heart rates %+v", heartRates)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+// Package heartrate reads heart rate exports from a wearable and summarizes the heart rate around a plunge.
+//
+// A FIT activity file is detected from its header, anything else is read as a CSV with a time and a heart
+// rate column. The header names the columns, without one the first column is the time and the second the
+// heart rate. Times are RFC3339, 'YYYY-MM-DD HH:MM:SS' in UTC, or Unix seconds or milliseconds.
+package heartrate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/fit"
+)
+
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// Parse reads the samples from a FIT or CSV export, sorted by time. Heart rates outside MinBPM and MaxBPM are dropped.
+func Parse(data []byte) ([]Sample, error) {
+	var samples []Sample
+
+	if fit.IsFIT(data) {
+		heartRates, err := fit.HeartRates(data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hr := range heartRates {
+			samples = append(samples, Sample{Time: hr.Time, BPM: hr.BPM})
+		}
+	} else {
+		var err error
+		samples, err = ParseCSV(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	samples = slices.DeleteFunc(samples, func(s Sample) bool {
+		return s.BPM < MinBPM || s.BPM > MaxBPM
+	})
+
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+
+	return samples, nil
+}
+
+// ParseCSV reads the samples from a CSV export, blank rows are skipped.
+func ParseCSV(r io.Reader) ([]Sample, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoSamples
+	}
+
+	timeColumn, bpmColumn := columns(rows[0])
+	if timeColumn >= 0 && bpmColumn >= 0 {
+		rows = rows[1:]
+	} else if len(rows[0]) >= 2 {
+		timeColumn, bpmColumn = 0, 1
+	} else {
+		return nil, ErrInvalidCSV
+	}
+
+	samples := make([]Sample, 0, len(rows))
+	for _, row := range rows {
+		if len(row) == 1 && len(row[0]) == 0 {
+			continue
+		}
+
+		if len(row) <= max(timeColumn, bpmColumn) {
+			return nil, ErrInvalidCSV
+		}
+
+		t, err := parseTime(row[timeColumn])
+		if err != nil {
+			return nil, err
+		}
+
+		bpm, err := strconv.ParseFloat(strings.TrimSpace(row[bpmColumn]), 64)
+		if err != nil {
+			return nil, ErrInvalidBPM
+		}
+
+		samples = append(samples, Sample{Time: t, BPM: int(bpm + 0.5)})
+	}
+
+	return samples, nil
+}
+
+// Align keeps the samples from LeadIn before the plunge starts until RecoveryWindow after it ends.
+// At least one of the samples must be during the plunge.
+func Align(samples []Sample, start time.Time, end time.Time) ([]Sample, error) {
+	from := start.Add(-LeadIn)
+	to := end.Add(RecoveryWindow)
+
+	aligned := make([]Sample, 0, len(samples))
+	during := false
+	for _, s := range samples {
+		if s.Time.Before(from) || s.Time.After(to) {
+			continue
+		}
+
+		during = during || (!s.Time.Before(start) && !s.Time.After(end))
+		aligned = append(aligned, s)
+	}
+
+	if !during {
+		return nil, ErrOutsideWindow
+	}
+
+	return aligned, nil
+}
+
+// Summarize the heart rate of a plunge from the aligned samples, sorted by time.
+func Summarize(samples []Sample, start time.Time, end time.Time) Summary {
+	var summary Summary
+	if len(samples) == 0 {
+		return summary
+	}
+
+	var resting, during []Sample
+	for _, s := range samples {
+		switch {
+		case s.Time.Before(start):
+			resting = append(resting, s)
+		case !s.Time.After(end):
+			during = append(during, s)
+		}
+	}
+
+	if len(resting) == 0 {
+		resting = samples[:1]
+	}
+
+	summary.RestingBPM = average(resting)
+	summary.AvgBPM = average(during)
+
+	for _, s := range during {
+		if s.BPM > summary.PeakBPM {
+			summary.PeakBPM = s.BPM
+			summary.PeakAt = s.Time
+		}
+	}
+
+	// the heart rate has recovered at the first sample after the plunge that is near the resting heart rate
+	for _, s := range samples {
+		if s.Time.Before(end) {
+			continue
+		}
+
+		if float64(s.BPM) <= summary.RestingBPM+RecoveryMarginBPM {
+			recovery := s.Time.Sub(end)
+			summary.Recovery = &recovery
+			break
+		}
+	}
+
+	return summary
+}
+
+func average(samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	var total int
+	for _, s := range samples {
+		total += s.BPM
+	}
+
+	return float64(total) / float64(len(samples))
+}
+
+// columns finds the time and heart rate columns in the header, a column that isn't found is -1.
+func columns(header []string) (int, int) {
+	timeColumn, bpmColumn := -1, -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.TrimSuffix(strings.TrimSuffix(name, " (bpm)"), "(bpm)")
+
+		switch {
+		case timeColumn < 0 && slices.Contains(timeColumnNames, name):
+			timeColumn = i
+		case bpmColumn < 0 && slices.Contains(heartRateColumnNames, name):
+			bpmColumn = i
+		}
+	}
+
+	return timeColumn, bpmColumn
+}
+
+// parseTime reads a time from the CSV, a time without a zone is in UTC.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	unix, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidTime
+	}
+
+	// anything this large is in milliseconds
+	if unix >= 1e12 {
+		return time.UnixMilli(unix).UTC(), nil
+	}
+
+	return time.Unix(unix, 0).UTC(), nil
+}
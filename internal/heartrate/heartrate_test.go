@@ -0,0 +1,138 @@
+package heartrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("should read the columns named in the header", func(t *testing.T) {
+		csv := "Date,Steps,Heart Rate (bpm)\n2024-06-01 07:00:00,0,72\n2024-06-01T07:00:05Z,3,110.6\n"
+
+		samples, err := ParseCSV(strings.NewReader(csv))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+		if len(samples) != 2 || !samples[0].Time.Equal(start) || samples[0].BPM != 72 || samples[1].BPM != 111 {
+			t.Errorf("unexpected samples %+v", samples)
+		}
+	})
+
+	t.Run("should read a CSV without a header", func(t *testing.T) {
+		samples, err := ParseCSV(strings.NewReader("1717225200,72\n1717225205000,90\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(samples) != 2 || samples[1].Time.Sub(samples[0].Time) != 5*time.Second {
+			t.Errorf("unexpected samples %+v", samples)
+		}
+	})
+
+	t.Run("should fail with an invalid time", func(t *testing.T) {
+		if _, err := ParseCSV(strings.NewReader("time,bpm\nyesterday,72\n")); !errors.Is(err, ErrInvalidTime) {
+			t.Errorf("expected %v, got %v", ErrInvalidTime, err)
+		}
+	})
+
+	t.Run("should fail without a heart rate column", func(t *testing.T) {
+		if _, err := ParseCSV(strings.NewReader("1717225200\n")); !errors.Is(err, ErrInvalidCSV) {
+			t.Errorf("expected %v, got %v", ErrInvalidCSV, err)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("should drop bad readings and sort the samples", func(t *testing.T) {
+		samples, err := Parse([]byte("time,hr\n1717225210,80\n1717225200,0\n1717225205,75\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(samples) != 2 || samples[0].BPM != 75 || samples[1].BPM != 80 {
+			t.Errorf("unexpected samples %+v", samples)
+		}
+	})
+
+	t.Run("should fail without any samples", func(t *testing.T) {
+		if _, err := Parse([]byte("time,hr\n")); !errors.Is(err, ErrNoSamples) {
+			t.Errorf("expected %v, got %v", ErrNoSamples, err)
+		}
+	})
+}
+
+func TestAlign(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Minute)
+
+	t.Run("should keep the samples around the plunge", func(t *testing.T) {
+		samples := []Sample{
+			{Time: start.Add(-10 * time.Minute), BPM: 70},
+			{Time: start.Add(-time.Minute), BPM: 72},
+			{Time: start.Add(time.Minute), BPM: 120},
+			{Time: end.Add(10 * time.Minute), BPM: 75},
+			{Time: end.Add(20 * time.Minute), BPM: 70},
+		}
+
+		aligned, err := Align(samples, start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(aligned) != 3 || aligned[0].BPM != 72 || aligned[2].BPM != 75 {
+			t.Errorf("unexpected samples %+v", aligned)
+		}
+	})
+
+	t.Run("should fail without a sample during the plunge", func(t *testing.T) {
+		samples := []Sample{{Time: start.Add(-time.Minute), BPM: 72}, {Time: end.Add(time.Minute), BPM: 90}}
+
+		if _, err := Align(samples, start, end); !errors.Is(err, ErrOutsideWindow) {
+			t.Errorf("expected %v, got %v", ErrOutsideWindow, err)
+		}
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Minute)
+
+	t.Run("should find the peak and the recovery", func(t *testing.T) {
+		samples := []Sample{
+			{Time: start.Add(-2 * time.Minute), BPM: 60},
+			{Time: start.Add(-time.Minute), BPM: 64},
+			{Time: start.Add(10 * time.Second), BPM: 130},
+			{Time: start.Add(2 * time.Minute), BPM: 100},
+			{Time: end.Add(time.Minute), BPM: 80},
+			{Time: end.Add(4 * time.Minute), BPM: 66},
+		}
+
+		summary := Summarize(samples, start, end)
+
+		if summary.RestingBPM != 62 || summary.PeakBPM != 130 || !summary.PeakAt.Equal(start.Add(10*time.Second)) || summary.AvgBPM != 115 {
+			t.Errorf("unexpected summary %+v", summary)
+		}
+
+		if summary.Recovery == nil || *summary.Recovery != 4*time.Minute {
+			t.Errorf("expected a 4 minute recovery, got %v", summary.Recovery)
+		}
+	})
+
+	t.Run("should not recover before the samples end", func(t *testing.T) {
+		samples := []Sample{
+			{Time: start, BPM: 70},
+			{Time: start.Add(time.Minute), BPM: 120},
+			{Time: end.Add(time.Minute), BPM: 95},
+		}
+
+		summary := Summarize(samples, start, end)
+
+		if summary.RestingBPM != 70 || summary.Recovery != nil {
+			t.Errorf("unexpected summary %+v", summary)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package heartrate
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// LeadIn is how long before the plunge starts the samples are kept, they are the resting heart rate.
+	LeadIn = 5 * time.Minute
+
+	// RecoveryWindow is how long after the plunge ends the samples are kept to measure the recovery.
+	RecoveryWindow = 15 * time.Minute
+
+	// RecoveryMarginBPM is how close to the resting heart rate the heart rate must return to be recovered.
+	RecoveryMarginBPM = 5
+
+	// MinBPM and MaxBPM bound the heart rates that are kept, anything outside them is a bad reading.
+	MinBPM = 25
+	MaxBPM = 250
+)
+
+var (
+	ErrNoSamples         = errors.New("no heart rate samples were found")
+	ErrInvalidCSV        = errors.New("the CSV needs a time and a heart rate column")
+	ErrInvalidTime       = errors.New("invalid time in the heart rate CSV")
+	ErrInvalidBPM        = errors.New("invalid heart rate in the heart rate CSV")
+	ErrOutsideWindow     = errors.New("none of the heart rate samples are during the plunge")
+	timeColumnNames      = []string{"time", "timestamp", "date", "datetime", "date_time"}
+	heartRateColumnNames = []string{"heart_rate", "heartrate", "heart rate", "hr", "bpm", "heart_rate_bpm"}
+)
+
+type (
+	// Sample is the heart rate in beats per minute at a point in time.
+	Sample struct {
+		Time time.Time
+		BPM  int
+	}
+
+	// Summary describes the heart rate around a plunge. The resting heart rate is the average before the
+	// plunge, or the first sample if there are none before it.
+	Summary struct {
+		RestingBPM float64
+		PeakBPM    int
+		PeakAt     time.Time
+		AvgBPM     float64
+
+		// Recovery is the time from the end of the plunge until the heart rate is back near the resting
+		// heart rate, it is nil if the samples end before then.
+		Recovery *time.Duration
+	}
+)
@@ -0,0 +1,107 @@
+package interlock
+
+import (
+	"errors"
+	"slices"
+)
+
+// Rules are the safety rules between the pump, ozone generator, leak sensors and plunges.
+// Every actuation is checked against them, and the monitor enforces them when the state changes underneath a device.
+var Rules = []Rule{
+	{
+		Name:   "leak_blocks_pump",
+		Action: ACTION_PUMP_ON,
+		Reason: "the pump can't run while a leak is detected",
+		Blocks: func(s State) bool { return shutsOff(s.Leaks, DEVICE_PUMP) },
+	},
+	{
+		Name:   "unacknowledged_leak_blocks_pump",
+		Action: ACTION_PUMP_ON,
+		Reason: "the pump can't run until the leak is acknowledged",
+		Blocks: func(s State) bool { return shutsOff(s.UnacknowledgedLeaks, DEVICE_PUMP) },
+	},
+	{
+		Name:   "leak_blocks_ozone",
+		Action: ACTION_OZONE_START,
+		Reason: "the ozone generator can't run while a leak is detected",
+		Blocks: func(s State) bool { return shutsOff(s.Leaks, DEVICE_OZONE) },
+	},
+	{
+		Name:   "ozone_requires_pump",
+		Action: ACTION_OZONE_START,
+		Reason: "the ozone generator requires the pump to be on",
+		Blocks: func(s State) bool { return !s.PumpOn },
+	},
+	{
+		Name:   "no_ozone_during_plunge",
+		Action: ACTION_OZONE_START,
+		Reason: "the ozone generator can't run during a plunge",
+		Blocks: func(s State) bool { return s.PlungeRunning },
+	},
+	{
+		Name:   "no_plunge_during_ozone",
+		Action: ACTION_PLUNGE_START,
+		Reason: "a plunge can't start while the ozone generator is running",
+		Blocks: func(s State) bool { return s.OzoneRunning },
+	},
+}
+
+func (v *Violation) Error() string {
+	return v.Reason
+}
+
+// IsViolation reports if the error is a refusal from an interlock rule.
+func IsViolation(err error) bool {
+	var v *Violation
+	return errors.As(err, &v)
+}
+
+// Check returns a Violation for the first rule that blocks the action in the given state.
+func Check(state State, action string) error {
+	for _, rule := range Rules {
+		if rule.Action == action && rule.Blocks(state) {
+			return &Violation{Rule: rule.Name, Action: action, Reason: rule.Reason}
+		}
+	}
+
+	return nil
+}
+
+// Enforce returns the actions needed to bring the state back within the rules.
+// A leak forces off the devices its zone shuts off, and the ozone generator is stopped if the pump is off or a plunge is running.
+func Enforce(state State) []Enforcement {
+	var enforcements []Enforcement
+
+	if state.PumpOn {
+		if v := Check(state, ACTION_PUMP_ON); v != nil {
+			enforcements = append(enforcements, toEnforcement(v, ACTION_PUMP_OFF))
+
+			// the pump is about to be turned off, which the ozone generator must also account for
+			state.PumpOn = false
+		}
+	}
+
+	if state.OzoneRunning {
+		if v := Check(state, ACTION_OZONE_START); v != nil {
+			enforcements = append(enforcements, toEnforcement(v, ACTION_OZONE_STOP))
+		}
+	}
+
+	return enforcements
+}
+
+// shutsOff reports if a leak in any of the zones shuts off the device.
+func shutsOff(zones []LeakZone, device string) bool {
+	for _, z := range zones {
+		if slices.Contains(z.ShutOff, device) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toEnforcement(err error, action string) Enforcement {
+	v := err.(*Violation)
+	return Enforcement{Rule: v.Rule, Action: action, Reason: v.Reason}
+}
@@ -0,0 +1,94 @@
+package interlock
+
+import (
+	"testing"
+)
+
+var (
+	tubLeak   = []LeakZone{{Name: "under tub", ShutOff: []string{DEVICE_PUMP, DEVICE_OZONE}}}
+	ozoneLeak = []LeakZone{{Name: "ozone generator", ShutOff: []string{DEVICE_OZONE}}}
+	trayLeak  = []LeakZone{{Name: "chiller drip tray"}}
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    State
+		action   string
+		expected string
+	}{
+		{"should allow the pump without a leak", State{}, ACTION_PUMP_ON, ""},
+		{"should refuse the pump during a leak", State{Leaks: tubLeak}, ACTION_PUMP_ON, "leak_blocks_pump"},
+		{"should allow the pump during a leak in a zone that doesn't shut it off", State{Leaks: ozoneLeak}, ACTION_PUMP_ON, ""},
+		{"should allow the pump during a leak in an alarm only zone", State{Leaks: trayLeak, UnacknowledgedLeaks: trayLeak}, ACTION_PUMP_ON, ""},
+		{"should refuse the pump until the leak is acknowledged", State{UnacknowledgedLeaks: tubLeak}, ACTION_PUMP_ON, "unacknowledged_leak_blocks_pump"},
+		{"should report the active leak before the acknowledgement", State{Leaks: tubLeak, UnacknowledgedLeaks: tubLeak}, ACTION_PUMP_ON, "leak_blocks_pump"},
+		{"should always allow the pump to be turned off", State{Leaks: tubLeak, OzoneRunning: true}, ACTION_PUMP_OFF, ""},
+		{"should allow ozone with the pump on", State{PumpOn: true}, ACTION_OZONE_START, ""},
+		{"should refuse ozone with the pump off", State{}, ACTION_OZONE_START, "ozone_requires_pump"},
+		{"should refuse ozone during a leak", State{PumpOn: true, Leaks: tubLeak}, ACTION_OZONE_START, "leak_blocks_ozone"},
+		{"should refuse ozone during a leak in a zone that only shuts off ozone", State{PumpOn: true, Leaks: ozoneLeak}, ACTION_OZONE_START, "leak_blocks_ozone"},
+		{"should refuse ozone during a plunge", State{PumpOn: true, PlungeRunning: true}, ACTION_OZONE_START, "no_ozone_during_plunge"},
+		{"should refuse a plunge while ozone is running", State{PumpOn: true, OzoneRunning: true}, ACTION_PLUNGE_START, "no_plunge_during_ozone"},
+		{"should allow a plunge with the pump off", State{}, ACTION_PLUNGE_START, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Check(tc.state, tc.action)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected the action to be allowed, got %v", err)
+				}
+				return
+			}
+
+			v, ok := err.(*Violation)
+			if !ok {
+				t.Fatalf("expected a violation, got %v", err)
+			}
+
+			if v.Rule != tc.expected || v.Action != tc.action {
+				t.Errorf("expected rule %s, got %+v", tc.expected, v)
+			}
+
+			if !IsViolation(err) {
+				t.Errorf("expected IsViolation to report the violation")
+			}
+		})
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	t.Run("should do nothing when the state is safe", func(t *testing.T) {
+		enforcements := Enforce(State{PumpOn: true, OzoneRunning: true})
+		if len(enforcements) != 0 {
+			t.Errorf("expected no enforcements, got %+v", enforcements)
+		}
+	})
+
+	t.Run("should turn the pump and ozone off during a leak", func(t *testing.T) {
+		enforcements := Enforce(State{PumpOn: true, OzoneRunning: true, Leaks: tubLeak})
+		if len(enforcements) != 2 {
+			t.Fatalf("expected %d enforcements, got %+v", 2, enforcements)
+		}
+
+		if enforcements[0].Action != ACTION_PUMP_OFF || enforcements[1].Action != ACTION_OZONE_STOP || enforcements[1].Rule != "leak_blocks_ozone" {
+			t.Errorf("unexpected enforcements %+v", enforcements)
+		}
+	})
+
+	t.Run("should only stop the ozone during a leak in a zone that shuts it off", func(t *testing.T) {
+		enforcements := Enforce(State{PumpOn: true, OzoneRunning: true, Leaks: ozoneLeak})
+		if len(enforcements) != 1 || enforcements[0].Action != ACTION_OZONE_STOP {
+			t.Errorf("unexpected enforcements %+v", enforcements)
+		}
+	})
+
+	t.Run("should stop the ozone when the pump is off", func(t *testing.T) {
+		enforcements := Enforce(State{OzoneRunning: true})
+		if len(enforcements) != 1 || enforcements[0].Action != ACTION_OZONE_STOP || enforcements[0].Rule != "ozone_requires_pump" {
+			t.Errorf("unexpected enforcements %+v", enforcements)
+		}
+	})
+}
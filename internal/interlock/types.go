@@ -0,0 +1,58 @@
+package interlock
+
+const (
+	ACTION_PUMP_ON      = "pump_on"
+	ACTION_PUMP_OFF     = "pump_off"
+	ACTION_OZONE_START  = "ozone_start"
+	ACTION_OZONE_STOP   = "ozone_stop"
+	ACTION_PLUNGE_START = "plunge_start"
+
+	// Devices a leak zone can shut off.
+	DEVICE_PUMP  = "pump"
+	DEVICE_OZONE = "ozone"
+)
+
+type (
+	// State is the current state of the devices and plunge that the rules are checked against.
+	State struct {
+		PumpOn        bool
+		OzoneRunning  bool
+		PlungeRunning bool
+
+		// Leaks are the zones with a leak detected.
+		Leaks []LeakZone
+
+		// UnacknowledgedLeaks are the zones with a leak that latched the alarm until a user acknowledges it.
+		UnacknowledgedLeaks []LeakZone
+	}
+
+	// LeakZone is an area watched by leak sensors and the devices a leak in it shuts off.
+	LeakZone struct {
+		Name    string
+		ShutOff []string
+	}
+
+	// Rule blocks an action while the state meets its condition.
+	Rule struct {
+		Name   string
+		Action string
+		Reason string // Reason is returned to the caller when the action is refused.
+
+		// Blocks reports if the action is not allowed in the given state.
+		Blocks func(s State) bool
+	}
+
+	// Enforcement is an action the monitor must take because the current state breaks a rule.
+	Enforcement struct {
+		Rule   string
+		Action string
+		Reason string
+	}
+
+	// Violation is returned when an action is refused by a rule.
+	Violation struct {
+		Rule   string
+		Action string
+		Reason string
+	}
+)
@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCaptureSize is how many deliveries a capture channel keeps in memory.
+const DefaultCaptureSize = 100
+
+func init() {
+	RegisterChannel(CHANNELTYPE_CAPTURE, newCaptureChannel)
+}
+
+type (
+	// Capture is a delivery recorded by a capture channel instead of being sent.
+	Capture struct {
+		Channel     string    `json:"channel"`
+		PhoneNumber string    `json:"phone_number,omitempty"`
+		Email       string    `json:"email,omitempty"`
+		Event       Event     `json:"event"`
+		CapturedAt  time.Time `json:"captured_at"`
+	}
+
+	// Capturer is a Channel that records its deliveries so they can be inspected.
+	Capturer interface {
+		Captured() []Capture
+		ClearCaptured()
+	}
+
+	// captureChannel keeps the most recent deliveries in memory and optionally appends each one to a file as a JSON line.
+	// It stands in for the real channels during development and tests, and records the deliveries to subscribed users.
+	captureChannel struct {
+		sync.Mutex
+		name     string
+		path     string
+		size     int
+		captured []Capture
+	}
+)
+
+func newCaptureChannel(config ChannelConfig) (Channel, error) {
+	size := config.Size
+	if size == 0 {
+		size = DefaultCaptureSize
+	}
+
+	if size < 0 {
+		return nil, fmt.Errorf("%w: capture size can not be negative", ErrInvalidChannel)
+	}
+
+	return &captureChannel{name: config.Name, path: config.Path, size: size}, nil
+}
+
+func (c *captureChannel) Name() string {
+	return c.name
+}
+
+func (c *captureChannel) Send(ctx context.Context, event Event) error {
+	return c.capture(Capture{Channel: c.name, Event: event})
+}
+
+func (c *captureChannel) SendSMS(ctx context.Context, event Event, phoneNumber string) error {
+	return c.capture(Capture{Channel: c.name, PhoneNumber: phoneNumber, Event: event})
+}
+
+func (c *captureChannel) SendEmail(ctx context.Context, event Event, address string) error {
+	return c.capture(Capture{Channel: c.name, Email: address, Event: event})
+}
+
+// Captured returns a copy of the recorded deliveries, oldest first.
+func (c *captureChannel) Captured() []Capture {
+	c.Lock()
+	defer c.Unlock()
+
+	captured := make([]Capture, len(c.captured))
+	copy(captured, c.captured)
+
+	return captured
+}
+
+// ClearCaptured forgets the recorded deliveries, the file is left as it is.
+func (c *captureChannel) ClearCaptured() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.captured = nil
+}
+
+func (c *captureChannel) capture(capture Capture) error {
+	capture.CapturedAt = time.Now().UTC()
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.captured = append(c.captured, capture)
+	if len(c.captured) > c.size {
+		c.captured = c.captured[len(c.captured)-c.size:]
+	}
+
+	if len(c.path) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal(capture)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+
+	return err
+}
@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaptureChannel(t *testing.T) {
+	t.Run("should fail with a negative size", func(t *testing.T) {
+		_, err := NewRouter(Config{Channels: []ChannelConfig{{Type: CHANNELTYPE_CAPTURE, Size: -1}}})
+		if err == nil {
+			t.Errorf("expected an error for a negative size")
+		}
+	})
+
+	t.Run("should capture the exact messages sent to channels and recipients", func(t *testing.T) {
+		router, err := NewRouter(Config{Channels: []ChannelConfig{{Type: CHANNELTYPE_CAPTURE}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !router.Capturing() {
+			t.Fatalf("expected the router to be capturing")
+		}
+
+		err = router.Send(context.Background(), Event{Type: TYPE_LEAK, Severity: SEVERITY_CRITICAL, Message: "Leak detected"}, Recipient{Name: "a", PhoneNumber: "+15555550100"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		captured := router.Captured()
+		if len(captured) != 2 {
+			t.Fatalf("expected %d captures, got %+v", 2, captured)
+		}
+
+		if captured[0].Event.Message != "Leak detected" || captured[0].Event.Title != Title(TYPE_LEAK) || len(captured[0].PhoneNumber) != 0 {
+			t.Errorf("unexpected broadcast capture %+v", captured[0])
+		}
+
+		if captured[1].PhoneNumber != "+15555550100" || captured[1].Channel != CHANNELTYPE_CAPTURE {
+			t.Errorf("unexpected recipient capture %+v", captured[1])
+		}
+
+		router.ClearCaptured()
+		if len(router.Captured()) != 0 {
+			t.Errorf("expected the captures to be cleared")
+		}
+	})
+
+	t.Run("should only keep the most recent deliveries", func(t *testing.T) {
+		channel, err := newCaptureChannel(ChannelConfig{Name: "capture", Size: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := channel.(*captureChannel)
+		for _, message := range []string{"one", "two", "three"} {
+			if err := c.Send(context.Background(), Event{Message: message}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		captured := c.Captured()
+		if len(captured) != 2 || captured[0].Event.Message != "two" || captured[1].Event.Message != "three" {
+			t.Errorf("unexpected captures %+v", captured)
+		}
+	})
+
+	t.Run("should append each delivery to the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notifications.jsonl")
+
+		channel, err := newCaptureChannel(ChannelConfig{Name: "capture", Path: path})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := channel.(*captureChannel)
+		c.Send(context.Background(), Event{Type: TYPE_OZONE, Message: "Ozone generator was started"})
+		c.SendEmail(context.Background(), Event{Type: TYPE_OZONE, Message: "Ozone generator was stopped"}, "a@mail.com")
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		lines := make([]Capture, 0)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var capture Capture
+			if err := json.Unmarshal(scanner.Bytes(), &capture); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, capture)
+		}
+
+		if len(lines) != 2 || lines[1].Email != "a@mail.com" || lines[1].Event.Message != "Ozone generator was stopped" {
+			t.Errorf("unexpected file contents %+v", lines)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+)
+
+// NewFilter creates the filter for the quiet hours, deduplication and rate limit settings.
+// A zero dedupe or rate limit setting uses the default and a negative setting turns it off.
+func NewFilter(config Config) (*Filter, error) {
+	f := &Filter{
+		quietStart: -1,
+		quietEnd:   -1,
+		dedupe:     seconds(config.DedupeSeconds, DefaultDedupeSeconds),
+		maxEvents:  config.RateLimit.MaxEvents,
+		period:     seconds(config.RateLimit.PeriodSeconds, DefaultRateLimitSeconds),
+		lastSent:   make(map[string]time.Time),
+		sent:       make(map[string][]time.Time),
+	}
+
+	if f.maxEvents == 0 {
+		f.maxEvents = DefaultRateLimitEvents
+	}
+
+	if len(config.QuietHours.Start) != 0 || len(config.QuietHours.End) != 0 {
+		var err error
+		f.quietStart, err = parseClock(config.QuietHours.Start)
+		if err != nil {
+			return nil, err
+		}
+
+		f.quietEnd, err = parseClock(config.QuietHours.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Allow reports if the event should be sent, or why it was suppressed.
+// Events with the same type, source and key are deduplicated, and the events a user didn't ask for are rate
+// limited so that a flapping sensor can't send a message on every change. Critical events are sent during the
+// quiet hours and are rate limited by their key rather than their type, so a leak in another zone is never
+// held back by the one that is flapping.
+func (f *Filter) Allow(event Event, now time.Time) (bool, string) {
+	f.Lock()
+	defer f.Unlock()
+
+	critical := event.Severity == SEVERITY_CRITICAL
+	if !critical && f.inQuietHours(now) {
+		return false, "quiet hours"
+	}
+
+	key := event.Type + "|" + event.Source + "|" + event.Key
+	if f.dedupe > 0 {
+		if last, ok := f.lastSent[key]; ok && now.Sub(last) < f.dedupe {
+			return false, "duplicate"
+		}
+	}
+
+	bucket := event.Type
+	if critical {
+		bucket = key
+	}
+
+	limited := f.maxEvents > 0 && !event.Requested
+	if limited && f.recent(bucket, now) >= f.maxEvents {
+		return false, "rate limited"
+	}
+
+	f.forget(now)
+	if f.dedupe > 0 {
+		f.lastSent[key] = now
+	}
+
+	if limited {
+		f.sent[bucket] = append(f.sent[bucket], now)
+	}
+
+	return true, ""
+}
+
+// recent returns how many events were sent from the rate limit bucket in the period.
+func (f *Filter) recent(bucket string, now time.Time) int {
+	count := 0
+	for _, sentAt := range f.sent[bucket] {
+		if now.Sub(sentAt) < f.period {
+			count++
+		}
+	}
+
+	return count
+}
+
+// forget drops the events that can no longer be a duplicate or count towards a rate limit.
+func (f *Filter) forget(now time.Time) {
+	for key, last := range f.lastSent {
+		if now.Sub(last) >= f.dedupe {
+			delete(f.lastSent, key)
+		}
+	}
+
+	for bucket, sent := range f.sent {
+		recent := sent[:0]
+		for _, sentAt := range sent {
+			if now.Sub(sentAt) < f.period {
+				recent = append(recent, sentAt)
+			}
+		}
+
+		if len(recent) == 0 {
+			delete(f.sent, bucket)
+		} else {
+			f.sent[bucket] = recent
+		}
+	}
+}
+
+func (f *Filter) inQuietHours(now time.Time) bool {
+	if f.quietStart < 0 || f.quietStart == f.quietEnd {
+		return false
+	}
+
+	local := now.Local()
+	minute := local.Hour()*60 + local.Minute()
+
+	if f.quietStart < f.quietEnd {
+		return minute >= f.quietStart && minute < f.quietEnd
+	}
+
+	// the quiet hours wrap past midnight
+	return minute >= f.quietStart || minute < f.quietEnd
+}
+
+// parseClock returns the minutes after midnight for a time formatted as HH:MM.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidQuietHours, value)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func seconds(value, defaultValue int) time.Duration {
+	if value == 0 {
+		value = defaultValue
+	}
+
+	return time.Duration(value) * time.Second
+}
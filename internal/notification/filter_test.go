@@ -0,0 +1,186 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFilterDedupe(t *testing.T) {
+	t.Run("should suppress a repeated event within the dedupe window", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: 60})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		leak := Event{Type: TYPE_LEAK, Severity: SEVERITY_CRITICAL, Source: "leak", Message: "Leak detected!!", Key: "drip-tray/detected"}
+
+		if ok, _ := f.Allow(leak, now); !ok {
+			t.Fatalf("expected the first event to be sent")
+		}
+
+		if ok, reason := f.Allow(leak, now.Add(30*time.Second)); ok || reason != "duplicate" {
+			t.Errorf("expected the duplicate to be suppressed, got %v %s", ok, reason)
+		}
+
+		if ok, _ := f.Allow(leak, now.Add(61*time.Second)); !ok {
+			t.Errorf("expected the event to be sent after the dedupe window")
+		}
+	})
+
+	t.Run("should dedupe the events of each key separately", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: 60})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		done := Event{Type: TYPE_PLUNGE, Source: "plunge_timer", Message: "The plunge is complete after 3m0s.", Key: "first"}
+
+		f.Allow(done, now)
+
+		done.Key = "second"
+		if ok, _ := f.Allow(done, now); !ok {
+			t.Errorf("expected the completion of another plunge to be sent")
+		}
+	})
+
+	t.Run("should dedupe on the key rather than the message", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: 60})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		f.Allow(Event{Type: TYPE_ALERT, Source: "Too warm", Message: "Water is 45.2°F", Key: "rule"}, now)
+		if ok, reason := f.Allow(Event{Type: TYPE_ALERT, Source: "Too warm", Message: "Water is 45.6°F", Key: "rule"}, now.Add(30*time.Second)); ok || reason != "duplicate" {
+			t.Errorf("expected the alert with a new reading to be suppressed, got %v %s", ok, reason)
+		}
+	})
+
+	t.Run("should forget events once the dedupe window has passed", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: 60, RateLimit: RateLimitConfig{MaxEvents: -1}})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		for i := 0; i < 100; i++ {
+			f.Allow(Event{Type: TYPE_ALERT, Key: fmt.Sprintf("rule-%d", i)}, now)
+		}
+
+		f.Allow(Event{Type: TYPE_ALERT, Key: "rule"}, now.Add(time.Minute))
+		if len(f.lastSent) != 1 {
+			t.Errorf("expected %d event to be remembered, got %d", 1, len(f.lastSent))
+		}
+	})
+
+	t.Run("should not dedupe when it is turned off", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: -1})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		event := Event{Type: TYPE_OZONE, Message: "Ozone generator was started"}
+
+		f.Allow(event, now)
+		if ok, _ := f.Allow(event, now); !ok {
+			t.Errorf("expected the event to be sent")
+		}
+	})
+}
+
+func TestFilterRateLimit(t *testing.T) {
+	t.Run("should rate limit a flapping leak sensor", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: -1, RateLimit: RateLimitConfig{MaxEvents: 3, PeriodSeconds: 3600}})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		leak := Event{Type: TYPE_LEAK, Severity: SEVERITY_CRITICAL, Source: "leak", Message: "Leak detected!!", Key: "drip-tray/detected"}
+
+		sent := 0
+		for i := 0; i < 30; i++ {
+			if ok, _ := f.Allow(leak, now.Add(time.Duration(i)*time.Minute)); ok {
+				sent++
+			}
+		}
+
+		if sent != 3 {
+			t.Errorf("expected %d events to be sent, got %d", 3, sent)
+		}
+
+		if ok, _ := f.Allow(leak, now.Add(time.Hour)); !ok {
+			t.Errorf("expected an event to be sent once the period has passed")
+		}
+	})
+
+	t.Run("should send a leak in another zone while one is flapping", func(t *testing.T) {
+		f, _ := NewFilter(Config{DedupeSeconds: -1, RateLimit: RateLimitConfig{MaxEvents: 1}})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		tub := Event{Type: TYPE_LEAK, Severity: SEVERITY_CRITICAL, Source: "leak", Message: "Leak detected in the under tub zone", Key: "under-tub/detected"}
+		tray := Event{Type: TYPE_LEAK, Severity: SEVERITY_CRITICAL, Source: "leak", Message: "Leak detected in the drip tray zone", Key: "drip-tray/detected"}
+
+		f.Allow(Event{Type: TYPE_LEAK, Severity: SEVERITY_WARNING, Message: "The leak has cleared."}, now)
+		if ok, reason := f.Allow(tub, now.Add(10*time.Second)); !ok {
+			t.Errorf("expected the leak under the tub to be sent, it was suppressed as %s", reason)
+		}
+
+		if ok, reason := f.Allow(tub, now.Add(30*time.Second)); ok || reason != "rate limited" {
+			t.Errorf("expected the flapping leak under the tub to be rate limited, got %v %s", ok, reason)
+		}
+
+		if ok, reason := f.Allow(tray, now.Add(50*time.Second)); !ok {
+			t.Errorf("expected the leak in the drip tray to be sent, it was suppressed as %s", reason)
+		}
+	})
+
+	t.Run("should not rate limit the events of a plunge timer", func(t *testing.T) {
+		f, _ := NewFilter(Config{})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		// a contrast protocol announces every phase, counts down and completes
+		for i := 0; i < 9; i++ {
+			event := Event{Type: TYPE_PLUNGE, Source: "plunge_timer", Message: fmt.Sprintf("Phase %d of 8", i+1), Key: fmt.Sprintf("plunge/phase/%d", i), Requested: true}
+			if ok, reason := f.Allow(event, now.Add(time.Duration(i)*time.Minute)); !ok {
+				t.Fatalf("expected event %d to be sent, it was suppressed as %s", i+1, reason)
+			}
+		}
+
+		if ok, _ := f.Allow(Event{Type: TYPE_PLUNGE, Message: "Plunge started"}, now); !ok {
+			t.Errorf("expected the timer not to count towards the rate limit")
+		}
+	})
+
+	t.Run("should rate limit each type separately", func(t *testing.T) {
+		f, _ := NewFilter(Config{RateLimit: RateLimitConfig{MaxEvents: 1}})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		f.Allow(Event{Type: TYPE_LEAK, Message: "Leak detected!!"}, now)
+		if ok, _ := f.Allow(Event{Type: TYPE_OZONE, Message: "Ozone generator was started"}, now); !ok {
+			t.Errorf("expected the ozone event to be sent")
+		}
+	})
+}
+
+func TestFilterQuietHours(t *testing.T) {
+	config := Config{QuietHours: QuietHoursConfig{Start: "22:00", End: "07:00"}}
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.Local)
+	morning := time.Date(2024, 1, 2, 6, 59, 0, 0, time.Local)
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		severity string
+		at       time.Time
+		expected bool
+	}{
+		{"should suppress info events overnight", SEVERITY_INFO, night, false},
+		{"should suppress warning events before the quiet hours end", SEVERITY_WARNING, morning, false},
+		{"should send critical events during the quiet hours", SEVERITY_CRITICAL, night, true},
+		{"should send info events during the day", SEVERITY_INFO, day, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFilter(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ok, _ := f.Allow(Event{Type: TYPE_OZONE, Severity: tc.severity, Message: "Ozone generator was started"}, tc.at)
+			if ok != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, ok)
+			}
+		})
+	}
+
+	t.Run("should fail with an invalid time", func(t *testing.T) {
+		_, err := NewFilter(Config{QuietHours: QuietHoursConfig{Start: "10pm", End: "07:00"}})
+		if !errors.Is(err, ErrInvalidQuietHours) {
+			t.Errorf("expected %v, got %v", ErrInvalidQuietHours, err)
+		}
+	})
+}
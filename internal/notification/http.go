@@ -0,0 +1,208 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultGotifyPriority = 5
+	MaxGotifyPriority     = 10
+)
+
+func init() {
+	RegisterChannel(CHANNELTYPE_WEBHOOK, newWebhookChannel)
+	RegisterChannel(CHANNELTYPE_NTFY, newNtfyChannel)
+	RegisterChannel(CHANNELTYPE_GOTIFY, newGotifyChannel)
+}
+
+type (
+	// webhookChannel posts the message as JSON to a URL.
+	webhookChannel struct {
+		name    string
+		url     string
+		headers map[string]string
+	}
+
+	// WebhookPayload is the body posted by a webhook channel.
+	WebhookPayload struct {
+		Type       string         `json:"type"`
+		Severity   string         `json:"severity"`
+		Source     string         `json:"source"`
+		Title      string         `json:"title"`
+		Message    string         `json:"message"`
+		Payload    map[string]any `json:"payload,omitempty"`
+		OccurredAt time.Time      `json:"occurred_at"`
+	}
+
+	// ntfyChannel publishes the message to a topic on an ntfy server.
+	ntfyChannel struct {
+		name  string
+		url   string
+		token string
+	}
+
+	// gotifyChannel pushes the message to a Gotify server using an application token.
+	gotifyChannel struct {
+		name     string
+		url      string
+		token    string
+		priority int
+	}
+)
+
+func newWebhookChannel(config ChannelConfig) (Channel, error) {
+	if len(config.URL) == 0 {
+		return nil, fmt.Errorf("%w: webhook requires a url", ErrInvalidChannel)
+	}
+
+	return &webhookChannel{name: config.Name, url: config.URL, headers: config.Headers}, nil
+}
+
+func (c *webhookChannel) Name() string {
+	return c.name
+}
+
+func (c *webhookChannel) Send(ctx context.Context, event Event) error {
+	payload := WebhookPayload{
+		Type:       event.Type,
+		Severity:   event.Severity,
+		Source:     event.Source,
+		Title:      event.Title,
+		Message:    event.Message,
+		Payload:    event.Payload,
+		OccurredAt: event.OccurredAt,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for k, v := range c.headers {
+		headers[k] = v
+	}
+
+	return post(ctx, c.url, headers, body)
+}
+
+func newNtfyChannel(config ChannelConfig) (Channel, error) {
+	if len(config.URL) == 0 || len(config.Topic) == 0 {
+		return nil, fmt.Errorf("%w: ntfy requires a url and topic", ErrInvalidChannel)
+	}
+
+	url := strings.TrimRight(config.URL, "/") + "/" + config.Topic
+
+	return &ntfyChannel{name: config.Name, url: url, token: config.Token}, nil
+}
+
+func (c *ntfyChannel) Name() string {
+	return c.name
+}
+
+func (c *ntfyChannel) Send(ctx context.Context, event Event) error {
+	headers := map[string]string{
+		"Title":    event.Title,
+		"Tags":     event.Type,
+		"Priority": ntfyPriority(event.Severity),
+	}
+
+	if len(c.token) != 0 {
+		headers["Authorization"] = "Bearer " + c.token
+	}
+
+	return post(ctx, c.url, headers, []byte(event.Message))
+}
+
+func ntfyPriority(severity string) string {
+	switch severity {
+	case SEVERITY_CRITICAL:
+		return "urgent"
+	case SEVERITY_WARNING:
+		return "high"
+	default:
+		return "default"
+	}
+}
+
+func newGotifyChannel(config ChannelConfig) (Channel, error) {
+	if len(config.URL) == 0 || len(config.Token) == 0 {
+		return nil, fmt.Errorf("%w: gotify requires a url and token", ErrInvalidChannel)
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = DefaultGotifyPriority
+	}
+
+	url := strings.TrimRight(config.URL, "/") + "/message"
+
+	return &gotifyChannel{name: config.Name, url: url, token: config.Token, priority: priority}, nil
+}
+
+func (c *gotifyChannel) Name() string {
+	return c.name
+}
+
+func (c *gotifyChannel) Send(ctx context.Context, event Event) error {
+	// critical events use the highest priority so they break through do not disturb
+	priority := c.priority
+	if event.Severity == SEVERITY_CRITICAL {
+		priority = MaxGotifyPriority
+	}
+
+	payload := struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}{
+		Title:    event.Title,
+		Message:  event.Message,
+		Priority: priority,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"X-Gotify-Key": c.token,
+	}
+
+	return post(ctx, c.url, headers, body)
+}
+
+// post sends the body to the URL and treats any status other than 2xx as a failure.
+func post(ctx context.Context, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
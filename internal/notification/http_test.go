@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPChannels(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	event := Event{Type: TYPE_LEAK, Title: "Plunger Leak Alert", Message: "Leak detected!!"}
+
+	t.Run("should post the message to a webhook", func(t *testing.T) {
+		c, err := newWebhookChannel(ChannelConfig{Name: "hook", URL: server.URL, Headers: map[string]string{"X-Api-Key": "key"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.Send(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("failed to decode the payload: %v", err)
+		}
+
+		if payload.Type != TYPE_LEAK || payload.Message != event.Message || request.Header.Get("X-Api-Key") != "key" {
+			t.Errorf("unexpected request %+v %v", payload, request.Header)
+		}
+	})
+
+	t.Run("should publish the message to an ntfy topic", func(t *testing.T) {
+		c, err := newNtfyChannel(ChannelConfig{Name: "ntfy", URL: server.URL + "/", Topic: "plunger", Token: "tk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.Send(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if request.URL.Path != "/plunger" || request.Header.Get("Title") != event.Title || request.Header.Get("Authorization") != "Bearer tk" || string(body) != event.Message {
+			t.Errorf("unexpected request %s %v %s", request.URL.Path, request.Header, body)
+		}
+	})
+
+	t.Run("should push the message to gotify", func(t *testing.T) {
+		c, err := newGotifyChannel(ChannelConfig{Name: "gotify", URL: server.URL, Token: "app-token"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := c.Send(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if request.URL.Path != "/message" || request.Header.Get("X-Gotify-Key") != "app-token" {
+			t.Errorf("unexpected request %s %v", request.URL.Path, request.Header)
+		}
+	})
+
+	t.Run("should fail when the server rejects the message", func(t *testing.T) {
+		status = http.StatusUnauthorized
+		defer func() { status = http.StatusOK }()
+
+		c, _ := newWebhookChannel(ChannelConfig{Name: "hook", URL: server.URL})
+		if err := c.Send(context.Background(), event); err == nil {
+			t.Errorf("expected an error for status %d", http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	channelsMu sync.RWMutex
+	factories  = make(map[string]ChannelFactory)
+)
+
+// RegisterChannel makes a channel type available to the notification configuration.
+// If RegisterChannel is called twice with the same channel type it panics.
+func RegisterChannel(channelType string, factory ChannelFactory) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+
+	if factory == nil {
+		panic("notification: RegisterChannel factory is nil")
+	}
+
+	if _, dup := factories[channelType]; dup {
+		panic("notification: RegisterChannel called twice for channel " + channelType)
+	}
+
+	factories[channelType] = factory
+}
+
+// ChannelTypes returns a sorted list of the registered channel types.
+func ChannelTypes() []string {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+
+	list := make([]string, 0, len(factories))
+	for name := range factories {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+func lookupChannel(channelType string) (ChannelFactory, error) {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+
+	factory, ok := factories[channelType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannelType, channelType)
+	}
+
+	return factory, nil
+}
+
+// NewRouter creates the configured channels and validates that every route refers to one of them.
+func NewRouter(config Config) (*Router, error) {
+	filter, err := NewFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Router{
+		filter:   filter,
+		channels: make(map[string]Channel),
+		names:    make([]string, 0, len(config.Channels)),
+		routes:   config.Routes,
+	}
+
+	for _, c := range config.Channels {
+		c = expandChannelConfig(c)
+		if len(c.Name) == 0 {
+			c.Name = c.Type
+		}
+
+		if _, dup := r.channels[c.Name]; dup {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateChannel, c.Name)
+		}
+
+		factory, err := lookupChannel(c.Type)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", c.Name, err)
+		}
+
+		channel, err := factory(c)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", c.Name, err)
+		}
+
+		slog.Debug("registered notification channel", "name", c.Name, "type", c.Type)
+
+		r.channels[c.Name] = channel
+		r.names = append(r.names, c.Name)
+
+		if _, ok := channel.(SMSSender); ok && len(r.sms) == 0 {
+			r.sms = c.Name
+		}
+
+		if _, ok := channel.(EmailSender); ok && len(r.email) == 0 {
+			r.email = c.Name
+		}
+	}
+
+	for _, route := range config.Routes {
+		for _, name := range route.Channels {
+			if _, ok := r.channels[name]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Channels returns the names of the configured channels.
+func (r *Router) Channels() []string {
+	return r.names
+}
+
+// Capturing reports if any of the channels record their deliveries.
+func (r *Router) Capturing() bool {
+	for _, name := range r.names {
+		if _, ok := r.channels[name].(Capturer); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Captured returns the deliveries recorded by the capture channels, oldest first.
+func (r *Router) Captured() []Capture {
+	captured := make([]Capture, 0)
+	for _, name := range r.names {
+		if c, ok := r.channels[name].(Capturer); ok {
+			captured = append(captured, c.Captured()...)
+		}
+	}
+
+	sort.SliceStable(captured, func(i, j int) bool {
+		return captured[i].CapturedAt.Before(captured[j].CapturedAt)
+	})
+
+	return captured
+}
+
+// ClearCaptured forgets the deliveries recorded by the capture channels.
+func (r *Router) ClearCaptured() {
+	for _, name := range r.names {
+		if c, ok := r.channels[name].(Capturer); ok {
+			c.ClearCaptured()
+		}
+	}
+}
+
+// Send delivers the event to every channel routed for its type and to each recipient's phone number and email,
+// and returns the errors from the deliveries that failed.
+// ErrSuppressed is returned if the event was filtered by the quiet hours, deduplication or rate limit.
+func (r *Router) Send(ctx context.Context, event Event, recipients ...Recipient) error {
+	event, targets, err := r.Targets(event, recipients...)
+	if errors.Is(err, ErrSuppressed) {
+		return err
+	}
+
+	errs := []error{err}
+	for _, target := range targets {
+		if err := r.Deliver(ctx, event, target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Targets applies the defaults and filter to the event and returns it with the deliveries it should be sent to:
+// every channel routed for its type, then a text and email for each recipient.
+// ErrSuppressed is returned if the event was filtered by the quiet hours, deduplication or rate limit.
+// ErrNoDirectChannel is returned with the other targets if a recipient can't be reached.
+func (r *Router) Targets(event Event, recipients ...Recipient) (Event, []Target, error) {
+	event = withDefaults(event)
+
+	if ok, reason := r.filter.Allow(event, event.OccurredAt); !ok {
+		return event, nil, fmt.Errorf("%w: %s", ErrSuppressed, reason)
+	}
+
+	targets := make([]Target, 0)
+	for _, name := range r.route(event.Type) {
+		targets = append(targets, Target{Channel: name})
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		if len(recipient.PhoneNumber) != 0 {
+			if len(r.sms) == 0 {
+				errs = append(errs, fmt.Errorf("recipient %s: %w: sms", recipient.Name, ErrNoDirectChannel))
+			} else {
+				targets = append(targets, Target{Channel: r.sms, PhoneNumber: recipient.PhoneNumber})
+			}
+		}
+
+		if len(recipient.Email) != 0 {
+			if len(r.email) == 0 {
+				errs = append(errs, fmt.Errorf("recipient %s: %w: email", recipient.Name, ErrNoDirectChannel))
+			} else {
+				targets = append(targets, Target{Channel: r.email, Email: recipient.Email})
+			}
+		}
+	}
+
+	return event, targets, errors.Join(errs...)
+}
+
+// Deliver sends the event to one target, bounded by DefaultSendTimeout.
+func (r *Router) Deliver(ctx context.Context, event Event, target Target) error {
+	channel, ok := r.channels[target.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, target.Channel)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, DefaultSendTimeout)
+	defer cancel()
+
+	var err error
+	switch {
+	case len(target.PhoneNumber) != 0:
+		sender, ok := channel.(SMSSender)
+		if !ok {
+			return fmt.Errorf("channel %s: %w: sms", target.Channel, ErrNoDirectChannel)
+		}
+		err = sender.SendSMS(sendCtx, event, target.PhoneNumber)
+
+	case len(target.Email) != 0:
+		sender, ok := channel.(EmailSender)
+		if !ok {
+			return fmt.Errorf("channel %s: %w: email", target.Channel, ErrNoDirectChannel)
+		}
+		err = sender.SendEmail(sendCtx, event, target.Email)
+
+	default:
+		err = channel.Send(sendCtx, event)
+	}
+
+	if err != nil {
+		return fmt.Errorf("channel %s: %w", target.Channel, err)
+	}
+
+	return nil
+}
+
+// withDefaults fills in the severity, title and time of an event that didn't set them.
+func withDefaults(event Event) Event {
+	if len(event.Type) == 0 {
+		event.Type = TYPE_SYSTEM
+	}
+
+	if len(event.Severity) == 0 {
+		event.Severity = SEVERITY_INFO
+	}
+
+	if len(event.Title) == 0 {
+		event.Title = Title(event.Type)
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	return event
+}
+
+// route returns the names of the channels that should receive the message type, each channel is only returned once.
+func (r *Router) route(msgType string) []string {
+	if len(r.routes) == 0 {
+		return r.names
+	}
+
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, route := range r.routes {
+		if !routeMatches(route, msgType) {
+			continue
+		}
+
+		for _, name := range route.Channels {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+func routeMatches(route RouteConfig, msgType string) bool {
+	for _, t := range route.Types {
+		if t == TYPE_ANY || t == msgType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandChannelConfig replaces ${NAME} references in the settings with the environment variable.
+func expandChannelConfig(c ChannelConfig) ChannelConfig {
+	c.Host = os.ExpandEnv(c.Host)
+	c.Username = os.ExpandEnv(c.Username)
+	c.Password = os.ExpandEnv(c.Password)
+	c.From = os.ExpandEnv(c.From)
+	c.URL = os.ExpandEnv(c.URL)
+	c.Token = os.ExpandEnv(c.Token)
+	c.Topic = os.ExpandEnv(c.Topic)
+	c.AccountSID = os.ExpandEnv(c.AccountSID)
+	c.AuthToken = os.ExpandEnv(c.AuthToken)
+	c.Path = os.ExpandEnv(c.Path)
+
+	to := make([]string, 0, len(c.To))
+	for _, t := range c.To {
+		to = append(to, os.ExpandEnv(t))
+	}
+	c.To = to
+
+	headers := make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	c.Headers = headers
+
+	return c
+}
+
+// Title returns the default title for an event type.
+func Title(msgType string) string {
+	switch msgType {
+	case TYPE_LEAK:
+		return "Plunger Leak Alert"
+	case TYPE_OZONE:
+		return "Plunger Ozone"
+	case TYPE_ALERT:
+		return "Plunger Temperature Alert"
+	case TYPE_INTERLOCK:
+		return "Plunger Safety Interlock"
+	case TYPE_PLUNGE:
+		return "Plunger Timer"
+	default:
+		return "Plunger Notification"
+	}
+}
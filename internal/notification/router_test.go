@@ -0,0 +1,253 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recordingChannel struct {
+	name   string
+	events []Event
+	err    error
+}
+
+func (c *recordingChannel) Name() string {
+	return c.name
+}
+
+func (c *recordingChannel) Send(ctx context.Context, event Event) error {
+	c.events = append(c.events, event)
+	return c.err
+}
+
+// directChannel records the events sent to subscribed users.
+type directChannel struct {
+	recordingChannel
+	phoneNumbers []string
+	addresses    []string
+}
+
+func (c *directChannel) SendSMS(ctx context.Context, event Event, phoneNumber string) error {
+	c.phoneNumbers = append(c.phoneNumbers, phoneNumber)
+	return nil
+}
+
+func (c *directChannel) SendEmail(ctx context.Context, event Event, address string) error {
+	c.addresses = append(c.addresses, address)
+	return nil
+}
+
+// recorded and direct hold the channels created by the test channel types so the tests can inspect them.
+var (
+	recorded = make(map[string]*recordingChannel)
+	direct   = make(map[string]*directChannel)
+)
+
+func init() {
+	RegisterChannel("recording", func(config ChannelConfig) (Channel, error) {
+		c := &recordingChannel{name: config.Name}
+		if config.Token == "fail" {
+			c.err = errors.New("failed to send")
+		}
+		recorded[config.Name] = c
+		return c, nil
+	})
+
+	RegisterChannel("direct", func(config ChannelConfig) (Channel, error) {
+		c := &directChannel{recordingChannel: recordingChannel{name: config.Name}}
+		direct[config.Name] = c
+		return c, nil
+	})
+}
+
+func TestNewRouter(t *testing.T) {
+	t.Run("should fail with an unknown channel type", func(t *testing.T) {
+		_, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "pager", Type: "pager"}}})
+		if !errors.Is(err, ErrUnknownChannelType) {
+			t.Errorf("expected %v, got %v", ErrUnknownChannelType, err)
+		}
+	})
+
+	t.Run("should fail with a duplicate channel name", func(t *testing.T) {
+		_, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "a", Type: "recording"}, {Name: "a", Type: "recording"}}})
+		if !errors.Is(err, ErrDuplicateChannel) {
+			t.Errorf("expected %v, got %v", ErrDuplicateChannel, err)
+		}
+	})
+
+	t.Run("should fail when a route refers to an unknown channel", func(t *testing.T) {
+		config := Config{
+			Channels: []ChannelConfig{{Name: "a", Type: "recording"}},
+			Routes:   []RouteConfig{{Types: []string{TYPE_LEAK}, Channels: []string{"b"}}},
+		}
+
+		_, err := NewRouter(config)
+		if !errors.Is(err, ErrUnknownChannel) {
+			t.Errorf("expected %v, got %v", ErrUnknownChannel, err)
+		}
+	})
+
+	t.Run("should fail with an invalid channel configuration", func(t *testing.T) {
+		_, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "hook", Type: CHANNELTYPE_WEBHOOK}}})
+		if !errors.Is(err, ErrInvalidChannel) {
+			t.Errorf("expected %v, got %v", ErrInvalidChannel, err)
+		}
+	})
+
+	t.Run("should expand environment variables in the settings", func(t *testing.T) {
+		t.Setenv("PLUNGER_TEST_TOKEN", "secret")
+
+		router, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "gotify", Type: CHANNELTYPE_GOTIFY, URL: "http://localhost", Token: "${PLUNGER_TEST_TOKEN}"}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if c := router.channels["gotify"].(*gotifyChannel); c.token != "secret" {
+			t.Errorf("expected token %s, got %s", "secret", c.token)
+		}
+	})
+}
+
+func TestRouterSend(t *testing.T) {
+	config := Config{
+		Channels: []ChannelConfig{
+			{Name: "email", Type: "recording"},
+			{Name: "push", Type: "recording"},
+			{Name: "broken", Type: "recording", Token: "fail"},
+		},
+		Routes: []RouteConfig{
+			{Types: []string{TYPE_LEAK, TYPE_INTERLOCK}, Channels: []string{"push", "email"}},
+			{Types: []string{TYPE_ANY}, Channels: []string{"email"}},
+			{Types: []string{TYPE_ALERT}, Channels: []string{"broken"}},
+		},
+	}
+
+	router, err := NewRouter(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("should send a message to each routed channel once", func(t *testing.T) {
+		err := router.Send(context.Background(), Event{Type: TYPE_LEAK, Title: "Leak", Message: "Leak detected"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(recorded["push"].events) != 1 || len(recorded["email"].events) != 1 || len(recorded["broken"].events) != 0 {
+			t.Errorf("unexpected deliveries push=%d email=%d broken=%d", len(recorded["push"].events), len(recorded["email"].events), len(recorded["broken"].events))
+		}
+	})
+
+	t.Run("should only send unrouted types to the catch all route", func(t *testing.T) {
+		err := router.Send(context.Background(), Event{Type: TYPE_OZONE, Message: "Ozone generator was started"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(recorded["push"].events) != 1 || len(recorded["email"].events) != 2 {
+			t.Errorf("unexpected deliveries push=%d email=%d", len(recorded["push"].events), len(recorded["email"].events))
+		}
+	})
+
+	t.Run("should return the errors from failed channels", func(t *testing.T) {
+		err := router.Send(context.Background(), Event{Type: TYPE_ALERT, Message: "Water is too warm"})
+		if err == nil {
+			t.Fatalf("expected an error from the broken channel")
+		}
+
+		if len(recorded["email"].events) != 3 {
+			t.Errorf("expected the other channels to still receive the message")
+		}
+	})
+
+	t.Run("should suppress a duplicate event", func(t *testing.T) {
+		err := router.Send(context.Background(), Event{Type: TYPE_OZONE, Message: "Ozone generator was started"})
+		if !errors.Is(err, ErrSuppressed) {
+			t.Errorf("expected %v, got %v", ErrSuppressed, err)
+		}
+	})
+}
+
+func TestRouterSendRecipients(t *testing.T) {
+	t.Run("should text and email each recipient", func(t *testing.T) {
+		router, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "users", Type: "direct"}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		recipients := []Recipient{
+			{Name: "a@mail.com", PhoneNumber: "+15555550100", Email: "a@mail.com"},
+			{Name: "b@mail.com", Email: "b@mail.com"},
+		}
+
+		err = router.Send(context.Background(), Event{Type: TYPE_LEAK, Message: "Leak detected"}, recipients...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := direct["users"]
+		if len(c.events) != 1 || len(c.phoneNumbers) != 1 || len(c.addresses) != 2 {
+			t.Errorf("unexpected deliveries events=%d sms=%d email=%d", len(c.events), len(c.phoneNumbers), len(c.addresses))
+		}
+	})
+
+	t.Run("should fail when no channel can text the recipient", func(t *testing.T) {
+		router, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "only-broadcast", Type: "recording"}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err = router.Send(context.Background(), Event{Type: TYPE_LEAK, Message: "Leak detected"}, Recipient{Name: "a", PhoneNumber: "+15555550100"})
+		if !errors.Is(err, ErrNoDirectChannel) {
+			t.Errorf("expected %v, got %v", ErrNoDirectChannel, err)
+		}
+	})
+}
+
+func TestRouterTargets(t *testing.T) {
+	router, err := NewRouter(Config{Channels: []ChannelConfig{{Name: "push", Type: "recording"}, {Name: "outbox", Type: "direct"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("should return a target for each channel and recipient", func(t *testing.T) {
+		event, targets, err := router.Targets(Event{Type: TYPE_LEAK, Message: "Leak detected"}, Recipient{Name: "a", PhoneNumber: "+15555550100", Email: "a@mail.com"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if event.Severity != SEVERITY_INFO || event.OccurredAt.IsZero() {
+			t.Errorf("expected the event defaults to be set, got %+v", event)
+		}
+
+		expected := []Target{{Channel: "push"}, {Channel: "outbox"}, {Channel: "outbox", PhoneNumber: "+15555550100"}, {Channel: "outbox", Email: "a@mail.com"}}
+		if len(targets) != len(expected) {
+			t.Fatalf("expected %d targets, got %+v", len(expected), targets)
+		}
+
+		for i := range expected {
+			if targets[i] != expected[i] {
+				t.Errorf("expected target %+v, got %+v", expected[i], targets[i])
+			}
+		}
+	})
+
+	t.Run("should deliver to a single target", func(t *testing.T) {
+		err := router.Deliver(context.Background(), Event{Type: TYPE_LEAK}, Target{Channel: "outbox", Email: "b@mail.com"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if c := direct["outbox"]; len(c.addresses) != 1 || c.addresses[0] != "b@mail.com" || len(c.events) != 0 {
+			t.Errorf("unexpected deliveries %+v", c)
+		}
+	})
+
+	t.Run("should fail to deliver to an unknown channel", func(t *testing.T) {
+		err := router.Deliver(context.Background(), Event{Type: TYPE_LEAK}, Target{Channel: "pager"})
+		if !errors.Is(err, ErrUnknownChannel) {
+			t.Errorf("expected %v, got %v", ErrUnknownChannel, err)
+		}
+	})
+}
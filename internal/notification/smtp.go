@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultSMTPPort = 587
+
+func init() {
+	RegisterChannel(CHANNELTYPE_SMTP, newSMTPChannel)
+}
+
+// smtpChannel emails each address in the configuration, or a subscribed user's address.
+type smtpChannel struct {
+	name   string
+	config ChannelConfig
+}
+
+func newSMTPChannel(config ChannelConfig) (Channel, error) {
+	if len(config.Host) == 0 || len(config.From) == 0 {
+		return nil, fmt.Errorf("%w: smtp requires a host and from address", ErrInvalidChannel)
+	}
+
+	if config.Port == 0 {
+		config.Port = DefaultSMTPPort
+	}
+
+	return &smtpChannel{name: config.Name, config: config}, nil
+}
+
+func (c *smtpChannel) Name() string {
+	return c.name
+}
+
+// Send the message to the configured addresses, there is nothing to send when the channel only emails subscribed users.
+func (c *smtpChannel) Send(ctx context.Context, event Event) error {
+	if len(c.config.To) == 0 {
+		return nil
+	}
+
+	return c.sendMail(ctx, event, c.config.To)
+}
+
+// SendEmail sends the message to a subscribed user's address.
+func (c *smtpChannel) SendEmail(ctx context.Context, event Event, address string) error {
+	return c.sendMail(ctx, event, []string{address})
+}
+
+// sendMail sends the message the way smtp.SendMail does, but net/smtp doesn't take a context so the connection
+// is given the context's deadline, or DefaultSendTimeout, and closed if the context is canceled.
+func (c *smtpChannel) sendMail(ctx context.Context, event Event, to []string) error {
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	dialer := net.Dialer{Timeout: DefaultSendTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultSendTimeout)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if len(c.config.Username) != 0 {
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(c.config.From); err != nil {
+		return err
+	}
+
+	for _, address := range to {
+		if err := client.Rcpt(address); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(c.buildMessage(event, to)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (c *smtpChannel) buildMessage(event Event, to []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", event.Title)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(event.Message)
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSMTPChannel(t *testing.T) {
+	t.Run("should give up on a server that doesn't respond", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer listener.Close()
+
+		// accept the connection but never send the greeting
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				defer conn.Close()
+				time.Sleep(5 * time.Second)
+			}
+		}()
+
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+		portNumber, _ := strconv.Atoi(port)
+
+		c, err := newSMTPChannel(ChannelConfig{Name: "email", Host: host, Port: portNumber, From: "plunger@example.com"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if err := c.(*smtpChannel).SendEmail(ctx, Event{Title: "Plunger", Message: "hi"}, "me@example.com"); err == nil {
+			t.Error("expected the send to fail")
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the send to give up at the deadline, it took %v", elapsed)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikoksr/notify/service/twilio"
+)
+
+func init() {
+	RegisterChannel(CHANNELTYPE_TWILIO, newTwilioChannel)
+}
+
+// twilioChannel sends an SMS to each phone number in the configuration, or to a subscribed user's phone number.
+type twilioChannel struct {
+	name    string
+	config  ChannelConfig
+	service *twilio.Service
+}
+
+func newTwilioChannel(config ChannelConfig) (Channel, error) {
+	if len(config.AccountSID) == 0 || len(config.AuthToken) == 0 || len(config.From) == 0 {
+		return nil, fmt.Errorf("%w: twilio requires an account_sid, auth_token and from phone number", ErrInvalidChannel)
+	}
+
+	service, err := twilio.New(config.AccountSID, config.AuthToken, config.From)
+	if err != nil {
+		return nil, err
+	}
+
+	service.AddReceivers(config.To...)
+
+	return &twilioChannel{name: config.Name, config: config, service: service}, nil
+}
+
+func (c *twilioChannel) Name() string {
+	return c.name
+}
+
+func (c *twilioChannel) Send(ctx context.Context, event Event) error {
+	return c.service.Send(ctx, event.Title, event.Message)
+}
+
+// SendSMS texts the message to a subscribed user's phone number.
+func (c *twilioChannel) SendSMS(ctx context.Context, event Event, phoneNumber string) error {
+	service, err := twilio.New(c.config.AccountSID, c.config.AuthToken, c.config.From)
+	if err != nil {
+		return err
+	}
+
+	service.AddReceivers(phoneNumber)
+
+	return service.Send(ctx, event.Title, event.Message)
+}
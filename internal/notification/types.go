@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	CHANNELTYPE_SMTP    = "smtp"
+	CHANNELTYPE_WEBHOOK = "webhook"
+	CHANNELTYPE_NTFY    = "ntfy"
+	CHANNELTYPE_GOTIFY  = "gotify"
+	CHANNELTYPE_TWILIO  = "twilio"
+	CHANNELTYPE_CAPTURE = "capture"
+
+	// Message types used to route notifications to channels.
+	TYPE_LEAK      = "leak"
+	TYPE_OZONE     = "ozone"
+	TYPE_ALERT     = "alert"
+	TYPE_INTERLOCK = "interlock"
+	TYPE_PLUNGE    = "plunge"
+	TYPE_SYSTEM    = "system"
+
+	// TYPE_ANY matches every message type in a route.
+	TYPE_ANY = "*"
+
+	SEVERITY_INFO     = "info"
+	SEVERITY_WARNING  = "warning"
+	SEVERITY_CRITICAL = "critical"
+
+	// DefaultSendTimeout bounds how long a channel may take to deliver a message.
+	DefaultSendTimeout = 10 * time.Second
+
+	// DefaultDedupeSeconds is how long an event about the same thing is suppressed after it was sent.
+	DefaultDedupeSeconds = 600
+
+	// DefaultRateLimitEvents is how many events of one type can be sent in DefaultRateLimitSeconds.
+	DefaultRateLimitEvents  = 6
+	DefaultRateLimitSeconds = 3600
+)
+
+var (
+	ErrUnknownChannelType = errors.New("unknown notification channel type")
+	ErrUnknownChannel     = errors.New("unknown notification channel")
+	ErrDuplicateChannel   = errors.New("duplicate notification channel name")
+	ErrInvalidChannel     = errors.New("invalid notification channel")
+	ErrInvalidQuietHours  = errors.New("quiet hours must be formatted as HH:MM")
+	ErrSuppressed         = errors.New("notification suppressed")
+	ErrNoDirectChannel    = errors.New("no notification channel can deliver to the recipient")
+)
+
+type (
+	// Event is a notification sent to the channels routed for its type.
+	Event struct {
+		Type     string         `json:"type"`
+		Severity string         `json:"severity"`
+		Source   string         `json:"source"` // Source is what raised the event, e.g. the monitor routine or alert rule.
+		Title    string         `json:"title"`
+		Message  string         `json:"message"`
+		Payload  map[string]any `json:"payload,omitempty"`
+
+		// Key identifies what the event is about for deduplication and the rate limit of critical events,
+		// e.g. the leak sensor or alert rule. It mustn't include readings that change from one event to the next.
+		Key string `json:"-"`
+
+		// Requested events were asked for by a user, e.g. the countdown of their plunge, and aren't rate limited.
+		Requested bool `json:"-"`
+
+		OccurredAt time.Time `json:"occurred_at"`
+	}
+
+	// Channel delivers events to one destination, e.g. an email address or a push topic.
+	Channel interface {
+		Name() string
+		Send(ctx context.Context, event Event) error
+	}
+
+	// SMSSender is a Channel that can text an event to a recipient's phone number.
+	SMSSender interface {
+		SendSMS(ctx context.Context, event Event, phoneNumber string) error
+	}
+
+	// EmailSender is a Channel that can email an event to a recipient's address.
+	EmailSender interface {
+		SendEmail(ctx context.Context, event Event, address string) error
+	}
+
+	// Recipient is a user subscribed to an event, an empty phone number or email skips that delivery.
+	Recipient struct {
+		Name        string
+		PhoneNumber string
+		Email       string
+	}
+
+	// Target is one delivery of an event, to a channel's configured destinations or to a recipient's phone number or email.
+	Target struct {
+		Channel     string
+		PhoneNumber string // PhoneNumber texts a recipient through the channel instead of its destinations.
+		Email       string // Email emails a recipient through the channel instead of its destinations.
+	}
+
+	// ChannelFactory creates a Channel from its configuration.
+	ChannelFactory func(config ChannelConfig) (Channel, error)
+
+	// Config is the notifications section of the configuration file.
+	Config struct {
+		Channels []ChannelConfig `json:"channels"`
+
+		// Routes send each message type to a list of channels, every channel receives every message when there are no routes.
+		Routes []RouteConfig `json:"routes"`
+
+		// QuietHours suppress everything but critical events, e.g. overnight.
+		QuietHours QuietHoursConfig `json:"quiet_hours"`
+
+		// DedupeSeconds is how long an event with the same type, source and key is suppressed after it was sent.
+		DedupeSeconds int `json:"dedupe_seconds"`
+
+		RateLimit RateLimitConfig `json:"rate_limit"`
+	}
+
+	// QuietHoursConfig is a daily period in the server's local time, the period may wrap past midnight.
+	QuietHoursConfig struct {
+		Start string `json:"start"` // Start of the quiet hours as HH:MM.
+		End   string `json:"end"`   // End of the quiet hours as HH:MM.
+	}
+
+	// RateLimitConfig limits how many events of each type, or with each key if they are critical, are sent in a period.
+	RateLimitConfig struct {
+		MaxEvents     int `json:"max_events"`
+		PeriodSeconds int `json:"period_seconds"`
+	}
+
+	// Filter decides if an event should be sent based on the quiet hours, deduplication and rate limit.
+	Filter struct {
+		sync.Mutex
+		quietStart int // minutes after midnight, -1 if there are no quiet hours
+		quietEnd   int
+		dedupe     time.Duration
+		maxEvents  int
+		period     time.Duration
+
+		lastSent map[string]time.Time   // when each distinct event was last sent
+		sent     map[string][]time.Time // when the events of each type were sent within the rate limit period
+	}
+
+	// RouteConfig sends the message types to the named channels.
+	RouteConfig struct {
+		Types    []string `json:"types"` // Types of messages to route, "*" matches every type.
+		Channels []string `json:"channels"`
+	}
+
+	// ChannelConfig holds the settings for every channel type, only the fields used by the type need to be set.
+	// Values may reference environment variables as ${NAME} so secrets don't need to be stored in the file.
+	ChannelConfig struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+
+		// smtp
+		Host     string `json:"host,omitempty"`
+		Port     int    `json:"port,omitempty"`
+		Username string `json:"username,omitempty"`
+		Password string `json:"password,omitempty"`
+
+		// smtp and twilio, To is optional when the channel only delivers to subscribed users
+		From string   `json:"from,omitempty"`
+		To   []string `json:"to,omitempty"`
+
+		// webhook, ntfy and gotify
+		URL     string            `json:"url,omitempty"`
+		Token   string            `json:"token,omitempty"`
+		Headers map[string]string `json:"headers,omitempty"`
+
+		// ntfy
+		Topic string `json:"topic,omitempty"`
+
+		// gotify
+		Priority int `json:"priority,omitempty"`
+
+		// twilio
+		AccountSID string `json:"account_sid,omitempty"`
+		AuthToken  string `json:"auth_token,omitempty"`
+
+		// capture
+		Path string `json:"path,omitempty"` // Path of a file to append each delivery to as a JSON line.
+		Size int    `json:"size,omitempty"` // Size is how many deliveries are kept in memory.
+	}
+
+	// Router sends each message to the channels routed for its type.
+	Router struct {
+		filter   *Filter
+		channels map[string]Channel
+		names    []string // names of the channels in configuration order
+		routes   []RouteConfig
+
+		sms   string // name of the first configured channel that can text a recipient
+		email string // name of the first configured channel that can email a recipient
+	}
+)
@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"time"
+)
+
+// Validate the protocol.
+func (p Protocol) Validate() error {
+	if len(p.Name) == 0 {
+		return ErrNameMissing
+	}
+
+	if p.Rounds < 1 || p.Rounds > MaxRounds {
+		return ErrInvalidRounds
+	}
+
+	if len(p.Phases) == 0 {
+		return ErrNoPhases
+	}
+
+	for _, phase := range p.Phases {
+		switch phase.Kind {
+		case PHASE_COLD, PHASE_REST, PHASE_BREATHWORK:
+		default:
+			return ErrInvalidPhaseKind
+		}
+
+		if phase.DurationSeconds <= 0 {
+			return ErrInvalidPhaseDuration
+		}
+	}
+
+	if Duration(p.Expand()) > MaxDuration {
+		return ErrProtocolTooLong
+	}
+
+	return nil
+}
+
+// Expand repeats the phases for each round, a phase without a name is named after its kind.
+func (p Protocol) Expand() []Phase {
+	phases := make([]Phase, 0, len(p.Phases)*max(p.Rounds, 1))
+	for round := 0; round < max(p.Rounds, 1); round++ {
+		for _, phase := range p.Phases {
+			if len(phase.Name) == 0 {
+				phase.Name = phase.Kind
+			}
+
+			phases = append(phases, phase)
+		}
+	}
+
+	return phases
+}
+
+// Duration is the total time of the phases.
+func Duration(phases []Phase) time.Duration {
+	var total time.Duration
+	for _, phase := range phases {
+		total += time.Duration(phase.DurationSeconds) * time.Second
+	}
+
+	return total
+}
+
+// At finds the phase that is running at now for phases that started at start.
+// Once every phase has ended the position is done and its index is the number of phases.
+func At(phases []Phase, start time.Time, now time.Time) Position {
+	phaseStart := start
+	for i, phase := range phases {
+		phaseEnd := phaseStart.Add(time.Duration(phase.DurationSeconds) * time.Second)
+		if now.Before(phaseEnd) {
+			return Position{Index: i, Phase: phase, StartedAt: phaseStart, EndsAt: phaseEnd}
+		}
+
+		phaseStart = phaseEnd
+	}
+
+	return Position{Index: len(phases), StartedAt: phaseStart, EndsAt: phaseStart, Done: true}
+}
+
+// Bounds returns when the phase at index starts and ends.
+func Bounds(phases []Phase, start time.Time, index int) (time.Time, time.Time) {
+	phaseStart := start.Add(Duration(phases[:index]))
+	return phaseStart, phaseStart.Add(time.Duration(phases[index].DurationSeconds) * time.Second)
+}
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func contrast() Protocol {
+	return Protocol{
+		Name:   "contrast",
+		Rounds: 3,
+		Phases: []Phase{
+			{Kind: PHASE_COLD, DurationSeconds: 60},
+			{Name: "warm up", Kind: PHASE_REST, DurationSeconds: 180},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol func(p *Protocol)
+		expected error
+	}{
+		{"should accept a valid protocol", func(p *Protocol) {}, nil},
+		{"should require a name", func(p *Protocol) { p.Name = "" }, ErrNameMissing},
+		{"should require a round", func(p *Protocol) { p.Rounds = 0 }, ErrInvalidRounds},
+		{"should require a phase", func(p *Protocol) { p.Phases = nil }, ErrNoPhases},
+		{"should reject an unknown kind", func(p *Protocol) { p.Phases[0].Kind = "sauna" }, ErrInvalidPhaseKind},
+		{"should reject a phase without a duration", func(p *Protocol) { p.Phases[1].DurationSeconds = 0 }, ErrInvalidPhaseDuration},
+		{"should reject a protocol that runs too long", func(p *Protocol) { p.Rounds = 20; p.Phases[1].DurationSeconds = 600 }, ErrProtocolTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := contrast()
+			tt.protocol(&p)
+
+			if err := p.Validate(); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Run("should repeat the phases for each round", func(t *testing.T) {
+		phases := contrast().Expand()
+
+		if len(phases) != 6 || phases[0].Name != PHASE_COLD || phases[5].Name != "warm up" {
+			t.Errorf("unexpected phases %+v", phases)
+		}
+
+		if Duration(phases) != 12*time.Minute {
+			t.Errorf("expected 12 minutes, got %v", Duration(phases))
+		}
+	})
+}
+
+func TestAt(t *testing.T) {
+	phases := contrast().Expand()
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	t.Run("should find the first phase at the start", func(t *testing.T) {
+		p := At(phases, start, start)
+		if p.Index != 0 || p.Done || !p.EndsAt.Equal(start.Add(time.Minute)) {
+			t.Errorf("unexpected position %+v", p)
+		}
+	})
+
+	t.Run("should move to the next phase when a phase ends", func(t *testing.T) {
+		p := At(phases, start, start.Add(4*time.Minute+30*time.Second))
+		if p.Index != 2 || p.Phase.Kind != PHASE_COLD || !p.StartedAt.Equal(start.Add(4*time.Minute)) {
+			t.Errorf("unexpected position %+v", p)
+		}
+	})
+
+	t.Run("should be done after the last phase", func(t *testing.T) {
+		p := At(phases, start, start.Add(12*time.Minute))
+		if !p.Done || p.Index != len(phases) {
+			t.Errorf("unexpected position %+v", p)
+		}
+	})
+
+	t.Run("should return the bounds of a phase", func(t *testing.T) {
+		from, to := Bounds(phases, start, 3)
+		if !from.Equal(start.Add(5*time.Minute)) || !to.Equal(start.Add(8*time.Minute)) {
+			t.Errorf("unexpected bounds %v %v", from, to)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PHASE_COLD       = "cold"
+	PHASE_REST       = "rest"
+	PHASE_BREATHWORK = "breathwork"
+
+	// MaxRounds limits how many times the phases of a protocol can be repeated.
+	MaxRounds = 20
+
+	// MaxDuration is the longest a protocol can run, including every round.
+	MaxDuration = 2 * time.Hour
+)
+
+var (
+	ErrNameMissing          = errors.New("protocol name is required")
+	ErrNoPhases             = errors.New("a protocol needs at least one phase")
+	ErrInvalidPhaseKind     = errors.New("phase kind must be one of 'cold', 'rest' or 'breathwork'")
+	ErrInvalidPhaseDuration = errors.New("'duration_seconds' of a phase must be greater than zero")
+	ErrInvalidRounds        = errors.New("'rounds' must be between 1 and 20")
+	ErrProtocolTooLong      = errors.New("a protocol can't run for more than 2 hours")
+)
+
+type (
+	// Phase is a timed step of a protocol.
+	Phase struct {
+		Name            string `json:"name"`
+		Kind            string `json:"kind"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+
+	// Protocol is a named sequence of phases that is repeated for a number of rounds,
+	// e.g. contrast therapy is 3 rounds of 1 minute cold and 3 minutes rest.
+	Protocol struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Rounds      int     `json:"rounds"`
+		Phases      []Phase `json:"phases"`
+	}
+
+	// Position is where a plunge is in its phases.
+	Position struct {
+		// Index of the current phase in the expanded phases, it is the number of phases when the protocol is done.
+		Index     int
+		Phase     Phase
+		StartedAt time.Time
+		EndsAt    time.Time
+		Done      bool
+	}
+)
@@ -0,0 +1,206 @@
+package sensor
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// Device is a single configured device that was created by a registered driver.
+	Device interface {
+		Config() DeviceConfig
+	}
+
+	// TemperatureDevice is a device that can read a temperature in Celsius.
+	// The calibration offset is applied by the caller.
+	TemperatureDevice interface {
+		Device
+		ReadTemperature() (float64, error)
+	}
+
+	// InputDevice is a device with a digital input, e.g. a leak sensor.
+	InputDevice interface {
+		Device
+		IsActive() (bool, error)
+	}
+
+	// SwitchDevice is a device that can be powered on and off, e.g. a relay.
+	SwitchDevice interface {
+		Device
+		IsOn() (bool, error)
+		TurnOn() error
+		TurnOff() error
+	}
+
+	// DriverFactory creates a Device for the configuration passed in.
+	DriverFactory func(config DeviceConfig) (Device, error)
+
+	// DeviceRegistry holds the devices created from the configuration and allows them to be looked up by ID or role.
+	DeviceRegistry struct {
+		devices []Device
+		byID    map[string]Device
+		byRole  map[string][]Device
+	}
+)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]DriverFactory)
+)
+
+// RegisterDriver makes a driver available for the driver type used in the device configuration.
+// If RegisterDriver is called twice with the same driver type it panics.
+func RegisterDriver(driverType string, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if factory == nil {
+		panic("sensor: RegisterDriver factory is nil")
+	}
+
+	if _, dup := drivers[driverType]; dup {
+		panic("sensor: RegisterDriver called twice for driver " + driverType)
+	}
+
+	drivers[driverType] = factory
+}
+
+// Drivers returns a sorted list of the registered driver types.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	list := make([]string, 0, len(drivers))
+	for name := range drivers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+func lookupDriver(driverType string) (DriverFactory, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	factory, ok := drivers[driverType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driverType)
+	}
+
+	return factory, nil
+}
+
+// NewDeviceRegistry creates a device for each configuration using the registered driver for its driver type.
+func NewDeviceRegistry(configs []DeviceConfig) (*DeviceRegistry, error) {
+	r := &DeviceRegistry{
+		devices: make([]Device, 0, len(configs)),
+		byID:    make(map[string]Device),
+		byRole:  make(map[string][]Device),
+	}
+
+	for _, c := range configs {
+		c = normalizeDeviceConfig(c)
+
+		factory, err := lookupDriver(c.DriverType)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", c.ID, err)
+		}
+
+		if _, dup := r.byID[c.ID]; dup {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateDeviceID, c.ID)
+		}
+
+		d, err := factory(c)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", c.ID, err)
+		}
+
+		slog.Debug("registered device", "id", c.ID, "role", c.Role, "driver", c.DriverType)
+
+		r.devices = append(r.devices, d)
+		r.byID[c.ID] = d
+		if len(c.Role) != 0 {
+			r.byRole[c.Role] = append(r.byRole[c.Role], d)
+		}
+	}
+
+	return r, nil
+}
+
+// normalizeDeviceConfig fills in the ID and role for configurations that do not specify them.
+// Configurations written before roles existed addressed devices by name, so the name is used for both.
+// A leak sensor without a zone watches a zone named after the sensor.
+func normalizeDeviceConfig(c DeviceConfig) DeviceConfig {
+	if len(c.ID) == 0 {
+		c.ID = c.Name
+	}
+
+	if len(c.Role) == 0 {
+		c.Role = strings.ToLower(c.Name)
+	}
+
+	if c.SensorType == SENSOR_LEAK && len(c.Zone) == 0 {
+		c.Zone = c.ID
+	}
+
+	return c
+}
+
+// Lookup will find a device by its ID, falling back to the first device with a matching role.
+func (r *DeviceRegistry) Lookup(idOrRole string) (Device, error) {
+	if d, ok := r.byID[idOrRole]; ok {
+		return d, nil
+	}
+
+	if devices := r.byRole[idOrRole]; len(devices) != 0 {
+		return devices[0], nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, idOrRole)
+}
+
+// BySensorType returns all devices of the sensor type in configuration order.
+func (r *DeviceRegistry) BySensorType(sensorType string) []Device {
+	devices := make([]Device, 0)
+	for _, d := range r.devices {
+		if d.Config().SensorType == sensorType {
+			devices = append(devices, d)
+		}
+	}
+
+	return devices
+}
+
+// Switch will find a device by ID or role that can be powered on and off.
+func (r *DeviceRegistry) Switch(idOrRole string) (SwitchDevice, error) {
+	d, err := r.Lookup(idOrRole)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := d.(SwitchDevice)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a switch", ErrUnsupportedDevice, idOrRole)
+	}
+
+	return s, nil
+}
+
+// Input will find a device by ID or role that has a digital input.
+func (r *DeviceRegistry) Input(idOrRole string) (InputDevice, error) {
+	d, err := r.Lookup(idOrRole)
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := d.(InputDevice)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an input", ErrUnsupportedDevice, idOrRole)
+	}
+
+	return i, nil
+}
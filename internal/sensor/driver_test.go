@@ -0,0 +1,110 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeviceRegistry(t *testing.T) {
+	configs := []DeviceConfig{
+		{ID: "water-probe", Role: ROLE_WATER, DriverType: DRIVERTYPE_MOCK, SensorType: SENSOR_TEMPERATURE, Name: "Water"},
+		{DriverType: DRIVERTYPE_MOCK, SensorType: SENSOR_POWER, Name: "Pump", NormallyOn: true},
+	}
+
+	t.Run("should find a device by id or role", func(t *testing.T) {
+		r, err := NewDeviceRegistry(configs)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+
+		if _, err := r.Lookup("water-probe"); err != nil {
+			t.Errorf("expected to find device by id: %v", err)
+		}
+
+		d, err := r.Lookup(ROLE_WATER)
+		if err != nil {
+			t.Fatalf("expected to find device by role: %v", err)
+		}
+
+		if d.Config().ID != "water-probe" {
+			t.Errorf("expected device %s, got %s", "water-probe", d.Config().ID)
+		}
+	})
+
+	t.Run("should default the id and role from the name", func(t *testing.T) {
+		r, err := NewDeviceRegistry(configs)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+
+		s, err := r.Switch(ROLE_PUMP)
+		if err != nil {
+			t.Fatalf("expected to find the pump: %v", err)
+		}
+
+		on, _ := s.IsOn()
+		if !on {
+			t.Errorf("expected a normally on device to start on")
+		}
+	})
+
+	t.Run("should fail for an unknown driver", func(t *testing.T) {
+		_, err := NewDeviceRegistry([]DeviceConfig{{DriverType: "unknown", Name: "Probe"}})
+		if !errors.Is(err, ErrUnknownDriver) {
+			t.Errorf("expected error %v, got %v", ErrUnknownDriver, err)
+		}
+	})
+
+	t.Run("should fail for duplicate device ids", func(t *testing.T) {
+		_, err := NewDeviceRegistry([]DeviceConfig{
+			{DriverType: DRIVERTYPE_MOCK, Name: "Probe"},
+			{DriverType: DRIVERTYPE_MOCK, Name: "Probe"},
+		})
+		if !errors.Is(err, ErrDuplicateDeviceID) {
+			t.Errorf("expected error %v, got %v", ErrDuplicateDeviceID, err)
+		}
+	})
+
+	t.Run("should fail to find a missing device", func(t *testing.T) {
+		r, err := NewDeviceRegistry(configs)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+
+		if _, err := r.Lookup(ROLE_OZONE); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected error %v, got %v", ErrDeviceNotFound, err)
+		}
+	})
+}
+
+func TestReadLeakSensors(t *testing.T) {
+	r, err := NewDeviceRegistry([]DeviceConfig{
+		{ID: "tub-leak", Role: ROLE_LEAK, DriverType: DRIVERTYPE_MOCK, SensorType: SENSOR_LEAK, Zone: "under tub"},
+		{ID: "drip-tray", Role: ROLE_LEAK, DriverType: DRIVERTYPE_MOCK, SensorType: SENSOR_LEAK},
+	})
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	s := &DeviceSensors{config: SensorConfig{SensorTimeout: time.Second}, devices: r, health: newDeviceHealthTracker()}
+
+	DefaultSimulator.Update(SimulatorUpdate{Leaks: map[string]bool{"drip-tray": true}})
+	defer DefaultSimulator.Update(SimulatorUpdate{Leaks: map[string]bool{"drip-tray": false}})
+
+	t.Run("should read every leak sensor with its zone", func(t *testing.T) {
+		readings := s.ReadLeakSensors(context.Background())
+		if len(readings) != 2 {
+			t.Fatalf("expected 2 readings, got %d", len(readings))
+		}
+
+		if readings[0].ID != "tub-leak" || readings[0].Zone != "under tub" || readings[0].LeakPresent {
+			t.Errorf("unexpected reading for the tub sensor %+v", readings[0])
+		}
+
+		if readings[1].ID != "drip-tray" || readings[1].Zone != "drip-tray" || !readings[1].LeakPresent {
+			t.Errorf("expected the drip tray to be wet in its own zone, got %+v", readings[1])
+		}
+	})
+}
@@ -3,144 +3,92 @@ package sensor
 import (
 	"log/slog"
 	"strconv"
+	"sync"
 
 	"github.com/stianeikeland/go-rpio/v4"
 	"github.com/yryz/ds18b20"
 )
 
-func (s *HardwareSensors) readTemperatureSensor(device *DeviceConfig) TemperatureReading {
-	tr := TemperatureReading{
-		Name:        device.Name,
-		Description: device.Description,
-		Address:     device.Address,
+type (
+	// ds18b20Device is a 1-Wire temperature probe addressed by its serial number.
+	ds18b20Device struct {
+		config DeviceConfig
 	}
 
-	t, err := ds18b20.Temperature(device.Address)
-	if err != nil {
-		slog.Error("failed to read sensor", "name", device.Name, "address", device.Address, "error", err)
-		tr.Err = err
-	} else {
-
-		t += device.CalibrationOffsetCelsius
-		tr.TemperatureC = t
-		tr.TemperatureF = (t * 9 / 5) + 32
-		tr.Err = nil
+	// gpioDevice is a pin on the Raspberry Pi used either as a digital input or to drive a relay.
+	gpioDevice struct {
+		config DeviceConfig
+		pin    rpio.Pin
 	}
+)
 
-	return tr
-}
-
-func (s *HardwareSensors) ReadTemperatures() []TemperatureReading {
-	slog.Debug(">>ReadRoomAndWaterTemperature")
-	defer slog.Debug("<<ReadRoomAndWaterTemperature")
-
-	readings := make([]TemperatureReading, 0, len(s.config.TemperatureSensors))
+var (
+	// gpioLock serializes access to the pins. rpio maps the GPIO registers into a package global when it is
+	// opened and unmaps them when it is closed, so a device closing it while another reads a pin would fault.
+	gpioLock sync.Mutex
 
-	for _, device := range s.config.TemperatureSensors {
-		tr := s.readTemperatureSensor(&device)
-		readings = append(readings, tr)
-	}
+	// the rpio calls are variables so the tests can run without the GPIO registers
+	gpioOpen  = rpio.Open
+	gpioClose = rpio.Close
+	gpioRead  = rpio.ReadPin
+)
 
-	return readings
+func init() {
+	RegisterDriver(DRIVERTYPE_DS18B20, newDS18B20Device)
+	RegisterDriver(DRIVERTYPE_GPIO, newGPIODevice)
 }
 
-func (s *HardwareSensors) ReadRoomAndWaterTemperature() (TemperatureReading, TemperatureReading) {
-	temperatures := s.ReadTemperatures()
-
-	var waterTemp TemperatureReading
-	var roomTemp TemperatureReading
-
-	for _, temp := range temperatures {
-		switch temp.Name {
-		case "Room":
-			roomTemp = temp
-		case "Water":
-			waterTemp = temp
-		}
-	}
-
-	return roomTemp, waterTemp
+func newDS18B20Device(config DeviceConfig) (Device, error) {
+	return &ds18b20Device{config: config}, nil
 }
 
-func (s *HardwareSensors) IsLeakPresent() (bool, error) {
-	slog.Debug(">>IsLeakPresent")
-	defer slog.Debug("<<IsLeakPresent")
-
-	if err := rpio.Open(); err != nil {
-		return false, err
-	}
-
-	defer rpio.Close()
-
-	pinNumber, err := strconv.Atoi(s.config.LeakSensor.Address)
-	if err != nil {
-		return false, err
-	}
-
-	pin := rpio.Pin(pinNumber)
-	res := pin.Read()
-	if res == 1 {
-		return true, nil
-	}
-
-	return false, nil
+func (d *ds18b20Device) Config() DeviceConfig {
+	return d.config
 }
 
-func (s *HardwareSensors) TurnOzoneOn() error {
-	slog.Debug(">>TurnOzoneOn")
-	defer slog.Debug("<<TurnOzoneOn")
-
-	return turnDeviceOn(&s.config.OzoneDevice)
+func (d *ds18b20Device) ReadTemperature() (float64, error) {
+	return ds18b20.Temperature(d.config.Address)
 }
 
-func (s *HardwareSensors) TurnOzoneOff() error {
-	slog.Debug(">>TurnOzoneOff")
-	defer slog.Debug("<<TurnOzoneOff")
+func newGPIODevice(config DeviceConfig) (Device, error) {
+	pinNumber, err := strconv.Atoi(config.Address)
+	if err != nil {
+		return nil, err
+	}
 
-	return turnDeviceOff(&s.config.OzoneDevice)
+	return &gpioDevice{config: config, pin: rpio.Pin(pinNumber)}, nil
 }
 
-func (s *HardwareSensors) IsPumpOn() (bool, error) {
-	slog.Debug(">>IsPumpOn")
-	defer slog.Debug("<<IsPumpOn")
-
-	return isDeviceOn(&s.config.PumpDevice)
+func (d *gpioDevice) Config() DeviceConfig {
+	return d.config
 }
 
-func (s *HardwareSensors) TurnPumpOn() error {
-	slog.Debug(">>TurnPumpOn")
-	defer slog.Debug("<<TurnPumpOn")
-
-	return turnDeviceOn(&s.config.PumpDevice)
-}
+func (d *gpioDevice) IsActive() (bool, error) {
+	slog.Debug(">>IsActive", "name", d.config.Name, "address", d.config.Address)
+	defer slog.Debug("<<IsActive")
 
-func (s *HardwareSensors) TurnPumpOff() error {
-	slog.Debug(">>TurnPumpOff")
-	defer slog.Debug("<<TurnPumpOff")
+	var res rpio.State
+	err := withGPIO(func() {
+		res = gpioRead(d.pin)
+	})
 
-	return turnDeviceOff(&s.config.PumpDevice)
+	return res == 1, err
 }
 
-func isDeviceOn(device *DeviceConfig) (bool, error) {
-	slog.Debug(">>isDeviceOn", "name", device.Name, "address", device.Address)
-	defer slog.Debug("<<isDeviceOn")
-
-	if err := rpio.Open(); err != nil {
-		return false, err
-	}
+func (d *gpioDevice) IsOn() (bool, error) {
+	slog.Debug(">>IsOn", "name", d.config.Name, "address", d.config.Address)
+	defer slog.Debug("<<IsOn")
 
-	defer rpio.Close()
-
-	pinNumber, err := strconv.Atoi(device.Address)
+	var res rpio.State
+	err := withGPIO(func() {
+		res = gpioRead(d.pin)
+	})
 	if err != nil {
 		return false, err
 	}
 
-	pin := rpio.Pin(pinNumber)
-	res := pin.Read()
-
 	var pinOnValue rpio.State = 1
-	if device.NormallyOn {
+	if d.config.NormallyOn {
 		pinOnValue = 0
 	}
 
@@ -151,58 +99,50 @@ func isDeviceOn(device *DeviceConfig) (bool, error) {
 	return false, nil
 }
 
-func turnDeviceOn(device *DeviceConfig) error {
-	slog.Debug(">>turnDeviceOn", "name", device.Name)
-	defer slog.Debug("<<turnDeviceOn", "name", device.Name)
-
-	if err := rpio.Open(); err != nil {
-		return err
-	}
+func (d *gpioDevice) TurnOn() error {
+	slog.Debug(">>TurnOn", "name", d.config.Name)
+	defer slog.Debug("<<TurnOn", "name", d.config.Name)
 
-	defer rpio.Close()
+	return withGPIO(func() {
+		d.pin.Output()
 
-	pinNumber, err := strconv.Atoi(device.Address)
-	if err != nil {
-		return err
-	}
+		// if the device is normally on, that means the pin is low when it is on
+		if d.config.NormallyOn {
+			d.pin.Low()
+		} else {
+			d.pin.High()
+		}
+	})
+}
 
-	pin := rpio.Pin(pinNumber)
-	pin.Output()
+func (d *gpioDevice) TurnOff() error {
+	slog.Debug(">>TurnOff", "name", d.config.Name)
+	defer slog.Debug("<<TurnOff", "name", d.config.Name)
 
-	// if the device is normally on, that means the pin is low when it is on
-	if device.NormallyOn {
-		pin.Low()
-	} else {
-		pin.High()
-	}
+	return withGPIO(func() {
+		d.pin.Output()
 
-	return nil
+		// if the device is normally on, that means the pin is high when it is off
+		if d.config.NormallyOn {
+			d.pin.High()
+		} else {
+			d.pin.Low()
+		}
+	})
 }
 
-func turnDeviceOff(device *DeviceConfig) error {
-	slog.Debug(">>turnDeviceOff", "name", device.Name)
-	defer slog.Debug("<<turnDeviceOff", "name", device.Name)
+// withGPIO opens the GPIO registers for fn and closes them again, holding gpioLock the whole time.
+func withGPIO(fn func()) error {
+	gpioLock.Lock()
+	defer gpioLock.Unlock()
 
-	if err := rpio.Open(); err != nil {
+	if err := gpioOpen(); err != nil {
 		return err
 	}
 
-	defer rpio.Close()
+	defer gpioClose()
 
-	pinNumber, err := strconv.Atoi(device.Address)
-	if err != nil {
-		return err
-	}
-
-	pin := rpio.Pin(pinNumber)
-	pin.Output()
-
-	// if the device is normally on, that means the pin is high when it is off
-	if device.NormallyOn {
-		pin.High()
-	} else {
-		pin.Low()
-	}
+	fn()
 
 	return nil
 }
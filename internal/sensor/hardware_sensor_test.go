@@ -0,0 +1,60 @@
+package sensor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stianeikeland/go-rpio/v4"
+)
+
+func TestGPIOLeakSensors(t *testing.T) {
+	// count how many devices have the GPIO registers open at once instead of mapping them
+	var open, overlapped atomic.Int32
+	gpioOpen = func() error {
+		if open.Add(1) > 1 {
+			overlapped.Store(1)
+		}
+		return nil
+	}
+	gpioClose = func() error {
+		open.Add(-1)
+		return nil
+	}
+	gpioRead = func(pin rpio.Pin) rpio.State {
+		time.Sleep(10 * time.Millisecond)
+		if pin == 17 {
+			return rpio.High
+		}
+		return rpio.Low
+	}
+	defer func() {
+		gpioOpen, gpioClose, gpioRead = rpio.Open, rpio.Close, rpio.ReadPin
+	}()
+
+	r, err := NewDeviceRegistry([]DeviceConfig{
+		{ID: "tub-leak", Role: ROLE_LEAK, DriverType: DRIVERTYPE_GPIO, SensorType: SENSOR_LEAK, Address: "17"},
+		{ID: "drip-tray", Role: ROLE_LEAK, DriverType: DRIVERTYPE_GPIO, SensorType: SENSOR_LEAK, Address: "27"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	s := &DeviceSensors{config: SensorConfig{SensorTimeout: time.Second}, devices: r, health: newDeviceHealthTracker()}
+
+	t.Run("should read one GPIO leak sensor at a time", func(t *testing.T) {
+		readings := s.ReadLeakSensors(context.Background())
+		if len(readings) != 2 {
+			t.Fatalf("expected 2 readings, got %d", len(readings))
+		}
+
+		if !readings[0].LeakPresent || readings[1].LeakPresent || readings[0].Err != nil || readings[1].Err != nil {
+			t.Errorf("unexpected readings %+v", readings)
+		}
+
+		if overlapped.Load() != 0 {
+			t.Error("expected the GPIO registers to be opened by one sensor at a time")
+		}
+	})
+}
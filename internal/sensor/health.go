@@ -0,0 +1,140 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is matched by every TimeoutError using errors.Is.
+var ErrTimeout = errors.New("sensor timeout")
+
+type (
+	// TimeoutError is returned when a device does not respond before the sensor timeout.
+	TimeoutError struct {
+		DeviceID  string
+		Operation string
+		Timeout   time.Duration
+	}
+
+	// DeviceHealth tracks the failures for a single device.
+	DeviceHealth struct {
+		ID                  string    `json:"id"`
+		Name                string    `json:"name"`
+		Role                string    `json:"role"`
+		ConsecutiveFailures int64     `json:"consecutive_failures"`
+		TotalFailures       int64     `json:"total_failures"`
+		Timeouts            int64     `json:"timeouts"`
+		LastError           string    `json:"last_error,omitempty"`
+		LastErrorAt         time.Time `json:"last_error_at,omitempty"`
+		LastSuccessAt       time.Time `json:"last_success_at,omitempty"`
+	}
+
+	deviceHealthTracker struct {
+		mu      sync.Mutex
+		devices map[string]*DeviceHealth
+	}
+)
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("device %s timed out after %v during %s", e.DeviceID, e.Timeout, e.Operation)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// IsTimeout reports if the error was caused by a device not responding in time.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
+func newDeviceHealthTracker() *deviceHealthTracker {
+	return &deviceHealthTracker{
+		devices: make(map[string]*DeviceHealth),
+	}
+}
+
+func (t *deviceHealthTracker) record(config DeviceConfig, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.devices[config.ID]
+	if !ok {
+		h = &DeviceHealth{ID: config.ID, Name: config.Name, Role: config.Role}
+		t.devices[config.ID] = h
+	}
+
+	now := time.Now().UTC()
+	if err == nil {
+		h.ConsecutiveFailures = 0
+		h.LastSuccessAt = now
+		return
+	}
+
+	h.ConsecutiveFailures++
+	h.TotalFailures++
+	h.LastError = err.Error()
+	h.LastErrorAt = now
+	if IsTimeout(err) {
+		h.Timeouts++
+	}
+}
+
+func (t *deviceHealthTracker) snapshot() []DeviceHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := make([]DeviceHealth, 0, len(t.devices))
+	for _, h := range t.devices {
+		health = append(health, *h)
+	}
+
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].ID < health[j].ID
+	})
+
+	return health
+}
+
+// callDevice runs the device operation and waits until it completes, the sensor timeout expires or the context is canceled.
+// A driver call that never returns can't be interrupted, so its goroutine is abandoned once the deadline passes.
+func callDevice[T any](ctx context.Context, s *DeviceSensors, config DeviceConfig, operation string, fn func() (T, error)) (T, error) {
+	if s.config.SensorTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.SensorTimeout)
+		defer cancel()
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	select {
+	case r := <-done:
+		s.health.record(config, r.err)
+		return r.value, r.err
+
+	case <-ctx.Done():
+		var zero T
+		err := ctx.Err()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			// the caller gave up, that says nothing about the device
+			return zero, err
+		}
+
+		err = &TimeoutError{DeviceID: config.ID, Operation: operation, Timeout: s.config.SensorTimeout}
+		s.health.record(config, err)
+		return zero, err
+	}
+}
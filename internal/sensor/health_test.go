@@ -0,0 +1,65 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSensorTimeout(t *testing.T) {
+	t.Run("should time out a hung read and count the failure", func(t *testing.T) {
+		sim, _, r := newTestSimulator(t)
+		s := &DeviceSensors{
+			config:  SensorConfig{SensorTimeout: 20 * time.Millisecond},
+			devices: r,
+			health:  newDeviceHealthTracker(),
+		}
+
+		sim.Update(SimulatorUpdate{Faults: map[string]SimulatorFault{"leak": {DelaySeconds: 0.2}}})
+
+		readings := s.ReadLeakSensors(context.Background())
+		if len(readings) != 1 {
+			t.Fatalf("expected one leak reading, got %d", len(readings))
+		}
+
+		err := readings[0].Err
+		if !IsTimeout(err) {
+			t.Fatalf("expected a timeout error, got %v", err)
+		}
+
+		var te *TimeoutError
+		if !errors.As(err, &te) || te.DeviceID != "leak" {
+			t.Errorf("expected the timeout to identify device %s, got %v", "leak", err)
+		}
+
+		health := s.DeviceHealth()
+		if len(health) != 1 || health[0].Timeouts != 1 || health[0].ConsecutiveFailures != 1 {
+			t.Errorf("expected one timeout to be recorded, got %+v", health)
+		}
+	})
+
+	t.Run("should reset consecutive failures after a successful read", func(t *testing.T) {
+		sim, _, r := newTestSimulator(t)
+		s := &DeviceSensors{
+			config:  SensorConfig{SensorTimeout: time.Second},
+			devices: r,
+			health:  newDeviceHealthTracker(),
+		}
+
+		sim.Update(SimulatorUpdate{Faults: map[string]SimulatorFault{"pump": {Error: "relay fault"}}})
+		if _, err := s.IsPumpOn(context.Background()); err == nil {
+			t.Fatalf("expected the injected error")
+		}
+
+		sim.Update(SimulatorUpdate{ClearFaults: true})
+		if _, err := s.IsPumpOn(context.Background()); err != nil {
+			t.Fatalf("expected the read to succeed, got %v", err)
+		}
+
+		health := s.DeviceHealth()
+		if health[0].ConsecutiveFailures != 0 || health[0].TotalFailures != 1 {
+			t.Errorf("expected 0 consecutive and 1 total failures, got %+v", health[0])
+		}
+	})
+}
@@ -4,103 +4,89 @@ import (
 	"log/slog"
 )
 
-func (m *MockSensors) readTemperatureSensor(device *DeviceConfig) TemperatureReading {
-	tr := TemperatureReading{
-		Name:        device.Name,
-		Description: device.Description,
-		Address:     device.Address,
-	}
+// simulatedDevice is created by the mock driver and reads its values from the simulator.
+type simulatedDevice struct {
+	sim    *Simulator
+	config DeviceConfig
+}
 
-	// TODO: read from config?
-	t := 10.0
+// DefaultSimulator backs every device created by the mock driver.
+var DefaultSimulator = NewSimulator()
 
-	t += device.CalibrationOffsetCelsius
-	tr.TemperatureC = t
-	tr.TemperatureF = (t * 9 / 5) + 32
-	tr.Err = nil
+func init() {
+	RegisterDriver(DRIVERTYPE_MOCK, func(config DeviceConfig) (Device, error) {
+		return DefaultSimulator.NewDevice(config)
+	})
+}
 
-	return tr
+func (d *simulatedDevice) Config() DeviceConfig {
+	return d.config
 }
 
-func (m *MockSensors) ReadTemperatures() []TemperatureReading {
-	slog.Debug(">>ReadTemperatures")
-	defer slog.Debug("<<ReadTemperatures")
+func (d *simulatedDevice) ReadTemperature() (float64, error) {
+	if err := d.sim.fault(d.config.ID); err != nil {
+		return 0, err
+	}
+
+	d.sim.mu.Lock()
+	defer d.sim.mu.Unlock()
 
-	readings := make([]TemperatureReading, 0, len(m.config.TemperatureSensors))
+	d.sim.advance(d.sim.now())
 
-	for _, device := range m.config.TemperatureSensors {
-		tr := m.readTemperatureSensor(&device)
-		readings = append(readings, tr)
+	t := d.sim.state.RoomTemperatureC
+	if d.config.Role == ROLE_WATER {
+		t = d.sim.state.WaterTemperatureC
 	}
 
-	return readings
+	// the calibration offset is added to the raw reading, so remove it to report the modeled temperature
+	return t - d.config.CalibrationOffsetCelsius, nil
 }
 
-func (m *MockSensors) ReadRoomAndWaterTemperature() (TemperatureReading, TemperatureReading) {
-	slog.Debug(">>ReadRoomAndWaterTemperature")
-	defer slog.Debug("<<ReadRoomAndWaterTemperature")
-	temperatures := m.ReadTemperatures()
-
-	var waterTemp TemperatureReading
-	var roomTemp TemperatureReading
-
-	for _, temp := range temperatures {
-		switch temp.Name {
-		case "Room":
-			roomTemp = temp
-		case "Water":
-			waterTemp = temp
-		}
+func (d *simulatedDevice) IsActive() (bool, error) {
+	if err := d.sim.fault(d.config.ID); err != nil {
+		return false, err
 	}
 
-	return roomTemp, waterTemp
-}
-
-func (m *MockSensors) IsLeakPresent() (bool, error) {
-	slog.Debug(">>IsLeakPresent")
-	defer slog.Debug("<<IsLeakPresent")
+	d.sim.mu.Lock()
+	defer d.sim.mu.Unlock()
 
-	// TODO: read from config
+	d.sim.advance(d.sim.now())
 
-	return false, nil
+	return d.sim.state.LeakPresent || d.sim.state.Leaks[d.config.ID], nil
 }
 
-func (m *MockSensors) TurnOzoneOn() error {
-	slog.Debug(">>TurnOzoneOn")
-	defer slog.Debug("<<TurnOzoneOn")
-
-	// TODO: read from config
-	return nil
-}
+func (d *simulatedDevice) IsOn() (bool, error) {
+	if err := d.sim.fault(d.config.ID); err != nil {
+		return false, err
+	}
 
-func (m *MockSensors) TurnOzoneOff() error {
-	slog.Debug(">>TurnOzoneOff")
-	defer slog.Debug("<<TurnOzoneOff")
+	d.sim.mu.Lock()
+	defer d.sim.mu.Unlock()
 
-	// TODO: read from config
-	return nil
+	return d.sim.state.Switches[d.config.ID], nil
 }
 
-func (m *MockSensors) IsPumpOn() (bool, error) {
-	slog.Debug(">>IsPumpOn")
-	defer slog.Debug("<<IsPumpOn")
+func (d *simulatedDevice) TurnOn() error {
+	return d.setSwitch(true)
+}
 
-	// TODO: read from config
-	return true, nil
+func (d *simulatedDevice) TurnOff() error {
+	return d.setSwitch(false)
 }
 
-func (m *MockSensors) TurnPumpOn() error {
-	slog.Debug(">>TurnPumpOn")
-	defer slog.Debug("<<TurnPumpOn")
+func (d *simulatedDevice) setSwitch(on bool) error {
+	slog.Debug("simulated switch", "name", d.config.Name, "on", on)
 
-	// TODO: read from config
-	return nil
-}
+	if err := d.sim.fault(d.config.ID); err != nil {
+		return err
+	}
+
+	d.sim.mu.Lock()
+	defer d.sim.mu.Unlock()
 
-func (m *MockSensors) TurnPumpOff() error {
-	slog.Debug(">>TurnPumpOff")
-	defer slog.Debug("<<TurnPumpOff")
+	// settle the model with the old switch state before changing it
+	d.sim.advance(d.sim.now())
+	d.sim.state.Switches[d.config.ID] = on
 
-	// TODO: read from config
 	return nil
 }
@@ -1,7 +1,9 @@
 package sensor
 
 import (
+	"context"
 	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -12,29 +14,211 @@ func NewSensorConfig(sensorTimeout int, devices []DeviceConfig, useMockSensor bo
 		Devices:       devices,
 	}
 
-	sc.TemperatureSensors = make(map[string]DeviceConfig)
-	for _, d := range sc.Devices {
-		switch d.SensorType {
-		case SENSOR_TEMPERATURE:
-			if d.DriverType == DRIVERTYPE_DS18B20 {
-				sc.TemperatureSensors[d.Address] = d
-			}
-		case SENSOR_LEAK:
-			sc.LeakSensor = d
-
-		case SENSOR_POWER:
-			switch d.Name {
-			case "Pump":
-				sc.PumpDevice = d
-			case "Ozone":
-				sc.OzoneDevice = d
-			}
+	configs := sc.Devices
+	if useMockSensor {
+		// swap every device over to the mock driver but keep the rest of the configuration
+		configs = make([]DeviceConfig, 0, len(sc.Devices))
+		for _, d := range sc.Devices {
+			d.DriverType = DRIVERTYPE_MOCK
+			configs = append(configs, d)
 		}
 	}
 
-	if useMockSensor {
-		return &MockSensors{config: sc}, nil
+	registry, err := NewDeviceRegistry(configs)
+	if err != nil {
+		slog.Error("failed to create the configured devices", "error", err)
+		return nil, err
+	}
+
+	return &DeviceSensors{config: sc, devices: registry, health: newDeviceHealthTracker()}, nil
+}
+
+func (s *DeviceSensors) readTemperatureSensor(ctx context.Context, device TemperatureDevice) TemperatureReading {
+	config := device.Config()
+	tr := TemperatureReading{
+		Name:        config.Name,
+		Description: config.Description,
+		Address:     config.Address,
+		Role:        config.Role,
+	}
+
+	t, err := callDevice(ctx, s, config, "read temperature", device.ReadTemperature)
+	if err != nil {
+		slog.Error("failed to read sensor", "name", config.Name, "address", config.Address, "error", err)
+		tr.Err = err
+	} else {
+		t += config.CalibrationOffsetCelsius
+		tr.TemperatureC = t
+		tr.TemperatureF = (t * 9 / 5) + 32
+		tr.Err = nil
 	}
 
-	return &HardwareSensors{config: sc}, nil
+	return tr
+}
+
+func (s *DeviceSensors) ReadTemperatures(ctx context.Context) []TemperatureReading {
+	slog.Debug(">>ReadTemperatures")
+	defer slog.Debug("<<ReadTemperatures")
+
+	devices := make([]TemperatureDevice, 0)
+	for _, d := range s.devices.BySensorType(SENSOR_TEMPERATURE) {
+		if td, ok := d.(TemperatureDevice); ok {
+			devices = append(devices, td)
+		}
+	}
+
+	readings := make([]TemperatureReading, len(devices))
+
+	// read the probes concurrently so one slow device doesn't delay the others past the timeout
+	var wg sync.WaitGroup
+	for i, td := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readings[i] = s.readTemperatureSensor(ctx, td)
+		}()
+	}
+
+	wg.Wait()
+
+	return readings
+}
+
+// FindReading returns the first reading from a device with the role.
+func FindReading(readings []TemperatureReading, role string) (TemperatureReading, bool) {
+	for _, r := range readings {
+		if r.Role == role {
+			return r, true
+		}
+	}
+
+	return TemperatureReading{}, false
+}
+
+func (s *DeviceSensors) readLeakSensor(ctx context.Context, device InputDevice) LeakReading {
+	config := device.Config()
+	lr := LeakReading{
+		ID:          config.ID,
+		Name:        config.Name,
+		Description: config.Description,
+		Zone:        config.Zone,
+	}
+
+	lr.LeakPresent, lr.Err = callDevice(ctx, s, config, "read input", device.IsActive)
+	if lr.Err != nil {
+		slog.Error("failed to read leak sensor", "id", config.ID, "zone", config.Zone, "error", lr.Err)
+	}
+
+	return lr
+}
+
+// ReadLeakSensors reads every leak sensor concurrently and returns the readings in configuration order.
+func (s *DeviceSensors) ReadLeakSensors(ctx context.Context) []LeakReading {
+	slog.Debug(">>ReadLeakSensors")
+	defer slog.Debug("<<ReadLeakSensors")
+
+	devices := make([]InputDevice, 0)
+	for _, d := range s.devices.BySensorType(SENSOR_LEAK) {
+		if in, ok := d.(InputDevice); ok {
+			devices = append(devices, in)
+		}
+	}
+
+	readings := make([]LeakReading, len(devices))
+
+	var wg sync.WaitGroup
+	for i, in := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readings[i] = s.readLeakSensor(ctx, in)
+		}()
+	}
+
+	wg.Wait()
+
+	return readings
+}
+
+func (s *DeviceSensors) TurnOzoneOn(ctx context.Context) error {
+	slog.Debug(">>TurnOzoneOn")
+	defer slog.Debug("<<TurnOzoneOn")
+
+	return s.TurnDeviceOn(ctx, ROLE_OZONE)
+}
+
+func (s *DeviceSensors) TurnOzoneOff(ctx context.Context) error {
+	slog.Debug(">>TurnOzoneOff")
+	defer slog.Debug("<<TurnOzoneOff")
+
+	return s.TurnDeviceOff(ctx, ROLE_OZONE)
+}
+
+func (s *DeviceSensors) IsPumpOn(ctx context.Context) (bool, error) {
+	slog.Debug(">>IsPumpOn")
+	defer slog.Debug("<<IsPumpOn")
+
+	return s.IsDeviceOn(ctx, ROLE_PUMP)
+}
+
+func (s *DeviceSensors) TurnPumpOn(ctx context.Context) error {
+	slog.Debug(">>TurnPumpOn")
+	defer slog.Debug("<<TurnPumpOn")
+
+	return s.TurnDeviceOn(ctx, ROLE_PUMP)
+}
+
+func (s *DeviceSensors) TurnPumpOff(ctx context.Context) error {
+	slog.Debug(">>TurnPumpOff")
+	defer slog.Debug("<<TurnPumpOff")
+
+	return s.TurnDeviceOff(ctx, ROLE_PUMP)
+}
+
+func (s *DeviceSensors) IsDeviceOn(ctx context.Context, id string) (bool, error) {
+	slog.Debug(">>IsDeviceOn", "id", id)
+	defer slog.Debug("<<IsDeviceOn", "id", id)
+
+	device, err := s.devices.Switch(id)
+	if err != nil {
+		return false, err
+	}
+
+	return callDevice(ctx, s, device.Config(), "read switch", device.IsOn)
+}
+
+func (s *DeviceSensors) TurnDeviceOn(ctx context.Context, id string) error {
+	slog.Debug(">>TurnDeviceOn", "id", id)
+	defer slog.Debug("<<TurnDeviceOn", "id", id)
+
+	device, err := s.devices.Switch(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = callDevice(ctx, s, device.Config(), "turn on", func() (struct{}, error) {
+		return struct{}{}, device.TurnOn()
+	})
+
+	return err
+}
+
+func (s *DeviceSensors) TurnDeviceOff(ctx context.Context, id string) error {
+	slog.Debug(">>TurnDeviceOff", "id", id)
+	defer slog.Debug("<<TurnDeviceOff", "id", id)
+
+	device, err := s.devices.Switch(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = callDevice(ctx, s, device.Config(), "turn off", func() (struct{}, error) {
+		return struct{}{}, device.TurnOff()
+	})
+
+	return err
+}
+
+func (s *DeviceSensors) DeviceHealth() []DeviceHealth {
+	return s.health.snapshot()
 }
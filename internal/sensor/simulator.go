@@ -0,0 +1,341 @@
+package sensor
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSimulatorWaterTemperatureC = 15.0
+	DefaultSimulatorRoomTemperatureC  = 20.0
+	DefaultSimulatorChillerSetpointC  = 3.0
+	DefaultSimulatorCoolingRate       = 0.02
+	DefaultSimulatorWarmingRate       = 0.005
+	DefaultSimulatorTimeScale         = 1.0
+)
+
+type (
+	// SimulatorFault describes an injected failure for a single device.
+	SimulatorFault struct {
+		// Error is returned from every operation on the device when set.
+		Error string `json:"error,omitempty"`
+
+		// DelaySeconds will block every operation on the device to simulate a hung read.
+		DelaySeconds float64 `json:"delay_seconds,omitempty"`
+	}
+
+	// SimulatorState is a snapshot of the simulated plunge.
+	SimulatorState struct {
+		WaterTemperatureC float64                   `json:"water_temperature_c"`
+		RoomTemperatureC  float64                   `json:"room_temperature_c"`
+		ChillerSetpointC  float64                   `json:"chiller_setpoint_c"`
+		CoolingRate       float64                   `json:"cooling_rate"`
+		WarmingRate       float64                   `json:"warming_rate"`
+		TimeScale         float64                   `json:"time_scale"`
+		LeakPresent       bool                      `json:"leak_present"`
+		Leaks             map[string]bool           `json:"leaks"`
+		Cooling           bool                      `json:"cooling"`
+		ElapsedSeconds    float64                   `json:"elapsed_seconds"`
+		PendingSteps      int                       `json:"pending_steps"`
+		Switches          map[string]bool           `json:"switches"`
+		Faults            map[string]SimulatorFault `json:"faults"`
+	}
+
+	// SimulatorUpdate changes any of the simulated values that are set.
+	// LeakPresent wets every leak sensor, Leaks wets a single sensor by its device ID.
+	// Rates are the fraction of the difference to the target temperature closed per simulated minute.
+	SimulatorUpdate struct {
+		WaterTemperatureC *float64                  `json:"water_temperature_c,omitempty"`
+		RoomTemperatureC  *float64                  `json:"room_temperature_c,omitempty"`
+		ChillerSetpointC  *float64                  `json:"chiller_setpoint_c,omitempty"`
+		CoolingRate       *float64                  `json:"cooling_rate,omitempty"`
+		WarmingRate       *float64                  `json:"warming_rate,omitempty"`
+		TimeScale         *float64                  `json:"time_scale,omitempty"`
+		LeakPresent       *bool                     `json:"leak_present,omitempty"`
+		Leaks             map[string]bool           `json:"leaks,omitempty"`
+		Switches          map[string]bool           `json:"switches,omitempty"`
+		Faults            map[string]SimulatorFault `json:"faults,omitempty"`
+		ClearFaults       bool                      `json:"clear_faults,omitempty"`
+	}
+
+	// ScenarioStep is applied once the scenario has been running for AfterSeconds of wall clock time.
+	ScenarioStep struct {
+		AfterSeconds float64 `json:"after_seconds"`
+		SimulatorUpdate
+	}
+
+	// SimulatorScenario sets the initial state of the simulator and a timeline of changes.
+	SimulatorScenario struct {
+		Initial SimulatorUpdate `json:"initial"`
+		Steps   []ScenarioStep  `json:"steps"`
+	}
+
+	// Simulator models the water in the plunge so the server can run end to end without hardware.
+	// The water cools toward the chiller setpoint while the pump circulates it and warms toward
+	// the room temperature while the pump is off.
+	Simulator struct {
+		mu         sync.Mutex
+		now        func() time.Time
+		state      SimulatorState
+		devices    map[string]DeviceConfig
+		lastUpdate time.Time
+		started    time.Time
+		steps      []ScenarioStep
+	}
+)
+
+// NewSimulator creates a simulator with the default thermal model.
+func NewSimulator() *Simulator {
+	return newSimulator(time.Now)
+}
+
+func newSimulator(now func() time.Time) *Simulator {
+	t := now()
+	return &Simulator{
+		now: now,
+		state: SimulatorState{
+			WaterTemperatureC: DefaultSimulatorWaterTemperatureC,
+			RoomTemperatureC:  DefaultSimulatorRoomTemperatureC,
+			ChillerSetpointC:  DefaultSimulatorChillerSetpointC,
+			CoolingRate:       DefaultSimulatorCoolingRate,
+			WarmingRate:       DefaultSimulatorWarmingRate,
+			TimeScale:         DefaultSimulatorTimeScale,
+			Leaks:             make(map[string]bool),
+			Switches:          make(map[string]bool),
+			Faults:            make(map[string]SimulatorFault),
+		},
+		devices:    make(map[string]DeviceConfig),
+		lastUpdate: t,
+		started:    t,
+	}
+}
+
+// LoadScenarioFile will read a scenario from a JSON file and start it.
+func (s *Simulator) LoadScenarioFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	bytes, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	var scenario SimulatorScenario
+	if err := json.Unmarshal(bytes, &scenario); err != nil {
+		return err
+	}
+
+	s.LoadScenario(scenario)
+
+	return nil
+}
+
+// LoadScenario applies the initial state of the scenario and restarts its timeline.
+func (s *Simulator) LoadScenario(scenario SimulatorScenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.advance(now)
+	s.apply(scenario.Initial)
+
+	steps := make([]ScenarioStep, len(scenario.Steps))
+	copy(steps, scenario.Steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].AfterSeconds < steps[j].AfterSeconds
+	})
+
+	s.steps = steps
+	s.started = now
+
+	slog.Info("loaded simulator scenario", "steps", len(steps))
+}
+
+// Update will apply the changes to the simulator immediately.
+func (s *Simulator) Update(update SimulatorUpdate) SimulatorState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance(s.now())
+	s.apply(update)
+
+	return s.snapshot()
+}
+
+// State returns a snapshot of the simulator after advancing the model to the current time.
+func (s *Simulator) State() SimulatorState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance(s.now())
+
+	return s.snapshot()
+}
+
+// NewDevice creates a simulated device for the configuration.
+func (s *Simulator) NewDevice(config DeviceConfig) (Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.devices[config.ID] = config
+	switch config.SensorType {
+	case SENSOR_POWER:
+		s.state.Switches[config.ID] = config.NormallyOn
+	case SENSOR_LEAK:
+		s.state.Leaks[config.ID] = false
+	}
+
+	return &simulatedDevice{sim: s, config: config}, nil
+}
+
+func (s *Simulator) snapshot() SimulatorState {
+	state := s.state
+	state.Cooling = s.isCooling()
+	state.ElapsedSeconds = s.now().Sub(s.started).Seconds()
+	state.PendingSteps = len(s.steps)
+
+	state.Leaks = make(map[string]bool, len(s.state.Leaks))
+	for id, wet := range s.state.Leaks {
+		state.Leaks[id] = wet
+	}
+
+	state.Switches = make(map[string]bool, len(s.state.Switches))
+	for id, on := range s.state.Switches {
+		state.Switches[id] = on
+	}
+
+	state.Faults = make(map[string]SimulatorFault, len(s.state.Faults))
+	for id, f := range s.state.Faults {
+		state.Faults[id] = f
+	}
+
+	return state
+}
+
+func (s *Simulator) apply(u SimulatorUpdate) {
+	if u.WaterTemperatureC != nil {
+		s.state.WaterTemperatureC = *u.WaterTemperatureC
+	}
+	if u.RoomTemperatureC != nil {
+		s.state.RoomTemperatureC = *u.RoomTemperatureC
+	}
+	if u.ChillerSetpointC != nil {
+		s.state.ChillerSetpointC = *u.ChillerSetpointC
+	}
+	if u.CoolingRate != nil {
+		s.state.CoolingRate = *u.CoolingRate
+	}
+	if u.WarmingRate != nil {
+		s.state.WarmingRate = *u.WarmingRate
+	}
+	if u.TimeScale != nil {
+		s.state.TimeScale = *u.TimeScale
+	}
+	if u.LeakPresent != nil {
+		s.state.LeakPresent = *u.LeakPresent
+	}
+	for id, wet := range u.Leaks {
+		s.state.Leaks[id] = wet
+	}
+	for id, on := range u.Switches {
+		s.state.Switches[id] = on
+	}
+	if u.ClearFaults {
+		s.state.Faults = make(map[string]SimulatorFault)
+	}
+	for id, f := range u.Faults {
+		if len(f.Error) == 0 && f.DelaySeconds == 0 {
+			delete(s.state.Faults, id)
+			continue
+		}
+		s.state.Faults[id] = f
+	}
+}
+
+// isCooling reports if the chiller is pulling the water toward its setpoint.
+// The chiller only runs while the pump circulates water through it, and if no chiller
+// device is configured it is assumed to always be powered.
+func (s *Simulator) isCooling() bool {
+	pumpOn := false
+	chillerOn := true
+	for id, on := range s.state.Switches {
+		switch s.devices[id].Role {
+		case ROLE_PUMP:
+			pumpOn = pumpOn || on
+		case ROLE_CHILLER:
+			chillerOn = on
+		}
+	}
+
+	return pumpOn && chillerOn
+}
+
+// advance moves the thermal model forward to now and applies any scenario steps that are due.
+func (s *Simulator) advance(now time.Time) {
+	for len(s.steps) != 0 {
+		step := s.steps[0]
+		due := s.started.Add(time.Duration(step.AfterSeconds * float64(time.Second)))
+		if due.After(now) {
+			break
+		}
+
+		s.step(due)
+		s.apply(step.SimulatorUpdate)
+		s.steps = s.steps[1:]
+		slog.Debug("applied simulator scenario step", "after_seconds", step.AfterSeconds)
+	}
+
+	s.step(now)
+}
+
+func (s *Simulator) step(now time.Time) {
+	elapsed := now.Sub(s.lastUpdate)
+	if elapsed <= 0 {
+		return
+	}
+	s.lastUpdate = now
+
+	minutes := elapsed.Minutes() * s.state.TimeScale
+
+	target := s.state.RoomTemperatureC
+	rate := s.state.WarmingRate
+	if s.isCooling() {
+		target = s.state.ChillerSetpointC
+		rate = s.state.CoolingRate
+	}
+
+	// Newton's law of cooling, close the gap to the target exponentially
+	s.state.WaterTemperatureC = target + (s.state.WaterTemperatureC-target)*math.Exp(-rate*minutes)
+}
+
+// fault will apply any injected fault for the device, blocking if a delay is configured.
+func (s *Simulator) fault(id string) error {
+	s.mu.Lock()
+	f, ok := s.state.Faults[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if f.DelaySeconds > 0 {
+		time.Sleep(time.Duration(f.DelaySeconds * float64(time.Second)))
+	}
+
+	if len(f.Error) != 0 {
+		return errors.New(f.Error)
+	}
+
+	return nil
+}
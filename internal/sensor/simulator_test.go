@@ -0,0 +1,122 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func newTestSimulator(t *testing.T) (*Simulator, *testClock, *DeviceRegistry) {
+	clock := &testClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	sim := newSimulator(clock.now)
+
+	configs := []DeviceConfig{
+		{ID: "water", Role: ROLE_WATER, SensorType: SENSOR_TEMPERATURE, CalibrationOffsetCelsius: -1.0},
+		{ID: "leak", Role: ROLE_LEAK, SensorType: SENSOR_LEAK},
+		{ID: "pump", Role: ROLE_PUMP, SensorType: SENSOR_POWER, NormallyOn: true},
+	}
+
+	r := &DeviceRegistry{byID: make(map[string]Device), byRole: make(map[string][]Device)}
+	for _, c := range configs {
+		d, err := sim.NewDevice(c)
+		if err != nil {
+			t.Fatalf("failed to create simulated device: %v", err)
+		}
+		r.devices = append(r.devices, d)
+		r.byID[c.ID] = d
+		r.byRole[c.Role] = append(r.byRole[c.Role], d)
+	}
+
+	return sim, clock, r
+}
+
+func TestSimulatorThermalModel(t *testing.T) {
+	t.Run("water should cool toward the setpoint while the pump is on", func(t *testing.T) {
+		sim, clock, _ := newTestSimulator(t)
+
+		clock.t = clock.t.Add(30 * time.Minute)
+		state := sim.State()
+
+		if !state.Cooling {
+			t.Errorf("expected the simulator to be cooling")
+		}
+
+		if state.WaterTemperatureC >= DefaultSimulatorWaterTemperatureC || state.WaterTemperatureC <= DefaultSimulatorChillerSetpointC {
+			t.Errorf("expected water temperature between %v and %v, got %v", DefaultSimulatorChillerSetpointC, DefaultSimulatorWaterTemperatureC, state.WaterTemperatureC)
+		}
+	})
+
+	t.Run("water should warm toward the room while the pump is off", func(t *testing.T) {
+		sim, clock, r := newTestSimulator(t)
+
+		pump, _ := r.Switch(ROLE_PUMP)
+		pump.TurnOff()
+
+		clock.t = clock.t.Add(time.Hour)
+		state := sim.State()
+
+		if state.WaterTemperatureC <= DefaultSimulatorWaterTemperatureC {
+			t.Errorf("expected water to warm above %v, got %v", DefaultSimulatorWaterTemperatureC, state.WaterTemperatureC)
+		}
+	})
+
+	t.Run("reading should account for the calibration offset", func(t *testing.T) {
+		_, _, r := newTestSimulator(t)
+
+		d, _ := r.Lookup(ROLE_WATER)
+		raw, err := d.(TemperatureDevice).ReadTemperature()
+		if err != nil {
+			t.Fatalf("failed to read temperature: %v", err)
+		}
+
+		if raw+d.Config().CalibrationOffsetCelsius != DefaultSimulatorWaterTemperatureC {
+			t.Errorf("expected calibrated temperature %v, got %v", DefaultSimulatorWaterTemperatureC, raw+d.Config().CalibrationOffsetCelsius)
+		}
+	})
+}
+
+func TestSimulatorScenario(t *testing.T) {
+	t.Run("should apply steps once they are due", func(t *testing.T) {
+		sim, clock, r := newTestSimulator(t)
+
+		leak := true
+		sim.LoadScenario(SimulatorScenario{
+			Steps: []ScenarioStep{
+				{AfterSeconds: 60, SimulatorUpdate: SimulatorUpdate{LeakPresent: &leak}},
+			},
+		})
+
+		input, _ := r.Input(ROLE_LEAK)
+		if active, _ := input.IsActive(); active {
+			t.Errorf("expected no leak before the step is due")
+		}
+
+		clock.t = clock.t.Add(61 * time.Second)
+		if active, _ := input.IsActive(); !active {
+			t.Errorf("expected a leak after the step is due")
+		}
+	})
+
+	t.Run("should inject read errors", func(t *testing.T) {
+		sim, _, r := newTestSimulator(t)
+
+		sim.Update(SimulatorUpdate{Faults: map[string]SimulatorFault{"water": {Error: "bus error"}}})
+
+		d, _ := r.Lookup(ROLE_WATER)
+		if _, err := d.(TemperatureDevice).ReadTemperature(); err == nil {
+			t.Errorf("expected an injected error")
+		}
+
+		sim.Update(SimulatorUpdate{ClearFaults: true})
+		if _, err := d.(TemperatureDevice).ReadTemperature(); err != nil {
+			t.Errorf("expected the fault to be cleared, got %v", err)
+		}
+	})
+}
@@ -1,13 +1,32 @@
 package sensor
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 const (
 	DRIVERTYPE_DS18B20 string = "DS18B20"
 	DRIVERTYPE_GPIO    string = "GPIO"
+	DRIVERTYPE_MOCK    string = "MOCK"
 	SENSOR_TEMPERATURE string = "temperature"
 	SENSOR_LEAK        string = "leak"
 	SENSOR_POWER       string = "power"
+
+	ROLE_WATER   string = "water"
+	ROLE_ROOM    string = "room"
+	ROLE_LEAK    string = "leak"
+	ROLE_PUMP    string = "pump"
+	ROLE_OZONE   string = "ozone"
+	ROLE_CHILLER string = "chiller"
+)
+
+var (
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrUnknownDriver     = errors.New("unknown driver type")
+	ErrUnsupportedDevice = errors.New("device does not support the operation")
+	ErrDuplicateDeviceID = errors.New("duplicate device id")
 )
 
 type (
@@ -16,20 +35,18 @@ type (
 	SensorConfig struct {
 		SensorTimeout time.Duration
 		Devices       []DeviceConfig
-
-		TemperatureSensors map[string]DeviceConfig
-		LeakSensor         DeviceConfig
-		OzoneDevice        DeviceConfig
-		PumpDevice         DeviceConfig
 	}
 
 	DeviceConfig struct {
+		ID                       string  `json:"id,omitempty"`
+		Role                     string  `json:"role,omitempty"`
 		DriverType               string  `json:"driver_type"`
 		SensorType               string  `json:"sensor_type"`
 		Address                  string  `json:"address"`
 		Name                     string  `json:"name"`
 		Description              string  `json:"description"`
 		NormallyOn               bool    `json:"normally_on,omitempty"`
+		Zone                     string  `json:"zone,omitempty"`
 		CalibrationOffsetCelsius float64 `json:"calibration_offset_celsius"`
 	}
 
@@ -37,27 +54,46 @@ type (
 		Name         string  `json:"name,omitempty"`
 		Description  string  `json:"description,omitempty"`
 		Address      string  `json:"address,omitempty"`
+		Role         string  `json:"role,omitempty"`
 		TemperatureC float64 `json:"temperature_c,omitempty"`
 		TemperatureF float64 `json:"temperature_f,omitempty"`
 		Err          error   `json:"err,omitempty"`
 	}
 
-	Sensors interface {
-		ReadRoomAndWaterTemperature() (TemperatureReading, TemperatureReading)
-		ReadTemperatures() []TemperatureReading
-		IsLeakPresent() (bool, error)
-		TurnOzoneOn() error
-		TurnOzoneOff() error
-		IsPumpOn() (bool, error)
-		TurnPumpOn() error
-		TurnPumpOff() error
+	// LeakReading is the state of a single leak sensor, Zone names the area it watches.
+	LeakReading struct {
+		ID          string `json:"id"`
+		Name        string `json:"name,omitempty"`
+		Description string `json:"description,omitempty"`
+		Zone        string `json:"zone"`
+		LeakPresent bool   `json:"leak_present"`
+		Err         error  `json:"err,omitempty"`
 	}
 
-	HardwareSensors struct {
-		config SensorConfig
+	// Sensors reads and controls the configured devices.
+	// Every call is bounded by the sensor timeout and returns a TimeoutError when a device does not respond.
+	Sensors interface {
+		ReadTemperatures(ctx context.Context) []TemperatureReading
+		ReadLeakSensors(ctx context.Context) []LeakReading
+		TurnOzoneOn(ctx context.Context) error
+		TurnOzoneOff(ctx context.Context) error
+		IsPumpOn(ctx context.Context) (bool, error)
+		TurnPumpOn(ctx context.Context) error
+		TurnPumpOff(ctx context.Context) error
+
+		// Power devices can be addressed by their configured ID or role.
+		IsDeviceOn(ctx context.Context, id string) (bool, error)
+		TurnDeviceOn(ctx context.Context, id string) error
+		TurnDeviceOff(ctx context.Context, id string) error
+
+		// DeviceHealth returns the failure counts for every device that has been used.
+		DeviceHealth() []DeviceHealth
 	}
 
-	MockSensors struct {
-		config SensorConfig
+	// DeviceSensors implements Sensors on top of the devices created from the driver registry.
+	DeviceSensors struct {
+		config  SensorConfig
+		devices *DeviceRegistry
+		health  *deviceHealthTracker
 	}
 )
@@ -0,0 +1,137 @@
+package thermostat
+
+import (
+	"time"
+)
+
+// DefaultSettings returns the settings used until the thermostat is configured.
+func DefaultSettings() Settings {
+	return Settings{
+		Mode:             MODE_OFF,
+		SetpointF:        DefaultSetpointF,
+		HysteresisF:      DefaultHysteresisF,
+		MinOnSeconds:     DefaultMinOnSeconds,
+		MinOffSeconds:    DefaultMinOffSeconds,
+		MaxCyclesPerHour: DefaultMaxCyclesPerHour,
+	}
+}
+
+// Validate the settings.
+func (s Settings) Validate() error {
+	switch s.Mode {
+	case MODE_OFF, MODE_COOL, MODE_HEAT:
+	default:
+		return ErrInvalidMode
+	}
+
+	if s.SetpointF < 32 || s.SetpointF > 110 {
+		return ErrInvalidSetpoint
+	}
+
+	if s.HysteresisF <= 0 || s.HysteresisF > 10 {
+		return ErrInvalidHysteresis
+	}
+
+	if s.MinOnSeconds < 0 || s.MinOffSeconds < 0 || s.MaxCyclesPerHour < 0 {
+		return ErrInvalidTiming
+	}
+
+	return nil
+}
+
+// Evaluate decides if the device should be on.
+//
+// In cool mode the device is switched on once the water rises to the setpoint plus the hysteresis
+// and off once it falls to the setpoint, heat mode is the mirror image. Between the two the device
+// keeps its current state. The minimum on/off times and the cycle limit protect a compressor from
+// short cycling, except that turning the mode off or losing the water reading always turns it off.
+func Evaluate(settings Settings, state State, waterF float64, valid bool, now time.Time) Decision {
+	if settings.Mode == MODE_OFF {
+		return Decision{On: false, Reason: REASON_MODE_OFF}
+	}
+
+	if !valid {
+		return Decision{On: false, Reason: REASON_NO_READING}
+	}
+
+	want, reason := demand(settings, state.On, waterF)
+	if want == state.On {
+		return Decision{On: want, Reason: reason}
+	}
+
+	sinceChange := now.Sub(state.LastChangedAt)
+	if state.On {
+		if sinceChange < time.Duration(settings.MinOnSeconds)*time.Second {
+			return Decision{On: true, Reason: REASON_MIN_ON}
+		}
+
+		return Decision{On: false, Reason: reason}
+	}
+
+	if !state.LastChangedAt.IsZero() && sinceChange < time.Duration(settings.MinOffSeconds)*time.Second {
+		return Decision{On: false, Reason: REASON_MIN_OFF}
+	}
+
+	if settings.MaxCyclesPerHour > 0 && CyclesSince(state.Starts, now.Add(-cycleWindow)) >= settings.MaxCyclesPerHour {
+		return Decision{On: false, Reason: REASON_CYCLE_LIMIT}
+	}
+
+	return Decision{On: true, Reason: reason}
+}
+
+// Apply records the decision in the state, tracking when the device was started.
+func (s State) Apply(d Decision, now time.Time) State {
+	if d.On == s.On {
+		return s
+	}
+
+	s.On = d.On
+	s.LastChangedAt = now
+
+	if d.On {
+		// only the starts inside the cycle window are needed
+		starts := make([]time.Time, 0, len(s.Starts)+1)
+		for _, t := range s.Starts {
+			if t.After(now.Add(-cycleWindow)) {
+				starts = append(starts, t)
+			}
+		}
+		s.Starts = append(starts, now)
+	}
+
+	return s
+}
+
+// CyclesSince counts the starts after the time.
+func CyclesSince(starts []time.Time, since time.Time) int {
+	count := 0
+	for _, t := range starts {
+		if t.After(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func demand(settings Settings, on bool, waterF float64) (bool, string) {
+	switch settings.Mode {
+	case MODE_COOL:
+		if waterF >= settings.SetpointF+settings.HysteresisF {
+			return true, REASON_DEMAND
+		}
+		if waterF <= settings.SetpointF {
+			return false, REASON_SATISFIED
+		}
+
+	case MODE_HEAT:
+		if waterF <= settings.SetpointF-settings.HysteresisF {
+			return true, REASON_DEMAND
+		}
+		if waterF >= settings.SetpointF {
+			return false, REASON_SATISFIED
+		}
+	}
+
+	return on, REASON_HOLDING
+}
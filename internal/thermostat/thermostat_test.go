@@ -0,0 +1,160 @@
+package thermostat
+
+import (
+	"testing"
+	"time"
+)
+
+func coolSettings() Settings {
+	s := DefaultSettings()
+	s.Mode = MODE_COOL
+	s.SetpointF = 39.0
+	s.HysteresisF = 1.0
+	s.MinOnSeconds = 300
+	s.MinOffSeconds = 300
+	s.MaxCyclesPerHour = 2
+
+	return s
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		settings func() Settings
+		state    State
+		waterF   float64
+		valid    bool
+		expected Decision
+	}{
+		{
+			name:     "should stay off when the mode is off",
+			settings: DefaultSettings,
+			state:    State{On: true, LastChangedAt: now},
+			waterF:   50,
+			valid:    true,
+			expected: Decision{On: false, Reason: REASON_MODE_OFF},
+		},
+		{
+			name:     "should turn off without a water reading",
+			settings: coolSettings,
+			state:    State{On: true, LastChangedAt: now},
+			valid:    false,
+			expected: Decision{On: false, Reason: REASON_NO_READING},
+		},
+		{
+			name:     "should turn on above the hysteresis band when cooling",
+			settings: coolSettings,
+			state:    State{LastChangedAt: longAgo},
+			waterF:   40.0,
+			valid:    true,
+			expected: Decision{On: true, Reason: REASON_DEMAND},
+		},
+		{
+			name:     "should hold its state inside the hysteresis band",
+			settings: coolSettings,
+			state:    State{On: true, LastChangedAt: longAgo},
+			waterF:   39.5,
+			valid:    true,
+			expected: Decision{On: true, Reason: REASON_HOLDING},
+		},
+		{
+			name:     "should turn off once the setpoint is reached",
+			settings: coolSettings,
+			state:    State{On: true, LastChangedAt: longAgo},
+			waterF:   39.0,
+			valid:    true,
+			expected: Decision{On: false, Reason: REASON_SATISFIED},
+		},
+		{
+			name:     "should wait for the minimum on time",
+			settings: coolSettings,
+			state:    State{On: true, LastChangedAt: now.Add(-time.Minute)},
+			waterF:   38.0,
+			valid:    true,
+			expected: Decision{On: true, Reason: REASON_MIN_ON},
+		},
+		{
+			name:     "should wait for the minimum off time",
+			settings: coolSettings,
+			state:    State{On: false, LastChangedAt: now.Add(-time.Minute)},
+			waterF:   45.0,
+			valid:    true,
+			expected: Decision{On: false, Reason: REASON_MIN_OFF},
+		},
+		{
+			name:     "should stop starting once the cycle limit is reached",
+			settings: coolSettings,
+			state: State{
+				LastChangedAt: now.Add(-10 * time.Minute),
+				Starts:        []time.Time{now.Add(-50 * time.Minute), now.Add(-30 * time.Minute)},
+			},
+			waterF:   45.0,
+			valid:    true,
+			expected: Decision{On: false, Reason: REASON_CYCLE_LIMIT},
+		},
+		{
+			name: "should turn on below the hysteresis band when heating",
+			settings: func() Settings {
+				s := coolSettings()
+				s.Mode = MODE_HEAT
+				return s
+			},
+			state:    State{LastChangedAt: longAgo},
+			waterF:   37.9,
+			valid:    true,
+			expected: Decision{On: true, Reason: REASON_DEMAND},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Evaluate(tc.settings(), tc.state, tc.waterF, tc.valid, now)
+			if d != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, d)
+			}
+		})
+	}
+}
+
+func TestStateApply(t *testing.T) {
+	t.Run("should record starts inside the cycle window", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		state := State{Starts: []time.Time{now.Add(-2 * time.Hour), now.Add(-30 * time.Minute)}}
+
+		state = state.Apply(Decision{On: true}, now)
+		if !state.On || !state.LastChangedAt.Equal(now) {
+			t.Errorf("expected the device to be on since %v, got %+v", now, state)
+		}
+
+		if len(state.Starts) != 2 {
+			t.Errorf("expected 2 starts in the last hour, got %d", len(state.Starts))
+		}
+	})
+}
+
+func TestSettingsValidate(t *testing.T) {
+	t.Run("should reject an unknown mode", func(t *testing.T) {
+		s := DefaultSettings()
+		s.Mode = "auto"
+		if err := s.Validate(); err != ErrInvalidMode {
+			t.Errorf("expected %v, got %v", ErrInvalidMode, err)
+		}
+	})
+
+	t.Run("should reject a zero hysteresis", func(t *testing.T) {
+		s := DefaultSettings()
+		s.HysteresisF = 0
+		if err := s.Validate(); err != ErrInvalidHysteresis {
+			t.Errorf("expected %v, got %v", ErrInvalidHysteresis, err)
+		}
+	})
+
+	t.Run("should accept the defaults", func(t *testing.T) {
+		if err := DefaultSettings().Validate(); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
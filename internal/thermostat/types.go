@@ -0,0 +1,81 @@
+package thermostat
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MODE_OFF  = "off"
+	MODE_COOL = "cool"
+	MODE_HEAT = "heat"
+
+	DefaultSetpointF        = 39.0
+	DefaultHysteresisF      = 1.0
+	DefaultMinOnSeconds     = 300
+	DefaultMinOffSeconds    = 300
+	DefaultMaxCyclesPerHour = 6
+
+	REASON_MODE_OFF    = "mode is off"
+	REASON_NO_READING  = "no water temperature reading"
+	REASON_DEMAND      = "temperature outside the hysteresis band"
+	REASON_SATISFIED   = "setpoint reached"
+	REASON_HOLDING     = "within the hysteresis band"
+	REASON_MIN_ON      = "waiting for the minimum on time"
+	REASON_MIN_OFF     = "waiting for the minimum off time"
+	REASON_CYCLE_LIMIT = "maximum cycles per hour reached"
+	cycleWindow        = time.Hour
+)
+
+var (
+	ErrInvalidMode       = errors.New("mode must be one of 'off', 'cool' or 'heat'")
+	ErrInvalidSetpoint   = errors.New("setpoint must be between 32 and 110 degrees Fahrenheit")
+	ErrInvalidHysteresis = errors.New("hysteresis must be greater than zero and no more than 10 degrees Fahrenheit")
+	ErrInvalidTiming     = errors.New("minimum on and off times and the maximum cycles per hour can't be negative")
+)
+
+type (
+	// Settings for the control loop.
+	Settings struct {
+		Mode string `json:"mode"`
+
+		// SetpointF is the target water temperature.
+		SetpointF float64 `json:"setpoint_f"`
+
+		// HysteresisF is how far the water can drift past the setpoint before the device is switched on.
+		HysteresisF float64 `json:"hysteresis_f"`
+
+		// MinOnSeconds is how long the device must run before it can be switched off.
+		MinOnSeconds int `json:"min_on_seconds"`
+
+		// MinOffSeconds is how long the device must rest before it can be switched on again.
+		MinOffSeconds int `json:"min_off_seconds"`
+
+		// MaxCyclesPerHour limits how often the device can be started, zero disables the limit.
+		MaxCyclesPerHour int `json:"max_cycles_per_hour"`
+	}
+
+	// State tracks the device between evaluations.
+	State struct {
+		On            bool
+		LastChangedAt time.Time
+		Starts        []time.Time
+	}
+
+	// Decision is the result of evaluating the control loop.
+	Decision struct {
+		On     bool
+		Reason string
+	}
+
+	// Status of the thermostat reported over the API.
+	Status struct {
+		Settings
+		Device         string    `json:"device"`
+		DeviceOn       bool      `json:"device_on"`
+		Reason         string    `json:"reason"`
+		LastChangedAt  time.Time `json:"last_changed_at"`
+		CyclesLastHour int       `json:"cycles_last_hour"`
+		Error          string    `json:"error,omitempty"`
+	}
+)
@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+func NewHandler(store AlertStore) *Handler {
+	return &Handler{
+		store,
+	}
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/alerts", h.handleAlertsGet)
+	mux.HandleFunc("POST /v1/alerts", h.handleAlertCreate)
+	mux.HandleFunc("GET /v1/alerts/{id}", h.handleAlertGet)
+	mux.HandleFunc("PUT /v1/alerts/{id}", h.handleAlertUpdate)
+	mux.HandleFunc("DELETE /v1/alerts/{id}", h.handleAlertDelete)
+}
+
+func (h *Handler) handleAlertsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleAlertsGet")
+	defer slog.Debug("<<handleAlertsGet")
+
+	dbRules, err := h.store.GetAlertRules(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the alert rules", err)
+		return
+	}
+
+	response := make([]AlertRuleResponse, 0, len(dbRules))
+	for _, db := range dbRules {
+		response = append(response, databaseAlertRuleToResponse(db))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleAlertGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleAlertGet")
+	defer slog.Debug("<<handleAlertGet")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid alert rule id", err)
+		return
+	}
+
+	dbRule, err := h.store.GetAlertRule(r.Context(), id)
+	if err != nil {
+		respondWithStoreError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseAlertRuleToResponse(dbRule))
+}
+
+func (h *Handler) handleAlertCreate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleAlertCreate")
+	defer slog.Debug("<<handleAlertCreate")
+
+	request, err := parseAlertRuleRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	enabled := request.Enabled == nil || *request.Enabled
+
+	arg := database.CreateAlertRuleParams{
+		Name:            request.Name,
+		Device:          request.Device,
+		Condition:       request.Condition,
+		ThresholdF:      fmt.Sprintf("%f", request.ThresholdF),
+		OfflineSeconds:  int32(request.OfflineSeconds),
+		CooldownSeconds: int32(request.CooldownSeconds),
+		Enabled:         enabled,
+		OneShot:         request.OneShot,
+	}
+
+	dbRule, err := h.store.CreateAlertRule(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to create the alert rule", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, databaseAlertRuleToResponse(dbRule))
+}
+
+func (h *Handler) handleAlertUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleAlertUpdate")
+	defer slog.Debug("<<handleAlertUpdate")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid alert rule id", err)
+		return
+	}
+
+	request, err := parseAlertRuleRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	enabled := request.Enabled == nil || *request.Enabled
+
+	arg := database.UpdateAlertRuleParams{
+		ID:              id,
+		Name:            request.Name,
+		Device:          request.Device,
+		Condition:       request.Condition,
+		ThresholdF:      fmt.Sprintf("%f", request.ThresholdF),
+		OfflineSeconds:  int32(request.OfflineSeconds),
+		CooldownSeconds: int32(request.CooldownSeconds),
+		Enabled:         enabled,
+		OneShot:         request.OneShot,
+	}
+
+	dbRule, err := h.store.UpdateAlertRule(r.Context(), arg)
+	if err != nil {
+		respondWithStoreError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseAlertRuleToResponse(dbRule))
+}
+
+func (h *Handler) handleAlertDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleAlertDelete")
+	defer slog.Debug("<<handleAlertDelete")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid alert rule id", err)
+		return
+	}
+
+	count, err := h.store.DeleteAlertRule(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to delete the alert rule", err)
+		return
+	}
+
+	if count == 0 {
+		respondWithStoreError(w, sql.ErrNoRows)
+		return
+	}
+
+	utils.RespondWithNoContent(w, http.StatusNoContent)
+}
+
+// parseAlertRuleRequest reads and validates the rule in the body.
+func parseAlertRuleRequest(r *http.Request) (AlertRuleRequest, error) {
+	var request AlertRuleRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, errors.New("Invalid body for alert rule")
+	}
+
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return request, errors.New("Invalid body for alert rule")
+	}
+
+	rule := alerts.Rule{
+		Name:            request.Name,
+		Device:          request.Device,
+		Condition:       request.Condition,
+		ThresholdF:      request.ThresholdF,
+		OfflineSeconds:  request.OfflineSeconds,
+		CooldownSeconds: request.CooldownSeconds,
+	}
+
+	return request, rule.Validate()
+}
+
+func respondWithStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the alert rule", err)
+		return
+	}
+
+	utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the alert rule", err)
+}
+
+func databaseAlertRuleToResponse(db database.AlertRule) AlertRuleResponse {
+	// the threshold was validated before it was saved
+	threshold, _ := strconv.ParseFloat(db.ThresholdF, 64)
+
+	response := AlertRuleResponse{
+		ID:              db.ID,
+		CreatedAt:       db.CreatedAt,
+		UpdatedAt:       db.UpdatedAt,
+		Name:            db.Name,
+		Device:          db.Device,
+		Condition:       db.Condition,
+		ThresholdF:      threshold,
+		OfflineSeconds:  db.OfflineSeconds,
+		CooldownSeconds: db.CooldownSeconds,
+		Enabled:         db.Enabled,
+		OneShot:         db.OneShot,
+	}
+
+	if db.LastTriggeredAt.Valid {
+		response.LastTriggeredAt = &db.LastTriggeredAt.Time
+	}
+
+	return response
+}
@@ -0,0 +1,115 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+func TestAlertCreate(t *testing.T) {
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		h := NewHandler(&mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/alerts", bytes.NewBufferString("{"), h.handleAlertCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, "Invalid body for alert rule")
+	})
+
+	t.Run("should fail with an invalid condition", func(t *testing.T) {
+		h := NewHandler(&mockStore{})
+
+		body := `{"name": "cold", "device": "water", "condition": "between"}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/alerts", bytes.NewBufferString(body), h.handleAlertCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, alerts.ErrInvalidCondition.Error())
+	})
+
+	t.Run("should fail with a threshold that is out of range", func(t *testing.T) {
+		h := NewHandler(&mockStore{})
+
+		body := `{"name": "hot", "device": "water", "condition": "above", "threshold_f": 1000}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/alerts", bytes.NewBufferString(body), h.handleAlertCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, alerts.ErrInvalidThreshold.Error())
+	})
+
+	t.Run("should create an enabled rule by default", func(t *testing.T) {
+		store := mockStore{}
+		h := NewHandler(&store)
+
+		body := `{"name": "cold", "device": "water", "condition": "below", "threshold_f": 45, "cooldown_seconds": 600}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/alerts", bytes.NewBufferString(body), h.handleAlertCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if !store.created.Enabled || store.created.Condition != alerts.CONDITION_BELOW || store.created.CooldownSeconds != 600 {
+			t.Errorf("unexpected rule %+v", store.created)
+		}
+	})
+}
+
+func TestAlertGet(t *testing.T) {
+	t.Run("should fail with an invalid id", func(t *testing.T) {
+		h := NewHandler(&mockStore{})
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/alerts/{id}", map[string]string{"id": "not-a-uuid"}, nil, h.handleAlertGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail if the rule does not exist", func(t *testing.T) {
+		h := NewHandler(&mockStore{err: sql.ErrNoRows})
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/alerts/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handleAlertGet)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+}
+
+func TestAlertDelete(t *testing.T) {
+	t.Run("should fail if the rule does not exist", func(t *testing.T) {
+		h := NewHandler(&mockStore{deleted: 0})
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodDelete, "/v1/alerts/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handleAlertDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should delete the rule", func(t *testing.T) {
+		h := NewHandler(&mockStore{deleted: 1})
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodDelete, "/v1/alerts/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handleAlertDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNoContent)
+	})
+}
+
+type mockStore struct {
+	rules   []database.AlertRule
+	created database.CreateAlertRuleParams
+	deleted int64
+	err     error
+}
+
+func (m *mockStore) GetAlertRules(ctx context.Context) ([]database.AlertRule, error) {
+	return m.rules, m.err
+}
+
+func (m *mockStore) GetAlertRule(ctx context.Context, id uuid.UUID) (database.AlertRule, error) {
+	return database.AlertRule{ID: id}, m.err
+}
+
+func (m *mockStore) CreateAlertRule(ctx context.Context, arg database.CreateAlertRuleParams) (database.AlertRule, error) {
+	m.created = arg
+	return database.AlertRule{ID: uuid.New(), Name: arg.Name, Condition: arg.Condition, Enabled: arg.Enabled}, m.err
+}
+
+func (m *mockStore) UpdateAlertRule(ctx context.Context, arg database.UpdateAlertRuleParams) (database.AlertRule, error) {
+	return database.AlertRule{ID: arg.ID}, m.err
+}
+
+func (m *mockStore) DeleteAlertRule(ctx context.Context, id uuid.UUID) (int64, error) {
+	return m.deleted, m.err
+}
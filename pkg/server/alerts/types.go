@@ -0,0 +1,49 @@
+package alerts
+
+import (
+	"context"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type (
+	AlertRuleRequest struct {
+		Name            string  `json:"name"`
+		Device          string  `json:"device"`
+		Condition       string  `json:"condition"`
+		ThresholdF      float64 `json:"threshold_f"`
+		OfflineSeconds  int     `json:"offline_seconds"`
+		CooldownSeconds int     `json:"cooldown_seconds"`
+		Enabled         *bool   `json:"enabled,omitempty"`
+		OneShot         bool    `json:"one_shot"`
+	}
+
+	AlertRuleResponse struct {
+		ID              uuid.UUID  `json:"id"`
+		CreatedAt       time.Time  `json:"created_at"`
+		UpdatedAt       time.Time  `json:"updated_at"`
+		Name            string     `json:"name"`
+		Device          string     `json:"device"`
+		Condition       string     `json:"condition"`
+		ThresholdF      float64    `json:"threshold_f"`
+		OfflineSeconds  int32      `json:"offline_seconds"`
+		CooldownSeconds int32      `json:"cooldown_seconds"`
+		Enabled         bool       `json:"enabled"`
+		OneShot         bool       `json:"one_shot"`
+		LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	}
+
+	AlertStore interface {
+		GetAlertRules(ctx context.Context) ([]database.AlertRule, error)
+		GetAlertRule(ctx context.Context, id uuid.UUID) (database.AlertRule, error)
+		CreateAlertRule(ctx context.Context, arg database.CreateAlertRuleParams) (database.AlertRule, error)
+		UpdateAlertRule(ctx context.Context, arg database.UpdateAlertRuleParams) (database.AlertRule, error)
+		DeleteAlertRule(ctx context.Context, id uuid.UUID) (int64, error)
+	}
+
+	Handler struct {
+		store AlertStore
+	}
+)
@@ -0,0 +1,54 @@
+package debug
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+// NewHandler creates the handler used to inspect the notifications captured instead of being sent.
+func NewHandler(captures CaptureReader) *Handler {
+	return &Handler{
+		captures,
+	}
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/debug/notifications", h.handleCapturedGet)
+	mux.HandleFunc("DELETE /v1/debug/notifications", h.handleCapturedDelete)
+}
+
+// handleCapturedGet returns the captured notifications oldest first, optionally filtered by the event type and channel.
+func (h *Handler) handleCapturedGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleCapturedGet")
+	defer slog.Debug("<<handleCapturedGet")
+
+	eventType := r.URL.Query().Get("type")
+	channel := r.URL.Query().Get("channel")
+
+	response := make([]notification.Capture, 0)
+	for _, c := range h.captures.Captured() {
+		if len(eventType) != 0 && c.Event.Type != eventType {
+			continue
+		}
+
+		if len(channel) != 0 && c.Channel != channel {
+			continue
+		}
+
+		response = append(response, c)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleCapturedDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleCapturedDelete")
+	defer slog.Debug("<<handleCapturedDelete")
+
+	h.captures.ClearCaptured()
+
+	utils.RespondWithNoContent(w, http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func TestGetCapturedNotifications(t *testing.T) {
+	captures := mockCaptureReader{
+		captured: []notification.Capture{
+			{Channel: "capture", Event: notification.Event{Type: notification.TYPE_LEAK, Message: "Leak detected"}},
+			{Channel: "capture", Event: notification.Event{Type: notification.TYPE_OZONE, Message: "Ozone generator was started"}},
+			{Channel: "sms", PhoneNumber: "+15555550100", Event: notification.Event{Type: notification.TYPE_LEAK, Message: "Leak detected"}},
+		},
+	}
+
+	h := NewHandler(&captures)
+
+	t.Run("should return every capture", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/debug/notifications", nil, h.handleCapturedGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var response []notification.Capture
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(response) != 3 {
+			t.Errorf("expected %d captures, got %d", 3, len(response))
+		}
+	})
+
+	t.Run("should filter by type and channel", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/debug/notifications?type=leak&channel=sms", nil, h.handleCapturedGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var response []notification.Capture
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(response) != 1 || response[0].PhoneNumber != "+15555550100" {
+			t.Errorf("unexpected captures %+v", response)
+		}
+	})
+
+	t.Run("should clear the captures", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodDelete, "/v1/debug/notifications", nil, h.handleCapturedDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNoContent)
+
+		if len(captures.captured) != 0 {
+			t.Errorf("expected the captures to be cleared")
+		}
+	})
+}
+
+type mockCaptureReader struct {
+	captured []notification.Capture
+}
+
+func (m *mockCaptureReader) Captured() []notification.Capture {
+	return m.captured
+}
+
+func (m *mockCaptureReader) ClearCaptured() {
+	m.captured = nil
+}
@@ -0,0 +1,17 @@
+package debug
+
+import (
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+)
+
+type (
+	// CaptureReader reads and clears the notifications recorded by the capture channels.
+	CaptureReader interface {
+		Captured() []notification.Capture
+		ClearCaptured()
+	}
+
+	Handler struct {
+		captures CaptureReader
+	}
+)
@@ -7,13 +7,15 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 )
 
-func NewHandler(levelVar *slog.LevelVar, logger *slog.Logger) *Handler {
+func NewHandler(levelVar *slog.LevelVar, logger *slog.Logger, devices DeviceHealthReporter) *Handler {
 	h := Handler{}
 	h.logger = logger
 	h.levelVar = levelVar
+	h.devices = devices
 	// h.level = DefaultLogLevel
 	return &h
 }
@@ -28,10 +30,23 @@ func (h *Handler) handlerHealthGet(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlerGetHealth")
 	defer slog.Debug("<<handlerGetHealth")
 
+	devices := h.devices.DeviceHealth()
+
+	// the server is still usable when a device is failing, so report it as degraded rather than failing the check
+	status := "ok"
+	for _, d := range devices {
+		if d.ConsecutiveFailures > 0 {
+			status = "degraded"
+			break
+		}
+	}
+
 	response := struct {
-		Status string `json:"status"`
+		Status  string                `json:"status"`
+		Devices []sensor.DeviceHealth `json:"devices"`
 	}{
-		Status: "ok",
+		Status:  status,
+		Devices: devices,
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, response)
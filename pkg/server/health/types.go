@@ -3,10 +3,17 @@ package health
 import (
 	"log/slog"
 	"sync"
+
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
 )
 
+type DeviceHealthReporter interface {
+	DeviceHealth() []sensor.DeviceHealth
+}
+
 type Handler struct {
 	logger   *slog.Logger
 	levelVar *slog.LevelVar
+	devices  DeviceHealthReporter
 	mu       sync.RWMutex
 }
@@ -1,17 +1,23 @@
 package leaks
 
 import (
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/auth"
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
 )
 
-func NewHandler(store LeakStore) *Handler {
+func NewHandler(store LeakStore, alarm LeakAlarm) *Handler {
 	h := Handler{
 		store,
+		alarm,
 	}
 
 	return &h
@@ -19,38 +25,185 @@ func NewHandler(store LeakStore) *Handler {
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /v1/leaks", h.handlerLeakGet)
+	mux.HandleFunc("GET /v1/leaks/stats", h.handlerLeakStatsGet)
+	mux.HandleFunc("POST /v1/leaks/{id}/acknowledge", h.handlerLeakAcknowledge)
 }
 
 func (h *Handler) handlerLeakGet(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlerLeakGet")
 	defer slog.Debug("<<handlerLeakGet")
 
-	dbLeaks := make([]database.Leak, 0)
-
-	// TODO: Break this up and have a separate handler for one leak vs multiple
 	filter := r.URL.Query().Get("filter")
 	if filter == "current" {
+		h.handlerCurrentLeakGet(w, r)
+		return
+	}
+
+	from, to, err := utils.ParseTimeRange(r, DefaultLeakRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	page, err := utils.ParsePagination(r, DefaultLeaksLimit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbLeaks, err := h.store.GetLeaks(r.Context(), database.GetLeaksParams{
+		FromTime:  from,
+		ToTime:    to,
+		RowLimit:  int32(page.Limit),
+		RowOffset: int32(page.Offset),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the leak history", err)
+		return
+	}
+
+	total, err := h.store.CountLeaks(r.Context(), database.CountLeaksParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to count the leak history", err)
+		return
+	}
+
+	response := databaseLeaksToLeaks(dbLeaks, time.Now().UTC())
+
+	utils.RespondWithJSON(w, http.StatusOK, utils.NewPage(response, page, total))
+}
 
-		dbLeak, err := h.store.GetLatestLeakDetected(r.Context())
+func (h *Handler) handlerCurrentLeakGet(w http.ResponseWriter, r *http.Request) {
+	dbLeak, err := h.store.GetLatestLeakDetected(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the current leak event", err)
+		return
+	}
+
+	response := databaseLeaksToLeaks([]database.Leak{dbLeak}, time.Now().UTC())
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handlerLeakStatsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerLeakStatsGet")
+	defer slog.Debug("<<handlerLeakStatsGet")
+
+	from, to, err := utils.ParseTimeRange(r, DefaultLeakRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	dbLeaks, err := h.store.GetOverlappingLeaks(r.Context(), database.GetOverlappingLeaksParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the leak history", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, summarizeLeaks(dbLeaks, from, to, time.Now().UTC()))
+}
+
+// handlerLeakAcknowledge records the user that acknowledged a cleared leak, which allows the pump to run again.
+func (h *Handler) handlerLeakAcknowledge(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerLeakAcknowledge")
+	defer slog.Debug("<<handlerLeakAcknowledge")
+
+	apiKey, err := auth.ParseApiKey(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	user, err := h.store.GetUserByApiKey(r.Context(), apiKey)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid leak id", err)
+		return
+	}
+
+	var request AcknowledgeLeakRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&request)
 		if err != nil {
-			utils.RespondWithError(w, http.StatusNotFound, "could not find the current leak event", err)
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for leak acknowledgement", err)
 			return
 		}
+	}
 
-		dbLeaks = append(dbLeaks, dbLeak)
-
-	} else {
-		// TODO: Support reading of all (paginated) leaks
-		utils.RespondWithError(w, http.StatusNotImplemented, "read all leaks not supported", errors.New("read all leaks not supported"))
+	dbLeak, err := h.alarm.AcknowledgeLeak(r.Context(), id, user.ID, request.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, monitor.ErrLeakNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error(), err)
+		case errors.Is(err, monitor.ErrLeakActive), errors.Is(err, monitor.ErrLeakAcknowledged):
+			utils.RespondWithError(w, http.StatusConflict, err.Error(), err)
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, "failed to acknowledge the leak", err)
+		}
 		return
 	}
 
-	response := databaseLeaksToLeaks(dbLeaks)
+	response := databaseLeaksToLeaks([]database.Leak{dbLeak}, time.Now().UTC())
 
-	utils.RespondWithJSON(w, http.StatusOK, response)
+	utils.RespondWithJSON(w, http.StatusOK, response[0])
 }
 
-func databaseLeaksToLeaks(dbLeaks []database.Leak) []LeakResponse {
+// summarizeLeaks counts the leaks that overlap the window and the time they were wet within it.
+// A leak that hasn't been cleared is wet until now.
+func summarizeLeaks(dbLeaks []database.Leak, from, to, now time.Time) LeakStatsResponse {
+	stats := LeakStatsResponse{
+		From: from,
+		To:   to,
+	}
+
+	for _, dbLeak := range dbLeaks {
+		end := now
+		if dbLeak.ClearedAt.Valid {
+			end = dbLeak.ClearedAt.Time
+		} else {
+			stats.Active = true
+		}
+
+		start := dbLeak.DetectedAt
+		if start.Before(from) {
+			start = from
+		}
+
+		if end.After(to) {
+			end = to
+		}
+
+		if !end.After(start) {
+			continue
+		}
+
+		wet := end.Sub(start).Seconds()
+		stats.Count++
+		stats.TotalWetSeconds += wet
+
+		if wet > stats.LongestSeconds {
+			id := dbLeak.ID
+			stats.LongestSeconds = wet
+			stats.LongestLeakID = &id
+		}
+	}
+
+	return stats
+}
+
+func databaseLeaksToLeaks(dbLeaks []database.Leak, now time.Time) []LeakResponse {
 	leaks := make([]LeakResponse, 0, len(dbLeaks))
 
 	for _, dbLeak := range dbLeaks {
@@ -60,10 +213,27 @@ func databaseLeaksToLeaks(dbLeaks []database.Leak) []LeakResponse {
 			CreatedAt:  dbLeak.CreatedAt,
 			UpdatedAt:  dbLeak.UpdatedAt,
 			DetectedAt: dbLeak.DetectedAt,
+			SensorID:   dbLeak.SensorID,
+			Zone:       dbLeak.Zone,
 		}
 
+		end := now
 		if dbLeak.ClearedAt.Valid {
 			leak.ClearedAt = dbLeak.ClearedAt
+			end = dbLeak.ClearedAt.Time
+		} else {
+			leak.Active = true
+		}
+
+		leak.DurationSeconds = end.Sub(dbLeak.DetectedAt).Seconds()
+
+		if dbLeak.AcknowledgedAt.Valid {
+			leak.AcknowledgedAt = &dbLeak.AcknowledgedAt.Time
+			leak.AcknowledgementNote = dbLeak.AcknowledgementNote.String
+		}
+
+		if dbLeak.AcknowledgedBy.Valid {
+			leak.AcknowledgedBy = &dbLeak.AcknowledgedBy.UUID
 		}
 
 		leaks = append(leaks, leak)
@@ -2,19 +2,25 @@ package leaks
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
 )
 
 func TestGetCurrentLeak(t *testing.T) {
 	t.Run("Fail to find a currently running leak", func(t *testing.T) {
 		store := mockLeakStore{}
-		h := NewHandler(&store)
+		h := NewHandler(&store, &mockLeakAlarm{})
 
 		store.err = errors.New("could not find the current leak event")
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks?filter=current", nil, h.handlerLeakGet)
@@ -32,7 +38,7 @@ func TestGetCurrentLeak(t *testing.T) {
 		store := mockLeakStore{}
 		dbLeak := database.Leak{}
 		store.leak = dbLeak
-		h := NewHandler(&store)
+		h := NewHandler(&store, &mockLeakAlarm{})
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks?filter=current", nil, h.handlerLeakGet)
 
 		if rr.Code != http.StatusOK {
@@ -45,30 +51,225 @@ func TestGetCurrentLeak(t *testing.T) {
 	})
 }
 
-func TestGetAllLeaks(t *testing.T) {
-	t.Run("Expect not implemented", func(t *testing.T) {
+func TestGetLeakHistory(t *testing.T) {
+	t.Run("should fail with an invalid time range", func(t *testing.T) {
 		store := mockLeakStore{}
+		h := NewHandler(&store, &mockLeakAlarm{})
 
-		store.err = errors.New("read all leaks not supported")
-		h := NewHandler(&store)
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks?from=2024-06-02T00:00:00Z&to=2024-06-01T00:00:00Z", nil, h.handlerLeakGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
 
-		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks", nil, h.handlerLeakGet)
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		store := mockLeakStore{}
+		h := NewHandler(&store, &mockLeakAlarm{})
 
-		if rr.Code != http.StatusNotImplemented {
-			t.Errorf("expected status code %d, got %d", http.StatusNotImplemented, rr.Code)
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks?limit=1000", nil, h.handlerLeakGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, utils.ErrInvalidLimit.Error())
+	})
+
+	t.Run("should return a page of leaks with their durations", func(t *testing.T) {
+		detectedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+		store := mockLeakStore{
+			leaks: []database.Leak{
+				{ID: uuid.New(), DetectedAt: detectedAt, ClearedAt: sql.NullTime{Time: detectedAt.Add(90 * time.Second), Valid: true}, SensorID: "drip-tray", Zone: "chiller drip tray"},
+				{ID: uuid.New(), DetectedAt: detectedAt.Add(-time.Hour)},
+			},
+			count: 12,
 		}
+		h := NewHandler(&store, &mockLeakAlarm{})
 
-		if !strings.Contains(rr.Body.String(), store.err.Error()) {
-			t.Errorf("expected message %s, got %s", store.err.Error(), rr.Body.String())
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks?from=2024-06-01T00:00:00Z&to=2024-06-02T00:00:00Z&limit=2&offset=4", nil, h.handlerLeakGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.arg.RowLimit != 2 || store.arg.RowOffset != 4 || !store.arg.FromTime.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected query %+v", store.arg)
+		}
+
+		var page utils.Page[LeakResponse]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+
+		if page.Total != 12 || page.Limit != 2 || page.Offset != 4 || len(page.Items) != 2 {
+			t.Fatalf("unexpected page %+v", page)
+		}
+
+		if page.Items[0].DurationSeconds != 90 || page.Items[0].Active {
+			t.Errorf("expected a cleared leak of %d seconds, got %+v", 90, page.Items[0])
+		}
+
+		if page.Items[0].SensorID != "drip-tray" || page.Items[0].Zone != "chiller drip tray" {
+			t.Errorf("expected the leak to identify the sensor that tripped, got %+v", page.Items[0])
+		}
+
+		if !page.Items[1].Active {
+			t.Errorf("expected the uncleared leak to be active")
+		}
+	})
+}
+
+func TestGetLeakStats(t *testing.T) {
+	t.Run("should fail when the store fails", func(t *testing.T) {
+		store := mockLeakStore{err: errors.New("database is down")}
+		h := NewHandler(&store, &mockLeakAlarm{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/leaks/stats", nil, h.handlerLeakStatsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+	})
+
+	t.Run("should summarize the leaks in the window", func(t *testing.T) {
+		from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(24 * time.Hour)
+		longest := uuid.New()
+
+		leaks := []database.Leak{
+			// started before the window, only the 10 minutes inside it count
+			{ID: uuid.New(), DetectedAt: from.Add(-time.Hour), ClearedAt: sql.NullTime{Time: from.Add(10 * time.Minute), Valid: true}},
+			{ID: longest, DetectedAt: from.Add(time.Hour), ClearedAt: sql.NullTime{Time: from.Add(time.Hour + 30*time.Minute), Valid: true}},
+			// still wet, counted until the end of the window
+			{ID: uuid.New(), DetectedAt: to.Add(-5 * time.Minute)},
+		}
+
+		stats := summarizeLeaks(leaks, from, to, to.Add(time.Hour))
+
+		if stats.Count != 3 || !stats.Active {
+			t.Errorf("expected %d leaks with one active, got %+v", 3, stats)
+		}
+
+		if stats.TotalWetSeconds != (45 * time.Minute).Seconds() {
+			t.Errorf("expected %v seconds wet, got %v", (45 * time.Minute).Seconds(), stats.TotalWetSeconds)
+		}
+
+		if stats.LongestSeconds != (30*time.Minute).Seconds() || stats.LongestLeakID == nil || *stats.LongestLeakID != longest {
+			t.Errorf("unexpected longest leak %+v", stats)
 		}
 	})
 }
 
+func TestAcknowledgeLeak(t *testing.T) {
+	store := mockLeakStore{apiKey: "12345", user: database.User{ID: uuid.New()}}
+	alarm := mockLeakAlarm{}
+	h := NewHandler(&store, &alarm)
+
+	id := uuid.New()
+	headers := map[string][]string{
+		"Authorization": {"ApiKey 12345"},
+	}
+	path := "/v1/leaks/" + id.String() + "/acknowledge"
+	values := map[string]string{"id": id.String()}
+
+	acknowledge := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header = headers
+		for k, v := range values {
+			req.SetPathValue(k, v)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(h.handlerLeakAcknowledge).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("should fail without an API key", func(t *testing.T) {
+		rr := utils.TestRequestWithPathValues(t, http.MethodPost, path, values, nil, h.handlerLeakAcknowledge)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+	})
+
+	t.Run("should fail while the leak is active", func(t *testing.T) {
+		alarm.err = monitor.ErrLeakActive
+		defer func() { alarm.err = nil }()
+
+		rr := acknowledge(t, "")
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, monitor.ErrLeakActive.Error())
+	})
+
+	t.Run("should fail for an unknown leak", func(t *testing.T) {
+		alarm.err = monitor.ErrLeakNotFound
+		defer func() { alarm.err = nil }()
+
+		rr := acknowledge(t, "")
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should record the user and note", func(t *testing.T) {
+		rr := acknowledge(t, `{"note":"condensation on the sensor"}`)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if alarm.id != id || alarm.userID != store.user.ID || alarm.note != "condensation on the sensor" {
+			t.Errorf("unexpected acknowledgement %+v", alarm)
+		}
+
+		var response LeakResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if response.AcknowledgedBy == nil || *response.AcknowledgedBy != store.user.ID || response.AcknowledgementNote != "condensation on the sensor" {
+			t.Errorf("unexpected response %+v", response)
+		}
+	})
+}
+
+type mockLeakAlarm struct {
+	id     uuid.UUID
+	userID uuid.UUID
+	note   string
+	err    error
+}
+
+func (m *mockLeakAlarm) AcknowledgeLeak(ctx context.Context, id uuid.UUID, userID uuid.UUID, note string) (database.Leak, error) {
+	if m.err != nil {
+		return database.Leak{}, m.err
+	}
+
+	m.id, m.userID, m.note = id, userID, note
+
+	return database.Leak{
+		ID:                  id,
+		AcknowledgedAt:      sql.NullTime{Time: time.Now(), Valid: true},
+		AcknowledgedBy:      uuid.NullUUID{UUID: userID, Valid: true},
+		AcknowledgementNote: sql.NullString{String: note, Valid: len(note) != 0},
+	}, nil
+}
+
 type mockLeakStore struct {
-	err  error
-	leak database.Leak
+	err    error
+	apiKey string
+	user   database.User
+	leak   database.Leak
+	leaks  []database.Leak
+	count  int64
+	arg    database.GetLeaksParams
 }
 
 func (m *mockLeakStore) GetLatestLeakDetected(ctx context.Context) (database.Leak, error) {
 	return m.leak, m.err
 }
+
+func (m *mockLeakStore) GetLeaks(ctx context.Context, arg database.GetLeaksParams) ([]database.Leak, error) {
+	m.arg = arg
+	return m.leaks, m.err
+}
+
+func (m *mockLeakStore) CountLeaks(ctx context.Context, arg database.CountLeaksParams) (int64, error) {
+	return m.count, m.err
+}
+
+func (m *mockLeakStore) GetOverlappingLeaks(ctx context.Context, arg database.GetOverlappingLeaksParams) ([]database.Leak, error) {
+	return m.leaks, m.err
+}
+
+func (m *mockLeakStore) GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error) {
+	if m.apiKey != apiKey {
+		return database.User{}, errors.New("invalid API key")
+	}
+	return m.user, nil
+}
@@ -9,6 +9,14 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// DefaultLeakRange is the window used for the history and stats when 'from' is not given.
+	DefaultLeakRange = 30 * 24 * time.Hour
+
+	// DefaultLeaksLimit is the page size of the leak history when no limit is given.
+	DefaultLeaksLimit = 50
+)
+
 type (
 	LeakResponse struct {
 		ID         uuid.UUID    `json:"id"`
@@ -16,13 +24,49 @@ type (
 		UpdatedAt  time.Time    `json:"updated_at"`
 		DetectedAt time.Time    `json:"detected_at"`
 		ClearedAt  sql.NullTime `json:"cleared_at"`
+		SensorID   string       `json:"sensor_id"`
+		Zone       string       `json:"zone"`
+
+		// DurationSeconds is from detected_at to cleared_at, or to now while the leak is active.
+		DurationSeconds float64 `json:"duration_seconds"`
+		Active          bool    `json:"active"`
+
+		AcknowledgedAt      *time.Time `json:"acknowledged_at,omitempty"`
+		AcknowledgedBy      *uuid.UUID `json:"acknowledged_by,omitempty"`
+		AcknowledgementNote string     `json:"acknowledgement_note,omitempty"`
+	}
+
+	// AcknowledgeLeakRequest is the optional note stored with the acknowledgement.
+	AcknowledgeLeakRequest struct {
+		Note string `json:"note"`
+	}
+
+	// LeakStatsResponse summarizes the leaks in a window, only the part of a leak inside the window is counted as wet time.
+	LeakStatsResponse struct {
+		From            time.Time  `json:"from"`
+		To              time.Time  `json:"to"`
+		Count           int        `json:"count"`
+		TotalWetSeconds float64    `json:"total_wet_seconds"`
+		LongestSeconds  float64    `json:"longest_seconds"`
+		LongestLeakID   *uuid.UUID `json:"longest_leak_id,omitempty"`
+		Active          bool       `json:"active"`
 	}
 
 	LeakStore interface {
 		GetLatestLeakDetected(ctx context.Context) (database.Leak, error)
+		GetLeaks(ctx context.Context, arg database.GetLeaksParams) ([]database.Leak, error)
+		CountLeaks(ctx context.Context, arg database.CountLeaksParams) (int64, error)
+		GetOverlappingLeaks(ctx context.Context, arg database.GetOverlappingLeaksParams) ([]database.Leak, error)
+		GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error)
+	}
+
+	// LeakAlarm acknowledges leaks, releasing the latched alarm that keeps the pump off.
+	LeakAlarm interface {
+		AcknowledgeLeak(ctx context.Context, id uuid.UUID, userID uuid.UUID, note string) (database.Leak, error)
 	}
 
 	Handler struct {
 		store LeakStore
+		alarm LeakAlarm
 	}
 )
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+)
+
+// evaluateAlertRules records the readings and notifies the user of every enabled rule that was triggered.
+func (mctx *MonitorContext) evaluateAlertRules(readings []sensor.TemperatureReading) {
+	slog.Debug(">>evaluateAlertRules")
+	defer slog.Debug("<<evaluateAlertRules")
+
+	now := time.Now().UTC()
+	current := mctx.updateAlertReadings(readings, now)
+
+	dbRules, err := mctx.store.GetEnabledAlertRules(mctx.ctx)
+	if err != nil {
+		slog.Error("failed to read the alert rules", "error", err)
+		return
+	}
+
+	for _, dbRule := range dbRules {
+		alert, triggered := alerts.Evaluate(databaseToAlertRule(dbRule), current, now)
+		if !triggered {
+			continue
+		}
+
+		slog.Info("alert rule triggered", "rule", dbRule.Name, "message", alert.Message)
+
+		arg := database.MarkAlertRuleTriggeredParams{
+			ID:              dbRule.ID,
+			LastTriggeredAt: sql.NullTime{Valid: true, Time: now},
+		}
+
+		// don't notify unless the cooldown was saved, otherwise the user would be alerted on every tick
+		err = mctx.store.MarkAlertRuleTriggered(mctx.ctx, arg)
+		if err != nil {
+			slog.Error("failed to save the alert rule trigger", "rule", dbRule.Name, "error", err)
+			continue
+		}
+
+		mctx.NotifyCh <- alertToEvent(alert)
+	}
+}
+
+// alertToEvent creates the notification for a triggered rule, reaching a target is informational while the other conditions are warnings.
+func alertToEvent(alert alerts.Alert) notification.Event {
+	severity := notification.SEVERITY_WARNING
+	if alert.Rule.Condition == alerts.CONDITION_TARGET {
+		severity = notification.SEVERITY_INFO
+	}
+
+	return notification.Event{
+		Type:     notification.TYPE_ALERT,
+		Severity: severity,
+		Source:   alert.Rule.Name,
+		Message:  alert.Message,
+		Payload: map[string]any{
+			"rule_id":       alert.Rule.ID,
+			"condition":     alert.Rule.Condition,
+			"device":        alert.Reading.Name,
+			"temperature_f": alert.Reading.TemperatureF,
+			"threshold_f":   alert.Rule.ThresholdF,
+		},
+		Key: alert.Rule.ID.String(),
+	}
+}
+
+// updateAlertReadings keeps the latest reading from each device along with when it was last read successfully.
+func (mctx *MonitorContext) updateAlertReadings(readings []sensor.TemperatureReading, now time.Time) []alerts.Reading {
+	for _, r := range readings {
+		key := r.Address + "/" + r.Name
+
+		ar, ok := mctx.alertReadings[key]
+		if !ok {
+			// a device that has never been read is considered offline from the time the monitor first saw it
+			ar = alerts.Reading{Name: r.Name, Address: r.Address, Role: r.Role, LastSeenAt: now}
+		}
+
+		ar.Valid = r.Err == nil
+		if ar.Valid {
+			ar.TemperatureF = r.TemperatureF
+			ar.LastSeenAt = now
+		}
+
+		mctx.alertReadings[key] = ar
+	}
+
+	current := make([]alerts.Reading, 0, len(mctx.alertReadings))
+	for _, ar := range mctx.alertReadings {
+		current = append(current, ar)
+	}
+
+	return current
+}
+
+func databaseToAlertRule(db database.AlertRule) alerts.Rule {
+	// the threshold was validated before it was saved
+	threshold, _ := strconv.ParseFloat(db.ThresholdF, 64)
+
+	return alerts.Rule{
+		ID:              db.ID,
+		Name:            db.Name,
+		Device:          db.Device,
+		Condition:       db.Condition,
+		ThresholdF:      threshold,
+		OfflineSeconds:  int(db.OfflineSeconds),
+		CooldownSeconds: int(db.CooldownSeconds),
+		Enabled:         db.Enabled,
+		OneShot:         db.OneShot,
+		LastTriggeredAt: db.LastTriggeredAt.Time,
+	}
+}
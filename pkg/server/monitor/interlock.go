@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+)
+
+// CheckAction returns an interlock.Violation if the action is not allowed in the current state.
+func (mctx *MonitorContext) CheckAction(ctx context.Context, action string) error {
+	state, err := mctx.interlockState(ctx)
+	if err != nil {
+		return err
+	}
+
+	return interlock.Check(state, action)
+}
+
+// interlockState reads the current state of the pump, ozone generator, leak sensors and plunge.
+// The state is still returned with an error, with the pump assumed to be on if it couldn't be read.
+func (mctx *MonitorContext) interlockState(ctx context.Context) (interlock.State, error) {
+	var state interlock.State
+
+	pumpOn, pumpErr := mctx.sensors.IsPumpOn(ctx)
+	if pumpErr != nil {
+		// assume the pump is on so that a leak will still try to turn it off
+		pumpOn = true
+	}
+
+	state.PumpOn = pumpOn
+
+	plunge, err := mctx.store.GetLatestPlunge(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return state, fmt.Errorf("failed to read the latest plunge: %w", err)
+	}
+
+	state.PlungeRunning = err == nil && plunge.Running
+
+	mctx.Lock()
+	state.OzoneRunning = mctx.OzoneRunning
+	state.Leaks, state.UnacknowledgedLeaks = mctx.leakInterlockZones()
+	mctx.Unlock()
+
+	return state, pumpErr
+}
+
+// enforceInterlocks turns off any device that is running in a state the interlock rules don't allow.
+func (mctx *MonitorContext) enforceInterlocks() {
+	state, err := mctx.interlockState(mctx.ctx)
+	if err != nil {
+		slog.Warn("failed to read the complete interlock state", "error", err)
+	}
+
+	for _, e := range interlock.Enforce(state) {
+		slog.Info("enforcing interlock", "rule", e.Rule, "action", e.Action)
+
+		switch e.Action {
+		case interlock.ACTION_PUMP_OFF:
+			err = mctx.sensors.TurnPumpOff(mctx.ctx)
+			if err != nil {
+				slog.Error("failed to turn the pump off", "rule", e.Rule, "error", err)
+				mctx.NotifyCh <- notification.Event{
+					Type:     notification.TYPE_INTERLOCK,
+					Severity: notification.SEVERITY_CRITICAL,
+					Source:   SOURCE_INTERLOCK,
+					Message:  fmt.Sprintf("Failed to turn off the pump, %s.", e.Reason),
+					Payload:  map[string]any{"rule": e.Rule, "action": e.Action},
+					Key:      e.Rule + "/" + e.Action,
+				}
+			}
+
+		case interlock.ACTION_OZONE_STOP:
+			mctx.Lock()
+			if mctx.OzoneRunning {
+				mctx.ozoneCancelFunc()
+			}
+			mctx.Unlock()
+
+			mctx.NotifyCh <- notification.Event{
+				Type:     notification.TYPE_INTERLOCK,
+				Severity: notification.SEVERITY_WARNING,
+				Source:   SOURCE_INTERLOCK,
+				Message:  fmt.Sprintf("Stopping the ozone generator, %s.", e.Reason),
+				Payload:  map[string]any{"rule": e.Rule, "action": e.Action},
+				Key:      e.Rule + "/" + e.Action,
+			}
+		}
+	}
+}
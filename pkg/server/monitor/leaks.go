@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/google/uuid"
+)
+
+// LeakAlarm reports if a leak is waiting to be acknowledged.
+func (mctx *MonitorContext) LeakAlarm() bool {
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	return len(mctx.leakUnacknowledged) != 0
+}
+
+// LeakZones returns the state of each zone watched by a leak sensor or waiting for a leak to be acknowledged, sorted by name.
+func (mctx *MonitorContext) LeakZones() []LeakZoneStatus {
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	zones := make(map[string]*LeakZoneStatus)
+	zone := func(name string) *LeakZoneStatus {
+		z, ok := zones[name]
+		if !ok {
+			z = &LeakZoneStatus{Zone: name, Sensors: make([]string, 0), ShutOff: mctx.leakShutOff(name)}
+			zones[name] = z
+		}
+
+		return z
+	}
+
+	for _, ls := range mctx.leakSensors {
+		z := zone(ls.zone)
+		z.Sensors = append(z.Sensors, ls.id)
+		z.LeakDetected = z.LeakDetected || ls.detected
+	}
+
+	for name := range mctx.leakUnacknowledged {
+		zone(name).Unacknowledged = true
+	}
+
+	status := make([]LeakZoneStatus, 0, len(zones))
+	for _, z := range zones {
+		sort.Strings(z.Sensors)
+		status = append(status, *z)
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Zone < status[j].Zone
+	})
+
+	return status
+}
+
+// AcknowledgeLeak records who acknowledged a leak, the pump can run again once every leak is acknowledged.
+// A leak can't be acknowledged while its sensor still detects it.
+func (mctx *MonitorContext) AcknowledgeLeak(ctx context.Context, id uuid.UUID, userID uuid.UUID, note string) (database.Leak, error) {
+	leak, err := mctx.store.GetLeak(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return leak, ErrLeakNotFound
+	} else if err != nil {
+		return leak, fmt.Errorf("failed to read the leak: %w", err)
+	}
+
+	if !leak.ClearedAt.Valid {
+		// a leak stays open while its sensor is wet, one left open by a sensor that is dry or no longer
+		// configured would otherwise latch the alarm for good
+		mctx.Lock()
+		ls, ok := mctx.leakSensors[leak.SensorID]
+		mctx.Unlock()
+
+		if ok && ls.detected {
+			return leak, ErrLeakActive
+		}
+
+		leak, err = mctx.store.ClearDetectedLeak(ctx, id)
+		if err != nil {
+			return leak, fmt.Errorf("failed to clear the leak: %w", err)
+		}
+	}
+
+	if leak.AcknowledgedAt.Valid {
+		return leak, ErrLeakAcknowledged
+	}
+
+	leak, err = mctx.store.AcknowledgeLeak(ctx, database.AcknowledgeLeakParams{
+		ID:                  id,
+		AcknowledgedAt:      sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		AcknowledgedBy:      uuid.NullUUID{UUID: userID, Valid: true},
+		AcknowledgementNote: sql.NullString{String: note, Valid: len(note) != 0},
+	})
+	if err != nil {
+		return leak, fmt.Errorf("failed to acknowledge the leak: %w", err)
+	}
+
+	mctx.refreshLeakAlarm(ctx)
+
+	mctx.NotifyCh <- notification.Event{
+		Type:     notification.TYPE_LEAK,
+		Severity: notification.SEVERITY_INFO,
+		Source:   SOURCE_LEAK,
+		Message:  "The leak was acknowledged.",
+		Payload:  map[string]any{"leak_id": id.String(), "note": note},
+		Key:      id.String() + "/acknowledged",
+	}
+
+	return leak, nil
+}
+
+// clearSensorLeaks clears the open leaks of a sensor that reads dry.
+func (mctx *MonitorContext) clearSensorLeaks(ctx context.Context, sensorID string) {
+	leaks, err := mctx.store.ClearSensorLeaks(ctx, sensorID)
+	if err != nil {
+		slog.Error("failed to clear the open leaks of the sensor", "id", sensorID, "error", err)
+		return
+	}
+
+	for _, leak := range leaks {
+		slog.Info("cleared a leak that was open when the server started", "id", sensorID, "leak_id", leak.ID)
+	}
+}
+
+// refreshLeakAlarm latches the alarm for each zone with an unacknowledged leak.
+// The alarm is left as it is if the leaks can't be read so a database error can't release it.
+func (mctx *MonitorContext) refreshLeakAlarm(ctx context.Context) {
+	leaks, err := mctx.store.GetUnacknowledgedLeaks(ctx)
+	if err != nil {
+		slog.Error("failed to read the unacknowledged leaks", "error", err)
+		return
+	}
+
+	unacknowledged := make(map[string]bool, len(leaks))
+	for _, leak := range leaks {
+		unacknowledged[leak.Zone] = true
+	}
+
+	mctx.Lock()
+	mctx.leakUnacknowledged = unacknowledged
+	mctx.Unlock()
+}
+
+// leakShutOff returns the devices a leak in the zone turns off, both the pump and ozone generator unless
+// the zone was configured. The caller must hold the lock.
+func (mctx *MonitorContext) leakShutOff(zone string) []string {
+	if shutOff, ok := mctx.leakZones[zone]; ok {
+		return shutOff
+	}
+
+	return []string{interlock.DEVICE_PUMP, interlock.DEVICE_OZONE}
+}
+
+// leakInterlockZones returns the zones with a latched or detected leak and the devices the leak shuts off.
+// The caller must hold the lock.
+func (mctx *MonitorContext) leakInterlockZones() (detected []interlock.LeakZone, unacknowledged []interlock.LeakZone) {
+	seen := make(map[string]bool)
+	for _, ls := range mctx.leakSensors {
+		if ls.detected && !seen[ls.zone] {
+			seen[ls.zone] = true
+			detected = append(detected, interlock.LeakZone{Name: ls.zone, ShutOff: mctx.leakShutOff(ls.zone)})
+		}
+	}
+
+	for zone := range mctx.leakUnacknowledged {
+		unacknowledged = append(unacknowledged, interlock.LeakZone{Name: zone, ShutOff: mctx.leakShutOff(zone)})
+	}
+
+	return detected, unacknowledged
+}
+
+// leakDetectedMessage describes the leak and the devices that are being turned off because of it.
+func leakDetectedMessage(reading sensor.LeakReading, shutOff []string) string {
+	name := reading.Name
+	if len(name) == 0 {
+		name = reading.ID
+	}
+
+	message := fmt.Sprintf("Leak detected by %s in the %s zone!!", name, reading.Zone)
+
+	devices := make([]string, 0, len(shutOff))
+	for _, device := range shutOff {
+		switch device {
+		case interlock.DEVICE_PUMP:
+			devices = append(devices, "pump")
+		case interlock.DEVICE_OZONE:
+			devices = append(devices, "ozone generator")
+		}
+	}
+
+	if len(devices) != 0 {
+		message += fmt.Sprintf(" Turning off the %s.", strings.Join(devices, " and "))
+	}
+
+	if slices.Contains(shutOff, interlock.DEVICE_PUMP) {
+		message += " The leak must be acknowledged before the pump can run again."
+	}
+
+	return message
+}
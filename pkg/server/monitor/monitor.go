@@ -3,34 +3,59 @@ package monitor
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/KyleBrandon/plunger-server/config"
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/debounce"
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
-	"github.com/nikoksr/notify"
 )
 
 // InitializeMonitorContext will initialize a new MonitorSync struct.
-func InitializeMonitorContext(notifier *notify.Notify, store MonitorStore, sensors sensor.Sensors) *MonitorContext {
+func InitializeMonitorContext(notifier Notifier, store MonitorStore, sensors sensor.Sensors, settings config.Config) *MonitorContext {
 	slog.Debug(">>InitializeMonitorContext")
 	defer slog.Debug("<<InitializeMonitorContext")
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 
+	ozoneDuration := settings.OzoneDuration
+	if ozoneDuration <= 0 {
+		ozoneDuration = config.DefaultOzoneRunDuration
+	}
+
 	mctx := MonitorContext{
-		wg:                &wg,
-		ctx:               ctx,
-		store:             store,
-		sensors:           sensors,
-		monitorCancelFunc: cancel,
-		OzoneCh:           make(chan OzoneTask),
-		NotifyCh:          make(chan NotificationTask),
-		notifier:          notifier,
-		TempMonitorCh:     make(chan TemperatureTask),
+		wg:                 &wg,
+		ctx:                ctx,
+		store:              store,
+		sensors:            sensors,
+		monitorCancelFunc:  cancel,
+		OzoneCh:            make(chan OzoneTask),
+		ozoneDuration:      ozoneDuration,
+		NotifyCh:           make(chan notification.Event),
+		notifier:           notifier,
+		outboxCh:           make(chan struct{}, 1),
+		retention:          settings.Retention,
+		ThermostatCh:       make(chan struct{}, 1),
+		thermostatDevice:   settings.Thermostat.Device,
+		thermostatSettings: settings.Thermostat.Settings,
+		alertReadings:      make(map[string]alerts.Reading),
+		leakSensors:        make(map[string]leakSensor),
+		leakZones:          make(map[string][]string),
+		leakUnacknowledged: make(map[string]bool),
+		leakDebounceCount:  settings.LeakDetection.DebounceReadings,
+		leakDebounceWindow: time.Duration(settings.LeakDetection.DebounceSeconds) * time.Second,
+	}
+
+	for _, zone := range settings.LeakDetection.Zones {
+		mctx.leakZones[zone.Name] = zone.ShutOff
 	}
 
 	mctx.startMonitorRoutines()
@@ -52,6 +77,9 @@ func (mctx *MonitorContext) startMonitorRoutines() {
 	mctx.wg.Add(1)
 	go mctx.monitorNotifications()
 
+	mctx.wg.Add(1)
+	go mctx.monitorOutbox()
+
 	mctx.wg.Add(1)
 	go mctx.monitorTemperatures()
 
@@ -60,6 +88,12 @@ func (mctx *MonitorContext) startMonitorRoutines() {
 
 	mctx.wg.Add(1)
 	go mctx.monitorLeaks()
+
+	mctx.wg.Add(1)
+	go mctx.monitorRetention()
+
+	mctx.wg.Add(1)
+	go mctx.monitorPlunge()
 }
 
 func (mctx *MonitorContext) monitorOzone() {
@@ -69,9 +103,15 @@ func (mctx *MonitorContext) monitorOzone() {
 	// close the waitgroup when the routine exits
 	defer mctx.wg.Done()
 
-	// start and stop with the ozone off
-	mctx.sensors.TurnOzoneOff()
-	defer mctx.sensors.TurnOzoneOff()
+	// start and stop with the ozone off, use a fresh context so shutting down can't skip turning it off
+	mctx.sensors.TurnOzoneOff(mctx.ctx)
+	defer mctx.sensors.TurnOzoneOff(context.Background())
+
+	scheduleTicker := time.NewTicker(OZONE_SCHEDULE_INTERVAL)
+	defer scheduleTicker.Stop()
+
+	// schedules are checked once per minute, starting with the minute after the monitor started
+	lastMinute := time.Now().Truncate(time.Minute)
 
 	for {
 		select {
@@ -79,6 +119,13 @@ func (mctx *MonitorContext) monitorOzone() {
 			slog.Debug("monitorOzone: context done")
 			return
 
+		case <-scheduleTicker.C:
+			minute := time.Now().Truncate(time.Minute)
+			if minute.After(lastMinute) {
+				lastMinute = minute
+				mctx.runOzoneSchedules(minute)
+			}
+
 		case task, ok := <-mctx.OzoneCh:
 			if !ok {
 				slog.Error("The ozone notification channel was closed")
@@ -89,7 +136,14 @@ func (mctx *MonitorContext) monitorOzone() {
 			switch task.Action {
 			case OZONEACTION_START:
 				slog.Debug("OZONEACTION_START")
-				mctx.startOzoneGenerator(task.Duration)
+				duration := task.Duration
+				if duration <= 0 {
+					duration = int(mctx.ozoneDuration.Minutes())
+				}
+
+				if err := mctx.startOzoneGenerator(duration); err != nil {
+					slog.Warn("failed to start the ozone generator", "error", err)
+				}
 
 			case OZONEACTION_STOP:
 				// cancel the ozone generator
@@ -105,17 +159,21 @@ func (mctx *MonitorContext) monitorOzone() {
 	}
 }
 
-func (mctx *MonitorContext) startOzoneGenerator(duration int) {
+func (mctx *MonitorContext) startOzoneGenerator(duration int) error {
 	slog.Debug(">>startOzoneGenerator")
 	defer slog.Debug("<<startOzoneGenerator")
 
+	err := mctx.CheckAction(mctx.ctx, interlock.ACTION_OZONE_START)
+	if err != nil {
+		return err
+	}
+
 	mctx.Lock()
 	defer mctx.Unlock()
 
 	// is the ozone generator already running?
 	if mctx.OzoneRunning {
-		slog.Warn("ozone is already running")
-		return
+		return ErrOzoneRunning
 	}
 
 	startTime := sql.NullTime{
@@ -128,16 +186,16 @@ func (mctx *MonitorContext) startOzoneGenerator(duration int) {
 		ExpectedDuration: int32(duration),
 	}
 
-	_, err := mctx.store.StartOzoneGenerator(mctx.ctx, args)
+	ozone, err := mctx.store.StartOzoneGenerator(mctx.ctx, args)
 	if err != nil {
 		slog.Error("failed to update database with ozone start", "error", err)
-		return
+		return err
 	}
 
-	err = mctx.sensors.TurnOzoneOn()
+	err = mctx.sensors.TurnOzoneOn(mctx.ctx)
 	if err != nil {
 		mctx.setOzoneErrorMessage(mctx.ctx, "failed to turn on ozone generator", err)
-		return
+		return err
 	}
 
 	// create a context for the ozone goroutine with a hard timeout
@@ -154,15 +212,24 @@ func (mctx *MonitorContext) startOzoneGenerator(duration int) {
 		mctx.stopOzoneGenerator()
 	}()
 
-	mctx.NotifyCh <- NotificationTask{Message: "Ozone generator was started"}
+	mctx.NotifyCh <- notification.Event{
+		Type:     notification.TYPE_OZONE,
+		Severity: notification.SEVERITY_INFO,
+		Source:   SOURCE_OZONE,
+		Message:  "Ozone generator was started",
+		Payload:  map[string]any{"duration_minutes": duration},
+		Key:      ozone.ID.String() + "/started",
+	}
+
+	return nil
 }
 
 func (mctx *MonitorContext) stopOzoneGenerator() error {
 	slog.Debug(">>stopOzoneGenerator")
 	defer slog.Debug("<<stopOzoneGenerator")
 
-	// turn ozone off no matter what
-	err := mctx.sensors.TurnOzoneOff()
+	// turn ozone off no matter what, even if the monitor is shutting down
+	err := mctx.sensors.TurnOzoneOff(context.Background())
 	if err != nil {
 		mctx.setOzoneErrorMessage(mctx.ctx, "failed to turn off ozone generator", err)
 		return err
@@ -185,7 +252,13 @@ func (mctx *MonitorContext) stopOzoneGenerator() error {
 		return err
 	}
 
-	mctx.NotifyCh <- NotificationTask{Message: "Ozone generator was stopped"}
+	mctx.NotifyCh <- notification.Event{
+		Type:     notification.TYPE_OZONE,
+		Severity: notification.SEVERITY_INFO,
+		Source:   SOURCE_OZONE,
+		Message:  "Ozone generator was stopped",
+		Key:      ozone.ID.String() + "/stopped",
+	}
 
 	return nil
 }
@@ -213,7 +286,13 @@ func (mctx *MonitorContext) setOzoneErrorMessage(ctx context.Context, statusMess
 		return err
 	}
 
-	mctx.NotifyCh <- NotificationTask{Message: statusMessage}
+	mctx.NotifyCh <- notification.Event{
+		Type:     notification.TYPE_OZONE,
+		Severity: notification.SEVERITY_WARNING,
+		Source:   SOURCE_OZONE,
+		Message:  statusMessage,
+		Key:      ozone.ID.String() + "/failed",
+	}
 
 	return nil
 }
@@ -224,6 +303,9 @@ func (mctx *MonitorContext) monitorTemperatures() {
 
 	defer mctx.wg.Done()
 
+	mctx.loadThermostat()
+	defer mctx.stopThermostat()
+
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -234,97 +316,182 @@ func (mctx *MonitorContext) monitorTemperatures() {
 			return
 
 		case <-ticker.C:
-			rt, wt := mctx.sensors.ReadRoomAndWaterTemperature()
-			if rt.Err != nil {
-				slog.Error("failed to read the room temperature", "error", rt.Err)
+			readings := mctx.sensors.ReadTemperatures(mctx.ctx)
+			for _, r := range readings {
+				if r.Err != nil {
+					slog.Error("failed to read the temperature", "name", r.Name, "role", r.Role, "error", r.Err)
+				}
 			}
 
-			if wt.Err != nil {
-				slog.Error("failed to read the water temperature", "error", wt.Err)
-			}
+			mctx.saveCurrentTemperatures(readings)
 
-			mctx.saveCurrentTemperatures(rt, wt)
+			rt, _ := sensor.FindReading(readings, sensor.ROLE_ROOM)
+			wt, wtFound := sensor.FindReading(readings, sensor.ROLE_WATER)
 
 			mctx.Lock()
 			mctx.WaterTemperature = wt.TemperatureF
 			mctx.RoomTemperature = rt.TemperatureF
-			// are we monitoring for a target temperature?
-			if mctx.temperatureMonitoring {
-				lowRange := mctx.TargetTemperature - 0.5
-				highRange := mctx.TargetTemperature + 0.5
-				if wt.TemperatureF >= lowRange && wt.TemperatureF <= highRange {
-					slog.Debug("Temperature in range", "target", mctx.TargetTemperature, "lowRange", lowRange, "highRange", highRange)
-					// notify  the user
-					mctx.NotifyCh <- NotificationTask{Message: fmt.Sprintf("Target temperature %v was reached", mctx.TargetTemperature)}
-					mctx.temperatureMonitoring = false
-				}
-
-			}
+			mctx.waterValid = wtFound && wt.Err == nil
 			mctx.Unlock()
 
-		case task, ok := <-mctx.TempMonitorCh:
-			slog.Info("Received temperure task")
-			if !ok {
-				slog.Error("The temperature notification channel was closed")
-				return
-			}
+			mctx.runThermostat()
+			mctx.evaluateAlertRules(readings)
 
-			slog.Debug("Monitor temperature", "temperature", task.TargetTemperature)
-			mctx.Lock()
-			mctx.TargetTemperature = task.TargetTemperature
-			mctx.temperatureMonitoring = true
-			mctx.Unlock()
+		case <-mctx.ThermostatCh:
+			slog.Debug("thermostat settings changed")
+			mctx.runThermostat()
 		}
 	}
 }
 
-func (mctx *MonitorContext) saveCurrentTemperatures(rt sensor.TemperatureReading, wt sensor.TemperatureReading) {
-	waterTemp := sql.NullString{
-		Valid: false,
+// saveCurrentTemperatures will persist every successful reading with a shared timestamp.
+func (mctx *MonitorContext) saveCurrentTemperatures(readings []sensor.TemperatureReading) {
+	readAt := time.Now().UTC()
+
+	for _, r := range readings {
+		if r.Err != nil {
+			continue
+		}
+
+		arg := database.SaveTemperatureReadingParams{
+			ReadAt:        readAt,
+			DeviceAddress: r.Address,
+			DeviceName:    r.Name,
+			DeviceRole:    r.Role,
+			TemperatureC:  fmt.Sprintf("%f", r.TemperatureC),
+			TemperatureF:  fmt.Sprintf("%f", r.TemperatureF),
+		}
+
+		_, err := mctx.store.SaveTemperatureReading(mctx.ctx, arg)
+		if err != nil {
+			slog.Error("failed to save the temperature reading", "name", r.Name, "error", err)
+		}
 	}
-	roomTemp := sql.NullString{
-		Valid: false,
+}
+
+// monitorRetention will periodically roll up and prune the temperature history.
+func (mctx *MonitorContext) monitorRetention() {
+	slog.Debug(">>monitorRetention")
+	defer slog.Debug("<<monitorRetention")
+
+	defer mctx.wg.Done()
+
+	// catch up on anything that aged out while the server was down
+	mctx.applyRetention(time.Now().UTC())
+
+	ticker := time.NewTicker(RETENTION_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-mctx.ctx.Done():
+			slog.Debug("monitorRetention: context done")
+			return
+
+		case <-ticker.C:
+			mctx.applyRetention(time.Now().UTC())
+		}
 	}
+}
+
+// applyRetention rolls the raw readings older than the raw retention into hourly aggregates, deletes
+// the aggregates older than the rollup retention and records what was pruned.
+// A negative retention setting disables that stage.
+func (mctx *MonitorContext) applyRetention(now time.Time) {
+	slog.Debug(">>applyRetention")
+	defer slog.Debug("<<applyRetention")
+
+	arg := database.CreateRetentionRunParams{}
+	var errs []error
+
+	if mctx.retention.RawDays > 0 {
+		// cut on an hour boundary so an hourly bucket is only ever rolled up once
+		arg.RawCutoff = now.AddDate(0, 0, -mctx.retention.RawDays).Truncate(time.Hour)
 
-	if rt.Err == nil {
-		roomTemp.Valid = true
-		roomTemp.String = fmt.Sprintf("%f", rt.TemperatureF)
+		rolled, err := mctx.store.RollupTemperatureReadings(mctx.ctx, arg.RawCutoff)
+		if err != nil {
+			slog.Error("failed to roll up the temperature readings", "cutoff", arg.RawCutoff, "error", err)
+			errs = append(errs, err)
+		}
+
+		arg.ReadingsRolledUp = rolled.ReadingsRolledUp
+		arg.RollupsWritten = rolled.RollupsWritten
 	}
 
-	if wt.Err == nil {
-		waterTemp.Valid = true
-		waterTemp.String = fmt.Sprintf("%f", wt.TemperatureF)
+	if mctx.retention.RollupDays > 0 {
+		arg.RollupCutoff = now.AddDate(0, 0, -mctx.retention.RollupDays).Truncate(time.Hour)
+
+		pruned, err := mctx.store.PruneTemperatureRollups(mctx.ctx, arg.RollupCutoff)
+		if err != nil {
+			slog.Error("failed to prune the temperature rollups", "cutoff", arg.RollupCutoff, "error", err)
+			errs = append(errs, err)
+		}
+
+		arg.RollupsPruned = pruned
 	}
 
-	arg := database.SaveTemperatureParams{
-		WaterTemp: waterTemp,
-		RoomTemp:  roomTemp,
+	if err := errors.Join(errs...); err != nil {
+		arg.ErrorMessage = sql.NullString{Valid: true, String: truncate(err.Error(), 255)}
 	}
-	_, err := mctx.store.SaveTemperature(mctx.ctx, arg)
+
+	slog.Info("applied temperature retention",
+		"raw_cutoff", arg.RawCutoff,
+		"rollup_cutoff", arg.RollupCutoff,
+		"readings_rolled_up", arg.ReadingsRolledUp,
+		"rollups_written", arg.RollupsWritten,
+		"rollups_pruned", arg.RollupsPruned)
+
+	_, err := mctx.store.CreateRetentionRun(mctx.ctx, arg)
 	if err != nil {
-		slog.Error("failed to save the room and water temperatures", "error", err)
+		slog.Error("failed to record the retention run", "error", err)
 	}
 }
 
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	return s[:length]
+}
+
 func (mctx *MonitorContext) monitorLeaks() {
 	slog.Debug(">>monitorLeaks")
 	defer slog.Debug("<<monitorLeaks")
 
 	defer mctx.wg.Done()
 
-	// take an initial reading of the leak sensor so we can detect transitions from true/false
-	prevLeakReading, err := mctx.sensors.IsLeakPresent()
-	if err != nil {
-		slog.Warn("failed to read sensor to determine if a leak is present", "error", err)
+	// take an initial reading of the leak sensors so we can detect transitions from true/false
+	readings := mctx.sensors.ReadLeakSensors(mctx.ctx)
+
+	// condensation can make a sensor flap, so the leak state only changes once the readings agree
+	debouncers := make(map[string]*debounce.Debouncer, len(readings))
+
+	mctx.Lock()
+	for _, r := range readings {
+		if r.Err != nil {
+			slog.Warn("failed to read sensor to determine if a leak is present", "id", r.ID, "error", r.Err)
+		}
+
+		mctx.leakSensors[r.ID] = leakSensor{id: r.ID, zone: r.Zone, detected: r.LeakPresent}
+		debouncers[r.ID] = debounce.New(r.LeakPresent, mctx.leakDebounceCount, mctx.leakDebounceWindow)
 	}
+	mctx.Unlock()
 
-	notifyLeakDetected := true
+	// a leak that was open when the server stopped is cleared if its sensor is dry now, it still has to be acknowledged
+	for _, r := range readings {
+		if r.Err == nil && !r.LeakPresent {
+			mctx.clearSensorLeaks(mctx.ctx, r.ID)
+		}
+	}
+
+	// a leak that wasn't acknowledged before the server stopped keeps the alarm latched
+	mctx.refreshLeakAlarm(mctx.ctx)
 
 	// if there is a leak present at start create a leak entry
-	if prevLeakReading {
-		_, err := mctx.store.CreateLeakDetected(mctx.ctx, time.Now().UTC())
-		if err != nil {
-			slog.Error("failed to mctx.store leak detection in database", "error", err)
+	for _, r := range readings {
+		if r.LeakPresent {
+			mctx.processLeakReading(mctx.ctx, r, true)
 		}
 	}
 
@@ -341,65 +508,55 @@ func (mctx *MonitorContext) monitorLeaks() {
 
 		case <-ticker.C:
 
-			currentLeakReading, err := mctx.sensors.IsLeakPresent()
-			if err != nil {
-				slog.Warn("failed to read if leak was present", "error", err)
-			}
-
-			// have we had a change since we last read the sensor?
-			if prevLeakReading != currentLeakReading {
-				mctx.processLeakReading(mctx.ctx, currentLeakReading)
-
-				prevLeakReading = currentLeakReading
-			}
-
-			// if a leak was detected, then turn the pump off
-			if currentLeakReading {
-				if notifyLeakDetected {
-					mctx.NotifyCh <- NotificationTask{Message: "Leak detected!! Turning off pump."}
-					notifyLeakDetected = false
+			for _, r := range mctx.sensors.ReadLeakSensors(mctx.ctx) {
+				debouncer, ok := debouncers[r.ID]
+				if !ok || r.Err != nil {
+					// keep the debounced state rather than treat a failed read as dry
+					continue
 				}
 
-				err = mctx.sensors.TurnPumpOff()
-				if err != nil {
-					slog.Error("failed to turn pump off while leak detected", "error", err)
-					mctx.NotifyCh <- NotificationTask{Message: "Leak detected!! Failed to turn off pump."}
+				leakDetected, changed := debouncer.Update(r.LeakPresent, time.Now())
+				if !changed {
+					continue
 				}
-			} else {
-				// make sure to notify if a leak is detected
-				notifyLeakDetected = true
+
+				mctx.Lock()
+				ls := mctx.leakSensors[r.ID]
+				ls.detected = leakDetected
+				mctx.leakSensors[r.ID] = ls
+				mctx.Unlock()
+
+				mctx.processLeakReading(mctx.ctx, r, leakDetected)
 			}
+
+			// a leak turns off the devices its zone shuts off, and the ozone is stopped if the pump was turned off
+			mctx.enforceInterlocks()
 		}
 	}
 }
 
+// monitorNotifications queues each event in the outbox, delivering them is left to monitorOutbox so a slow
+// channel can't block the routines that send on NotifyCh.
 func (mctx *MonitorContext) monitorNotifications() {
 	slog.Debug(">>monitorNotifications")
 	defer slog.Debug("<<monitorNotifications")
 
 	defer mctx.wg.Done()
+
 	for {
 		select {
 		case <-mctx.ctx.Done():
 			slog.Debug("monitorNotifications: context done")
 			return
 
-		case task, ok := <-mctx.NotifyCh:
+		case event, ok := <-mctx.NotifyCh:
 			if !ok {
 				slog.Error("The notification channel was closed")
 				return
 			}
 
-			// Send the SMS
 			if mctx.notifier != nil {
-				err := mctx.notifier.Send(
-					context.Background(),
-					"Plunger Notification",
-					task.Message,
-				)
-				if err != nil {
-					slog.Error("failed to send message", "error", err, "message", task.Message)
-				}
+				mctx.queueNotification(event)
 			} else {
 				slog.Warn("Notifier is not registered for notifications")
 			}
@@ -408,35 +565,134 @@ func (mctx *MonitorContext) monitorNotifications() {
 	}
 }
 
-func (mctx *MonitorContext) processLeakReading(ctx context.Context, leakDetected bool) error {
-	var leak database.Leak
-	var err error
+// monitorOutbox delivers the notifications in the outbox when one is queued and retries the failed ones.
+func (mctx *MonitorContext) monitorOutbox() {
+	slog.Debug(">>monitorOutbox")
+	defer slog.Debug("<<monitorOutbox")
+
+	defer mctx.wg.Done()
+
+	if mctx.notifier == nil {
+		return
+	}
+
+	outboxTicker := time.NewTicker(OUTBOX_INTERVAL)
+	defer outboxTicker.Stop()
+
+	for {
+		select {
+		case <-mctx.ctx.Done():
+			slog.Debug("monitorOutbox: context done")
+			return
+
+		case <-outboxTicker.C:
+			mctx.deliverPendingNotifications()
+
+		case <-mctx.outboxCh:
+			mctx.deliverPendingNotifications()
+		}
+	}
+}
+
+// subscribers returns the users that subscribed to the event's type and enabled SMS or email.
+// Muted users are skipped unless the event is critical.
+func (mctx *MonitorContext) subscribers(event notification.Event) []notification.Recipient {
+	users, err := mctx.store.GetSubscribedUsers(mctx.ctx, event.Type)
+	if err != nil {
+		slog.Error("failed to read the users subscribed to the event", "type", event.Type, "error", err)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	recipients := make([]notification.Recipient, 0, len(users))
+	for _, user := range users {
+		if user.MutedUntil.Valid && user.MutedUntil.Time.After(now) && event.Severity != notification.SEVERITY_CRITICAL {
+			continue
+		}
+
+		recipient := notification.Recipient{Name: user.Email}
+		if user.NotifySms && user.PhoneNumber.Valid {
+			recipient.PhoneNumber = user.PhoneNumber.String
+		}
+
+		if user.NotifyEmail {
+			recipient.Email = user.Email
+		}
+
+		if len(recipient.PhoneNumber) != 0 || len(recipient.Email) != 0 {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	return recipients
+}
+
+// processLeakReading records a change in the debounced state of a leak sensor and notifies the users.
+func (mctx *MonitorContext) processLeakReading(ctx context.Context, reading sensor.LeakReading, leakDetected bool) error {
+	payload := map[string]any{"sensor_id": reading.ID, "zone": reading.Zone}
 
 	// if a leak was detected then create a new record to track it
 	if leakDetected {
-		leak, err = mctx.store.CreateLeakDetected(ctx, time.Now().UTC())
-		if err != nil {
-			slog.Error("failed to mctx.store leak detection in database", "error", err)
-			// TODO: we should have alternative means of reporting this
+		// latch the alarm even if the leak can't be stored, the devices must stay off until someone looks at it
+		mctx.Lock()
+		mctx.leakUnacknowledged[reading.Zone] = true
+		shutOff := mctx.leakShutOff(reading.Zone)
+		mctx.Unlock()
+
+		// a leak that was still open when the server stopped is continued rather than recorded twice
+		leak, err := mctx.store.GetActiveLeak(ctx, reading.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			leak, err = mctx.store.CreateLeakDetected(ctx, database.CreateLeakDetectedParams{
+				DetectedAt: time.Now().UTC(),
+				SensorID:   reading.ID,
+				Zone:       reading.Zone,
+			})
 		}
-	} else {
-		// if there is currently no leak, see if we need to report it being cleared
-		leak, err = mctx.store.GetLatestLeakDetected(mctx.ctx)
+
 		if err != nil {
-			slog.Warn("failed to read the latest leak from the database, create a new entry", "error", err)
-			return err
+			slog.Error("failed to store the leak detection in the database", "id", reading.ID, "error", err)
+			// TODO: we should have alternative means of reporting this
+		} else {
+			payload["leak_id"] = leak.ID.String()
 		}
 
-		// the entry's cleared_at should not be set
-		if !leak.ClearedAt.Valid {
-			leak, err = mctx.store.ClearDetectedLeak(ctx, leak.ID)
-			if err != nil {
-				slog.Error("failed to clear detected leak in database", "error", err)
-			}
-		} else {
-			// we think there should be a leak that was cleared but the database already has a cleared
-			slog.Warn("inconsistent database state, we think there should be a leak that we are clearing")
+		mctx.NotifyCh <- notification.Event{
+			Type:     notification.TYPE_LEAK,
+			Severity: notification.SEVERITY_CRITICAL,
+			Source:   SOURCE_LEAK,
+			Message:  leakDetectedMessage(reading, shutOff),
+			Payload:  payload,
+			Key:      reading.ID + "/detected",
 		}
+
+		return err
+	}
+
+	// if there is currently no leak, see if we need to report it being cleared
+	leak, err := mctx.store.GetActiveLeak(ctx, reading.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// we think there should be a leak that we are clearing but the database doesn't have one open
+		slog.Warn("inconsistent database state, there is no open leak for the sensor", "id", reading.ID)
+		return nil
+	} else if err != nil {
+		slog.Warn("failed to read the open leak from the database", "id", reading.ID, "error", err)
+		return err
+	}
+
+	leak, err = mctx.store.ClearDetectedLeak(ctx, leak.ID)
+	if err != nil {
+		slog.Error("failed to clear detected leak in database", "error", err)
+		return err
+	}
+
+	payload["leak_id"] = leak.ID.String()
+	mctx.NotifyCh <- notification.Event{
+		Type:     notification.TYPE_LEAK,
+		Severity: notification.SEVERITY_WARNING,
+		Source:   SOURCE_LEAK,
+		Message:  fmt.Sprintf("The leak in the %s zone has cleared. Acknowledge it to clear the alarm.", reading.Zone),
+		Payload:  payload,
+		Key:      reading.ID + "/cleared",
 	}
 
 	return nil
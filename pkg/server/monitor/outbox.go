@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+)
+
+// queueNotification stores a delivery in the outbox for each target of the event and wakes monitorOutbox to send them.
+func (mctx *MonitorContext) queueNotification(event notification.Event) {
+	event, targets, err := mctx.notifier.Targets(event, mctx.subscribers(event)...)
+	if errors.Is(err, notification.ErrSuppressed) {
+		slog.Info("notification was not sent", "reason", err, "type", event.Type, "message", event.Message)
+		return
+	}
+
+	if err != nil {
+		slog.Error("some subscribers can't be notified", "error", err, "type", event.Type)
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil || event.Payload == nil {
+		payload = []byte("{}")
+	}
+
+	now := time.Now().UTC()
+	for _, target := range targets {
+		entry, err := mctx.store.CreateNotification(mctx.ctx, database.CreateNotificationParams{
+			EventType:     event.Type,
+			Severity:      event.Severity,
+			Source:        event.Source,
+			Title:         event.Title,
+			Message:       event.Message,
+			Payload:       payload,
+			OccurredAt:    event.OccurredAt,
+			Channel:       target.Channel,
+			PhoneNumber:   sql.NullString{String: target.PhoneNumber, Valid: len(target.PhoneNumber) != 0},
+			Email:         sql.NullString{String: target.Email, Valid: len(target.Email) != 0},
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			// the delivery can't be retried without the outbox, so try it once rather than drop it
+			slog.Error("failed to store the notification in the outbox", "error", err, "channel", target.Channel)
+
+			mctx.wg.Add(1)
+			go func() {
+				defer mctx.wg.Done()
+
+				err := mctx.notifier.Deliver(mctx.ctx, event, target)
+				if err != nil {
+					slog.Error("failed to send message", "error", err, "message", event.Message)
+				}
+			}()
+			continue
+		}
+
+		slog.Debug("queued the notification", "id", entry.ID, "channel", target.Channel)
+	}
+
+	mctx.wakeOutbox()
+}
+
+// wakeOutbox asks monitorOutbox to deliver the pending notifications, a wake that is already waiting covers this one.
+func (mctx *MonitorContext) wakeOutbox() {
+	select {
+	case mctx.outboxCh <- struct{}{}:
+	default:
+	}
+}
+
+// deliverPendingNotifications sends the pending notifications whose next attempt is due.
+// A full batch wakes the outbox again so the rest don't wait for the next check.
+func (mctx *MonitorContext) deliverPendingNotifications() {
+	entries, err := mctx.store.GetPendingNotifications(mctx.ctx, database.GetPendingNotificationsParams{
+		NextAttemptAt: time.Now().UTC(),
+		Limit:         OUTBOX_BATCH_SIZE,
+	})
+	if err != nil {
+		slog.Error("failed to read the pending notifications", "error", err)
+		return
+	}
+
+	for _, entry := range entries {
+		mctx.deliverNotification(entry)
+	}
+
+	if len(entries) == OUTBOX_BATCH_SIZE {
+		mctx.wakeOutbox()
+	}
+}
+
+// deliverNotification sends an outbox entry and records the result. A failed entry is retried with a backoff
+// until it has been attempted OUTBOX_MAX_ATTEMPTS times.
+func (mctx *MonitorContext) deliverNotification(entry database.NotificationOutbox) {
+	event, target := outboxEntryToEvent(entry)
+
+	deliveryErr := mctx.notifier.Deliver(mctx.ctx, event, target)
+	now := time.Now().UTC()
+
+	if deliveryErr == nil {
+		_, err := mctx.store.MarkNotificationSent(mctx.ctx, database.MarkNotificationSentParams{
+			ID:     entry.ID,
+			SentAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			slog.Error("failed to record the notification as sent", "id", entry.ID, "error", err)
+		}
+		return
+	}
+
+	attempts := entry.Attempts + 1
+	status := NOTIFICATIONSTATUS_PENDING
+	if attempts >= OUTBOX_MAX_ATTEMPTS {
+		status = NOTIFICATIONSTATUS_FAILED
+		slog.Error("failed to send message, giving up", "id", entry.ID, "channel", entry.Channel, "attempts", attempts, "error", deliveryErr)
+	} else {
+		slog.Warn("failed to send message, will retry", "id", entry.ID, "channel", entry.Channel, "attempts", attempts, "error", deliveryErr)
+	}
+
+	_, err := mctx.store.MarkNotificationFailed(mctx.ctx, database.MarkNotificationFailedParams{
+		ID:            entry.ID,
+		Status:        status,
+		NextAttemptAt: now.Add(retryDelay(attempts)),
+		LastError:     sql.NullString{String: deliveryErr.Error(), Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to record the notification failure", "id", entry.ID, "error", err)
+	}
+}
+
+// retryDelay returns how long to wait after the number of failed attempts.
+func retryDelay(attempts int32) time.Duration {
+	delay := OUTBOX_RETRY_DELAY
+	for i := int32(1); i < attempts && delay < OUTBOX_MAX_RETRY_DELAY; i++ {
+		delay *= 2
+	}
+
+	return min(delay, OUTBOX_MAX_RETRY_DELAY)
+}
+
+func outboxEntryToEvent(entry database.NotificationOutbox) (notification.Event, notification.Target) {
+	event := notification.Event{
+		Type:       entry.EventType,
+		Severity:   entry.Severity,
+		Source:     entry.Source,
+		Title:      entry.Title,
+		Message:    entry.Message,
+		OccurredAt: entry.OccurredAt,
+	}
+
+	err := json.Unmarshal(entry.Payload, &event.Payload)
+	if err != nil {
+		slog.Warn("failed to read the notification payload", "id", entry.ID, "error", err)
+	}
+
+	target := notification.Target{
+		Channel:     entry.Channel,
+		PhoneNumber: entry.PhoneNumber.String,
+		Email:       entry.Email.String,
+	}
+
+	return event, target
+}
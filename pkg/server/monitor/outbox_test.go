@@ -0,0 +1,29 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int32
+		expected time.Duration
+	}{
+		{name: "should wait the retry delay after the first failure", attempts: 1, expected: 30 * time.Second},
+		{name: "should double the delay after the second failure", attempts: 2, expected: time.Minute},
+		{name: "should keep doubling the delay", attempts: 5, expected: 8 * time.Minute},
+		{name: "should double up to the last delay under the maximum", attempts: 7, expected: 32 * time.Minute},
+		{name: "should wait at most the maximum delay", attempts: 8, expected: OUTBOX_MAX_RETRY_DELAY},
+		{name: "should not grow past the maximum delay", attempts: 30, expected: OUTBOX_MAX_RETRY_DELAY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if delay := retryDelay(tt.attempts); delay != tt.expected {
+				t.Errorf("expected a delay of %s after %d attempts, got %s", tt.expected, tt.attempts, delay)
+			}
+		})
+	}
+}
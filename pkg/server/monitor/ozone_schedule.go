@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/cron"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+)
+
+// runOzoneSchedules starts the ozone generator for every enabled schedule that is due at the given minute.
+// Schedules are matched against the local time of the server. A schedule that can't run is recorded with the reason it was skipped.
+func (mctx *MonitorContext) runOzoneSchedules(minute time.Time) {
+	slog.Debug(">>runOzoneSchedules")
+	defer slog.Debug("<<runOzoneSchedules")
+
+	schedules, err := mctx.store.GetEnabledOzoneSchedules(mctx.ctx)
+	if err != nil {
+		slog.Error("failed to read the ozone schedules", "error", err)
+		return
+	}
+
+	for _, s := range schedules {
+		schedule, err := cron.Parse(s.CronExpression)
+		if err != nil {
+			slog.Error("invalid ozone schedule", "schedule", s.Name, "cron_expression", s.CronExpression, "error", err)
+			continue
+		}
+
+		if !schedule.Matches(minute.Local()) {
+			continue
+		}
+
+		duration := int(s.DurationMinutes)
+		if duration <= 0 {
+			duration = int(mctx.ozoneDuration.Minutes())
+		}
+
+		// the run is skipped if the ozone is already running or the interlock rules refuse it,
+		// e.g. while a plunge is running, the pump is off or a leak is detected
+		var skipReason string
+		err = mctx.startOzoneGenerator(duration)
+		if err != nil {
+			skipReason = err.Error()
+		}
+
+		if len(skipReason) != 0 {
+			slog.Info("skipped the ozone schedule", "schedule", s.Name, "reason", skipReason)
+		}
+
+		arg := database.CreateOzoneScheduleRunParams{
+			ScheduleID:   s.ID,
+			ScheduledFor: minute.UTC(),
+			Started:      len(skipReason) == 0,
+			SkipReason:   sql.NullString{String: skipReason, Valid: len(skipReason) != 0},
+		}
+
+		_, err = mctx.store.CreateOzoneScheduleRun(mctx.ctx, arg)
+		if err != nil {
+			slog.Error("failed to save the ozone schedule run", "schedule", s.Name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package monitor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/google/uuid"
+)
+
+// StartPlungeTimer times the plunge so it is completed when its expected duration passes, and samples the
+// temperatures until it stops. A plunge without an expected duration runs until it is stopped.
+func (mctx *MonitorContext) StartPlungeTimer(p database.Plunge) {
+	if !p.Running || !p.StartTime.Valid {
+		return
+	}
+
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	mctx.plungeTimer = newPlungeTimer(p, time.Now())
+}
+
+// StopPlungeTimer stops timing the plunge after it was stopped by the user.
+// The phase that was running is recorded on the plunge as incomplete.
+func (mctx *MonitorContext) StopPlungeTimer(id uuid.UUID) {
+	mctx.Lock()
+	timer := mctx.plungeTimer
+	if timer == nil || timer.id != id {
+		mctx.Unlock()
+		return
+	}
+
+	mctx.plungeTimer = nil
+	mctx.Unlock()
+
+	if len(timer.phases) == 0 {
+		return
+	}
+
+	now := time.Now()
+	pos := protocol.At(timer.phases, timer.start, now)
+	mctx.recordPlungePhases(timer, timer.recorded, pos.Index)
+
+	if !pos.Done {
+		mctx.recordPlungePhase(timer.id, pos.Index, pos.Phase, pos.StartedAt, now, false)
+	}
+}
+
+// PlungePhase returns the phase of the running plunge, nil if it isn't following a protocol.
+func (mctx *MonitorContext) PlungePhase() *PlungePhaseStatus {
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	timer := mctx.plungeTimer
+	if timer == nil || len(timer.phases) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	pos := protocol.At(timer.phases, timer.start, now)
+	if pos.Done {
+		return nil
+	}
+
+	return &PlungePhaseStatus{
+		Number:    pos.Index + 1,
+		Count:     len(timer.phases),
+		Name:      pos.Phase.Name,
+		Kind:      pos.Phase.Kind,
+		Remaining: pos.EndsAt.Sub(now).Seconds(),
+	}
+}
+
+func (mctx *MonitorContext) monitorPlunge() {
+	slog.Debug(">>monitorPlunge")
+	defer slog.Debug("<<monitorPlunge")
+
+	defer mctx.wg.Done()
+
+	// a plunge that was running when the server stopped is completed or picked up where it left off
+	mctx.recoverPlunge(time.Now())
+
+	ticker := time.NewTicker(PLUNGE_TIMER_INTERVAL)
+	defer ticker.Stop()
+
+	sampleTicker := time.NewTicker(PLUNGE_SAMPLE_INTERVAL)
+	defer sampleTicker.Stop()
+
+	for {
+		select {
+		case <-mctx.ctx.Done():
+			slog.Debug("monitorPlunge: context done")
+			return
+
+		case now := <-ticker.C:
+			mctx.runPlungeTimer(now)
+
+		case now := <-sampleTicker.C:
+			mctx.Lock()
+			timer := mctx.plungeTimer
+			mctx.Unlock()
+
+			if timer != nil {
+				mctx.samplePlungeTemperatures(timer.id, now)
+			}
+		}
+	}
+}
+
+// recoverPlunge restarts the timer for the running plunge, or completes it at its expected end if that passed while
+// the server was down.
+func (mctx *MonitorContext) recoverPlunge(now time.Time) {
+	p, err := mctx.store.GetLatestPlunge(mctx.ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	} else if err != nil {
+		slog.Error("failed to read the latest plunge", "error", err)
+		return
+	}
+
+	if !p.Running || !p.StartTime.Valid {
+		return
+	}
+
+	end := p.StartTime.Time.Add(time.Duration(p.ExpectedDuration) * time.Second)
+	if p.ExpectedDuration <= 0 || now.Before(end) {
+		slog.Info("resuming the plunge timer", "id", p.ID, "remaining", end.Sub(now))
+		mctx.StartPlungeTimer(p)
+		return
+	}
+
+	slog.Info("completing a plunge that ended while the server was stopped", "id", p.ID)
+
+	timer := newPlungeTimer(p, now)
+	mctx.recordPlungePhases(timer, 0, len(timer.phases))
+	mctx.completePlunge(p.ID, end, true)
+}
+
+// runPlungeTimer announces the time left in the plunge and completes it once the expected duration has passed.
+func (mctx *MonitorContext) runPlungeTimer(now time.Time) {
+	mctx.Lock()
+	timer := mctx.plungeTimer
+	if timer == nil {
+		mctx.Unlock()
+		return
+	}
+
+	// an untimed plunge is only sampled
+	if timer.end.IsZero() {
+		mctx.Unlock()
+		return
+	}
+
+	remaining := timer.end.Sub(now)
+	done := remaining <= 0
+
+	// only the shortest countdown that passed is announced if the timer fell behind
+	var countdown time.Duration
+	for len(timer.countdown) != 0 && remaining <= timer.countdown[0] {
+		countdown = timer.countdown[0]
+		timer.countdown = timer.countdown[1:]
+	}
+
+	// the phases that ended are recorded and the phase that started is announced
+	var recordFrom, recordTo int
+	var announce *protocol.Position
+	if len(timer.phases) != 0 {
+		pos := protocol.At(timer.phases, timer.start, now)
+		recordFrom, recordTo = timer.recorded, pos.Index
+		timer.recorded = pos.Index
+
+		if !pos.Done && pos.Index != timer.announced {
+			timer.announced = pos.Index
+			announce = &pos
+		}
+	}
+
+	if done {
+		mctx.plungeTimer = nil
+	}
+	mctx.Unlock()
+
+	mctx.recordPlungePhases(timer, recordFrom, recordTo)
+
+	if done {
+		mctx.completePlunge(timer.id, timer.end, false)
+		return
+	}
+
+	if announce != nil {
+		payload := map[string]any{
+			"plunge_id":        timer.id.String(),
+			"phase":            announce.Index + 1,
+			"phases":           len(timer.phases),
+			"kind":             announce.Phase.Kind,
+			"duration_seconds": announce.Phase.DurationSeconds,
+		}
+		if timer.userID.Valid {
+			payload["user_id"] = timer.userID.UUID.String()
+		}
+
+		mctx.NotifyCh <- notification.Event{
+			Type:      notification.TYPE_PLUNGE,
+			Severity:  notification.SEVERITY_INFO,
+			Source:    SOURCE_PLUNGE,
+			Message:   phaseMessage(announce.Index, len(timer.phases), announce.Phase),
+			Payload:   payload,
+			Key:       fmt.Sprintf("%s/phase/%d", timer.id, announce.Index),
+			Requested: true,
+		}
+	}
+
+	if countdown != 0 {
+		payload := map[string]any{"plunge_id": timer.id.String(), "remaining_seconds": countdown.Seconds()}
+		if timer.userID.Valid {
+			payload["user_id"] = timer.userID.UUID.String()
+		}
+
+		mctx.NotifyCh <- notification.Event{
+			Type:      notification.TYPE_PLUNGE,
+			Severity:  notification.SEVERITY_INFO,
+			Source:    SOURCE_PLUNGE,
+			Message:   fmt.Sprintf("%.0f seconds left in the plunge.", countdown.Seconds()),
+			Payload:   payload,
+			Key:       fmt.Sprintf("%s/countdown/%.0f", timer.id, countdown.Seconds()),
+			Requested: true,
+		}
+	}
+}
+
+// completePlunge stops the plunge at its expected end with the current temperatures and announces the finish.
+// A plunge recovered after a restart ends with the last temperatures sampled before its end instead.
+// Nothing is done if the plunge was already stopped by the user.
+func (mctx *MonitorContext) completePlunge(id uuid.UUID, end time.Time, recovered bool) error {
+	p, err := mctx.store.GetLatestPlunge(mctx.ctx)
+	if err != nil {
+		slog.Error("failed to read the plunge to complete", "id", id, "error", err)
+		return err
+	}
+
+	if p.ID != id || !p.Running {
+		return nil
+	}
+
+	var waterTemp, roomTemp string
+	if recovered {
+		waterTemp, roomTemp = mctx.lastPlungeTemperatures(p, end)
+	} else {
+		waterTemp, roomTemp = mctx.endPlungeTemperatures(id)
+	}
+
+	p, err = mctx.store.StopPlunge(mctx.ctx, database.StopPlungeParams{
+		ID:           id,
+		EndTime:      sql.NullTime{Time: end.UTC(), Valid: true},
+		EndWaterTemp: waterTemp,
+		EndRoomTemp:  roomTemp,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// the user stopped the plunge since it was read
+		return nil
+	} else if err != nil {
+		slog.Error("failed to complete the plunge", "id", id, "error", err)
+		return err
+	}
+
+	duration := time.Duration(p.ExpectedDuration) * time.Second
+	message := fmt.Sprintf("The plunge is complete after %s.", duration)
+	if recovered {
+		message = fmt.Sprintf("The plunge was completed after %s while the server was restarting.", duration)
+	}
+
+	payload := map[string]any{
+		"plunge_id":        id.String(),
+		"duration_seconds": duration.Seconds(),
+		"end_water_temp":   p.EndWaterTemp,
+		"recovered":        recovered,
+	}
+	if p.UserID.Valid {
+		payload["user_id"] = p.UserID.UUID.String()
+	}
+
+	mctx.NotifyCh <- notification.Event{
+		Type:      notification.TYPE_PLUNGE,
+		Severity:  notification.SEVERITY_INFO,
+		Source:    SOURCE_PLUNGE,
+		Message:   message,
+		Payload:   payload,
+		Key:       id.String() + "/complete",
+		Requested: true,
+	}
+
+	return nil
+}
+
+// endPlungeTemperatures samples the temperatures as the last point of the plunge, or takes them from the monitor
+// if they can't be read.
+func (mctx *MonitorContext) endPlungeTemperatures(id uuid.UUID) (waterTemp string, roomTemp string) {
+	water, room := mctx.samplePlungeTemperatures(id, time.Now())
+
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	waterTemp = water.String
+	if !water.Valid {
+		waterTemp = fmt.Sprintf("%f", mctx.WaterTemperature)
+	}
+
+	roomTemp = room.String
+	if !room.Valid {
+		roomTemp = fmt.Sprintf("%f", mctx.RoomTemperature)
+	}
+
+	return waterTemp, roomTemp
+}
+
+// lastPlungeTemperatures returns the temperatures of the last sample at or before the end of the plunge, or the
+// temperatures it started with if there isn't one.
+func (mctx *MonitorContext) lastPlungeTemperatures(p database.Plunge, end time.Time) (waterTemp string, roomTemp string) {
+	waterTemp, roomTemp = p.StartWaterTemp, p.StartRoomTemp
+
+	sample, err := mctx.store.GetLastPlungeTemperature(mctx.ctx, database.GetLastPlungeTemperatureParams{
+		PlungeID: p.ID,
+		ReadAt:   end.UTC(),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("failed to read the last temperatures of the plunge", "id", p.ID, "error", err)
+		}
+		return waterTemp, roomTemp
+	}
+
+	if sample.WaterTemp.Valid {
+		waterTemp = sample.WaterTemp.String
+	}
+
+	if sample.RoomTemp.Valid {
+		roomTemp = sample.RoomTemp.String
+	}
+
+	return waterTemp, roomTemp
+}
+
+// samplePlungeTemperatures stores the water and room temperatures in Fahrenheit for the plunge and updates its
+// average, minimum and maximum. A temperature that couldn't be read is stored as NULL.
+func (mctx *MonitorContext) samplePlungeTemperatures(id uuid.UUID, now time.Time) (water sql.NullString, room sql.NullString) {
+	readings := mctx.sensors.ReadTemperatures(mctx.ctx)
+
+	if wt, ok := sensor.FindReading(readings, sensor.ROLE_WATER); ok && wt.Err == nil {
+		water = sql.NullString{String: fmt.Sprintf("%f", wt.TemperatureF), Valid: true}
+	}
+
+	if rt, ok := sensor.FindReading(readings, sensor.ROLE_ROOM); ok && rt.Err == nil {
+		room = sql.NullString{String: fmt.Sprintf("%f", rt.TemperatureF), Valid: true}
+	}
+
+	if !water.Valid && !room.Valid {
+		slog.Warn("failed to read the temperatures for the plunge", "id", id)
+		return water, room
+	}
+
+	_, err := mctx.store.SavePlungeTemperature(mctx.ctx, database.SavePlungeTemperatureParams{
+		PlungeID:  id,
+		ReadAt:    now.UTC(),
+		WaterTemp: water,
+		RoomTemp:  room,
+	})
+	if err != nil {
+		slog.Error("failed to save the plunge temperature", "id", id, "error", err)
+		return water, room
+	}
+
+	_, err = mctx.store.UpdatePlungeTemperatureSummary(mctx.ctx, id)
+	if err != nil {
+		slog.Error("failed to update the plunge temperatures", "id", id, "error", err)
+	}
+
+	return water, room
+}
+
+// recordPlungePhases records the phases from index 'from' up to 'to' as completed at the time they were scheduled to end.
+func (mctx *MonitorContext) recordPlungePhases(timer *plungeTimer, from int, to int) {
+	for i := from; i < to; i++ {
+		start, end := protocol.Bounds(timer.phases, timer.start, i)
+		mctx.recordPlungePhase(timer.id, i, timer.phases[i], start, end, true)
+	}
+}
+
+// recordPlungePhase stores a phase on the plunge, a phase that was already recorded is left as it is.
+func (mctx *MonitorContext) recordPlungePhase(id uuid.UUID, index int, phase protocol.Phase, start time.Time, end time.Time, completed bool) {
+	err := mctx.store.RecordPlungePhase(mctx.ctx, database.RecordPlungePhaseParams{
+		PlungeID:        id,
+		Position:        int32(index),
+		Name:            phase.Name,
+		Kind:            phase.Kind,
+		DurationSeconds: int32(phase.DurationSeconds),
+		StartedAt:       start.UTC(),
+		EndedAt:         end.UTC(),
+		Completed:       completed,
+	})
+	if err != nil {
+		slog.Error("failed to record the plunge phase", "id", id, "phase", index, "error", err)
+	}
+}
+
+// phaseMessage announces the start of a phase, e.g. "Phase 2 of 6: rest for 3m0s."
+func phaseMessage(index int, count int, phase protocol.Phase) string {
+	duration := time.Duration(phase.DurationSeconds) * time.Second
+	if phase.Name == phase.Kind {
+		return fmt.Sprintf("Phase %d of %d: %s for %s.", index+1, count, phase.Kind, duration)
+	}
+
+	return fmt.Sprintf("Phase %d of %d: %s (%s) for %s.", index+1, count, phase.Name, phase.Kind, duration)
+}
+
+// newPlungeTimer creates the timer for a running plunge, skipping the countdowns that are longer than the plunge or
+// have already passed. A plunge without an expected duration has no end.
+func newPlungeTimer(p database.Plunge, now time.Time) *plungeTimer {
+	// the phases were validated when the protocol was saved, a plunge without a protocol has none
+	var phases []protocol.Phase
+	if err := json.Unmarshal(p.ProtocolPhases, &phases); err != nil && len(p.ProtocolPhases) != 0 {
+		slog.Warn("failed to read the phases of the plunge", "id", p.ID, "error", err)
+	}
+
+	if p.ExpectedDuration <= 0 {
+		return &plungeTimer{id: p.ID, userID: p.UserID, start: p.StartTime.Time, announced: -1}
+	}
+
+	duration := time.Duration(p.ExpectedDuration) * time.Second
+	end := p.StartTime.Time.Add(duration)
+
+	countdown := make([]time.Duration, 0, len(plungeCountdown))
+	for _, c := range plungeCountdown {
+		if c < duration && end.Sub(now) > c {
+			countdown = append(countdown, c)
+		}
+	}
+
+	return &plungeTimer{
+		id:        p.ID,
+		userID:    p.UserID,
+		start:     p.StartTime.Time,
+		end:       end,
+		countdown: countdown,
+		phases:    phases,
+		announced: -1,
+	}
+}
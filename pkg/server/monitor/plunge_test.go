@@ -0,0 +1,369 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/google/uuid"
+)
+
+func testPlunge(start time.Time, duration int32) database.Plunge {
+	return database.Plunge{
+		ID:               uuid.New(),
+		StartTime:        sql.NullTime{Time: start, Valid: true},
+		StartWaterTemp:   "50.000000",
+		StartRoomTemp:    "70.000000",
+		Running:          true,
+		ExpectedDuration: duration,
+		ProtocolPhases:   json.RawMessage("[]"),
+	}
+}
+
+func testMonitorContext(store MonitorStore) *MonitorContext {
+	return &MonitorContext{
+		ctx:      context.Background(),
+		store:    store,
+		NotifyCh: make(chan notification.Event, 10),
+	}
+}
+
+func TestNewPlungeTimer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		duration  int32
+		phases    string
+		end       time.Time
+		countdown []time.Duration
+		count     int
+	}{
+		{
+			name:      "should count down a plunge that just started",
+			start:     now,
+			duration:  180,
+			end:       now.Add(3 * time.Minute),
+			countdown: []time.Duration{time.Minute, 30 * time.Second},
+		},
+		{
+			name:      "should skip a countdown longer than the plunge",
+			start:     now,
+			duration:  45,
+			end:       now.Add(45 * time.Second),
+			countdown: []time.Duration{30 * time.Second},
+		},
+		{
+			name:      "should skip the countdowns that passed before the timer started",
+			start:     now.Add(-140 * time.Second),
+			duration:  180,
+			end:       now.Add(40 * time.Second),
+			countdown: []time.Duration{30 * time.Second},
+		},
+		{
+			name:     "should not end a plunge without an expected duration",
+			start:    now,
+			duration: 0,
+		},
+		{
+			name:      "should read the phases of the protocol",
+			start:     now,
+			duration:  90,
+			phases:    `[{"name":"cold","kind":"cold","duration_seconds":60},{"name":"rest","kind":"rest","duration_seconds":30}]`,
+			end:       now.Add(90 * time.Second),
+			countdown: []time.Duration{time.Minute, 30 * time.Second},
+			count:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPlunge(tt.start, tt.duration)
+			if len(tt.phases) != 0 {
+				p.ProtocolPhases = json.RawMessage(tt.phases)
+			}
+
+			timer := newPlungeTimer(p, now)
+
+			if timer.id != p.ID || !timer.start.Equal(tt.start) || !timer.end.Equal(tt.end) {
+				t.Errorf("expected the timer to run from %s to %s, got %+v", tt.start, tt.end, timer)
+			}
+
+			if len(timer.countdown) != len(tt.countdown) {
+				t.Fatalf("expected the countdown %v, got %v", tt.countdown, timer.countdown)
+			}
+
+			for i := range tt.countdown {
+				if timer.countdown[i] != tt.countdown[i] {
+					t.Errorf("expected the countdown %v, got %v", tt.countdown, timer.countdown)
+				}
+			}
+
+			if len(timer.phases) != tt.count || timer.announced != -1 {
+				t.Errorf("expected %d phases and none announced, got %d and %d", tt.count, len(timer.phases), timer.announced)
+			}
+		})
+	}
+}
+
+func TestRunPlungeTimer(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		announced string
+		remaining int
+	}{
+		{name: "should not announce before the first countdown", elapsed: 110 * time.Second, remaining: 2},
+		{name: "should announce the minute left", elapsed: 121 * time.Second, announced: "60 seconds left in the plunge.", remaining: 1},
+		{name: "should announce the 30 seconds left", elapsed: 150 * time.Second, announced: "30 seconds left in the plunge.", remaining: 0},
+		{name: "should only announce the shortest countdown that passed", elapsed: 170 * time.Second, announced: "30 seconds left in the plunge.", remaining: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mctx := testMonitorContext(&mockMonitorStore{})
+			mctx.plungeTimer = newPlungeTimer(testPlunge(start, 180), start)
+
+			mctx.runPlungeTimer(start.Add(tt.elapsed))
+
+			var messages []string
+			for len(mctx.NotifyCh) != 0 {
+				event := <-mctx.NotifyCh
+				messages = append(messages, event.Message)
+
+				if !strings.HasPrefix(event.Key, mctx.plungeTimer.id.String()+"/countdown/") || !event.Requested {
+					t.Errorf("expected the countdown to be keyed by the plunge and requested, got %+v", event)
+				}
+			}
+
+			if len(tt.announced) == 0 && len(messages) != 0 {
+				t.Errorf("expected no announcement, got %v", messages)
+			} else if len(tt.announced) != 0 && (len(messages) != 1 || messages[0] != tt.announced) {
+				t.Errorf("expected %q, got %v", tt.announced, messages)
+			}
+
+			if len(mctx.plungeTimer.countdown) != tt.remaining {
+				t.Errorf("expected %d countdowns left, got %v", tt.remaining, mctx.plungeTimer.countdown)
+			}
+		})
+	}
+}
+
+func TestRecoverPlunge(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	t.Run("should resume the timer of a plunge that is still running", func(t *testing.T) {
+		store := mockMonitorStore{plunge: testPlunge(start, 180)}
+		mctx := testMonitorContext(&store)
+
+		mctx.recoverPlunge(start.Add(time.Minute))
+
+		if mctx.plungeTimer == nil || mctx.plungeTimer.id != store.plunge.ID {
+			t.Fatalf("expected the timer to resume for %s, got %+v", store.plunge.ID, mctx.plungeTimer)
+		}
+
+		if store.stopped != nil {
+			t.Errorf("expected the plunge to keep running, it was stopped with %+v", store.stopped)
+		}
+	})
+
+	t.Run("should complete a plunge that ended while the server was stopped", func(t *testing.T) {
+		store := mockMonitorStore{plunge: testPlunge(start, 180)}
+		store.sample = &database.PlungeTemperature{
+			PlungeID:  store.plunge.ID,
+			ReadAt:    start.Add(175 * time.Second),
+			WaterTemp: sql.NullString{String: "48.500000", Valid: true},
+			RoomTemp:  sql.NullString{String: "68.000000", Valid: true},
+		}
+		mctx := testMonitorContext(&store)
+
+		mctx.recoverPlunge(start.Add(time.Hour))
+
+		if mctx.plungeTimer != nil {
+			t.Errorf("expected no timer for a completed plunge, got %+v", mctx.plungeTimer)
+		}
+
+		end := start.Add(3 * time.Minute)
+		if store.stopped == nil || !store.stopped.EndTime.Time.Equal(end) {
+			t.Fatalf("expected the plunge to end at %s, got %+v", end, store.stopped)
+		}
+
+		if !store.sampledBefore.Equal(end) || store.stopped.EndWaterTemp != "48.500000" || store.stopped.EndRoomTemp != "68.000000" {
+			t.Errorf("expected the end temperatures of the last sample before %s, got %+v", end, store.stopped)
+		}
+
+		if store.saved != 0 {
+			t.Errorf("expected no temperatures to be sampled after the plunge, got %d", store.saved)
+		}
+
+		event := <-mctx.NotifyCh
+		if event.Payload["recovered"] != true {
+			t.Errorf("expected the completion to be recovered, got %+v", event)
+		}
+	})
+
+	t.Run("should end a plunge without samples with its start temperatures", func(t *testing.T) {
+		store := mockMonitorStore{plunge: testPlunge(start, 180)}
+		mctx := testMonitorContext(&store)
+
+		mctx.recoverPlunge(start.Add(time.Hour))
+
+		if store.stopped == nil || store.stopped.EndWaterTemp != "50.000000" || store.stopped.EndRoomTemp != "70.000000" {
+			t.Errorf("expected the plunge to end with its start temperatures, got %+v", store.stopped)
+		}
+	})
+}
+
+type mockMonitorStore struct {
+	plunge        database.Plunge
+	sample        *database.PlungeTemperature
+	sampledBefore time.Time
+	stopped       *database.StopPlungeParams
+	saved         int
+}
+
+func (m *mockMonitorStore) GetLatestPlunge(ctx context.Context) (database.Plunge, error) {
+	return m.plunge, nil
+}
+
+func (m *mockMonitorStore) StopPlunge(ctx context.Context, arg database.StopPlungeParams) (database.Plunge, error) {
+	if !m.plunge.Running || m.plunge.ID != arg.ID {
+		return database.Plunge{}, sql.ErrNoRows
+	}
+
+	m.stopped = &arg
+	m.plunge.Running = false
+	m.plunge.EndTime = arg.EndTime
+	m.plunge.EndWaterTemp = arg.EndWaterTemp
+	m.plunge.EndRoomTemp = arg.EndRoomTemp
+	return m.plunge, nil
+}
+
+func (m *mockMonitorStore) SavePlungeTemperature(ctx context.Context, arg database.SavePlungeTemperatureParams) (database.PlungeTemperature, error) {
+	m.saved++
+	return database.PlungeTemperature{}, nil
+}
+
+func (m *mockMonitorStore) GetLastPlungeTemperature(ctx context.Context, arg database.GetLastPlungeTemperatureParams) (database.PlungeTemperature, error) {
+	m.sampledBefore = arg.ReadAt
+	if m.sample == nil {
+		return database.PlungeTemperature{}, sql.ErrNoRows
+	}
+	return *m.sample, nil
+}
+
+func (m *mockMonitorStore) RecordPlungePhase(ctx context.Context, arg database.RecordPlungePhaseParams) error {
+	return nil
+}
+
+func (m *mockMonitorStore) SaveTemperatureReading(ctx context.Context, arg database.SaveTemperatureReadingParams) (database.TemperatureReading, error) {
+	return database.TemperatureReading{}, nil
+}
+
+func (m *mockMonitorStore) GetLatestOzoneEntry(ctx context.Context) (database.Ozone, error) {
+	return database.Ozone{}, nil
+}
+
+func (m *mockMonitorStore) StartOzoneGenerator(ctx context.Context, arg database.StartOzoneGeneratorParams) (database.Ozone, error) {
+	return database.Ozone{}, nil
+}
+
+func (m *mockMonitorStore) StopOzoneGenerator(ctx context.Context, id uuid.UUID) (database.Ozone, error) {
+	return database.Ozone{}, nil
+}
+
+func (m *mockMonitorStore) UpdateOzoneEntryStatus(ctx context.Context, args database.UpdateOzoneEntryStatusParams) (database.Ozone, error) {
+	return database.Ozone{}, nil
+}
+
+func (m *mockMonitorStore) GetActiveLeak(ctx context.Context, sensorID string) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockMonitorStore) CreateLeakDetected(ctx context.Context, arg database.CreateLeakDetectedParams) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockMonitorStore) ClearDetectedLeak(ctx context.Context, id uuid.UUID) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockMonitorStore) ClearSensorLeaks(ctx context.Context, sensorID string) ([]database.Leak, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) RollupTemperatureReadings(ctx context.Context, readAt time.Time) (database.RollupTemperatureReadingsRow, error) {
+	return database.RollupTemperatureReadingsRow{}, nil
+}
+
+func (m *mockMonitorStore) PruneTemperatureRollups(ctx context.Context, bucket time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockMonitorStore) CreateRetentionRun(ctx context.Context, arg database.CreateRetentionRunParams) (database.RetentionRun, error) {
+	return database.RetentionRun{}, nil
+}
+
+func (m *mockMonitorStore) GetLatestThermostatSettings(ctx context.Context) (database.ThermostatSetting, error) {
+	return database.ThermostatSetting{}, sql.ErrNoRows
+}
+
+func (m *mockMonitorStore) GetEnabledAlertRules(ctx context.Context) ([]database.AlertRule, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) GetEnabledOzoneSchedules(ctx context.Context) ([]database.OzoneSchedule, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) CreateOzoneScheduleRun(ctx context.Context, arg database.CreateOzoneScheduleRunParams) (database.OzoneScheduleRun, error) {
+	return database.OzoneScheduleRun{}, nil
+}
+
+func (m *mockMonitorStore) GetSubscribedUsers(ctx context.Context, eventType string) ([]database.User, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockMonitorStore) GetPendingNotifications(ctx context.Context, arg database.GetPendingNotificationsParams) ([]database.NotificationOutbox, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) MarkNotificationSent(ctx context.Context, arg database.MarkNotificationSentParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockMonitorStore) MarkNotificationFailed(ctx context.Context, arg database.MarkNotificationFailedParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockMonitorStore) MarkAlertRuleTriggered(ctx context.Context, arg database.MarkAlertRuleTriggeredParams) error {
+	return nil
+}
+
+func (m *mockMonitorStore) GetLeak(ctx context.Context, id uuid.UUID) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockMonitorStore) AcknowledgeLeak(ctx context.Context, arg database.AcknowledgeLeakParams) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockMonitorStore) GetUnacknowledgedLeaks(ctx context.Context) ([]database.Leak, error) {
+	return nil, nil
+}
+
+func (m *mockMonitorStore) UpdatePlungeTemperatureSummary(ctx context.Context, plungeID uuid.UUID) (database.Plunge, error) {
+	return database.Plunge{}, nil
+}
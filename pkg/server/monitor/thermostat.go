@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
+)
+
+// ThermostatStatus returns the current settings and state of the thermostat.
+func (mctx *MonitorContext) ThermostatStatus() thermostat.Status {
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	now := time.Now().UTC()
+
+	return thermostat.Status{
+		Settings:       mctx.thermostatSettings,
+		Device:         mctx.thermostatDevice,
+		DeviceOn:       mctx.thermostatState.On,
+		Reason:         mctx.thermostatReason,
+		LastChangedAt:  mctx.thermostatState.LastChangedAt,
+		CyclesLastHour: thermostat.CyclesSince(mctx.thermostatState.Starts, now.Add(-time.Hour)),
+		Error:          mctx.thermostatError,
+	}
+}
+
+// SetThermostat replaces the thermostat settings and asks the monitor to evaluate them.
+func (mctx *MonitorContext) SetThermostat(settings thermostat.Settings) {
+	mctx.Lock()
+	mctx.thermostatSettings = settings
+	mctx.Unlock()
+
+	// a change that is already pending will pick up these settings as well
+	select {
+	case mctx.ThermostatCh <- struct{}{}:
+	default:
+	}
+}
+
+// loadThermostat restores the last saved settings and reads the current state of the device.
+// The device may have just been switched before a restart, so the minimum on and off times count from startup.
+func (mctx *MonitorContext) loadThermostat() {
+	slog.Debug(">>loadThermostat")
+	defer slog.Debug("<<loadThermostat")
+
+	if len(mctx.thermostatDevice) == 0 {
+		return
+	}
+
+	dbSettings, err := mctx.store.GetLatestThermostatSettings(mctx.ctx)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to read the thermostat settings, using the configured settings", "error", err)
+		}
+	} else {
+		mctx.Lock()
+		mctx.thermostatSettings = databaseToThermostatSettings(dbSettings)
+		mctx.Unlock()
+	}
+
+	on, err := mctx.sensors.IsDeviceOn(mctx.ctx, mctx.thermostatDevice)
+
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	mctx.thermostatState.LastChangedAt = time.Now().UTC()
+
+	if err != nil {
+		slog.Warn("failed to read the thermostat device", "device", mctx.thermostatDevice, "error", err)
+		mctx.thermostatError = err.Error()
+		return
+	}
+
+	mctx.thermostatState.On = on
+}
+
+// runThermostat evaluates the control loop against the last water temperature and switches the device.
+func (mctx *MonitorContext) runThermostat() {
+	if len(mctx.thermostatDevice) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	mctx.Lock()
+	settings := mctx.thermostatSettings
+	state := mctx.thermostatState
+	waterF := mctx.WaterTemperature
+	valid := mctx.waterValid
+	mctx.Unlock()
+
+	d := thermostat.Evaluate(settings, state, waterF, valid, now)
+
+	var err error
+	if d.On != state.On {
+		if d.On {
+			err = mctx.sensors.TurnDeviceOn(mctx.ctx, mctx.thermostatDevice)
+		} else {
+			err = mctx.sensors.TurnDeviceOff(mctx.ctx, mctx.thermostatDevice)
+		}
+
+		if err != nil {
+			slog.Error("failed to switch the thermostat device", "device", mctx.thermostatDevice, "on", d.On, "error", err)
+		} else {
+			slog.Info("thermostat switched the device", "device", mctx.thermostatDevice, "on", d.On, "reason", d.Reason, "water_temp", waterF)
+			state = state.Apply(d, now)
+		}
+	}
+
+	mctx.Lock()
+	defer mctx.Unlock()
+
+	mctx.thermostatState = state
+	mctx.thermostatReason = d.Reason
+	mctx.thermostatError = ""
+	if err != nil {
+		mctx.thermostatError = err.Error()
+	}
+}
+
+// stopThermostat turns the device off when the monitor exits so it isn't left running without control.
+func (mctx *MonitorContext) stopThermostat() {
+	if len(mctx.thermostatDevice) == 0 {
+		return
+	}
+
+	err := mctx.sensors.TurnDeviceOff(context.Background(), mctx.thermostatDevice)
+	if err != nil && !errors.Is(err, sensor.ErrDeviceNotFound) {
+		slog.Error("failed to turn off the thermostat device", "device", mctx.thermostatDevice, "error", err)
+	}
+}
+
+func databaseToThermostatSettings(db database.ThermostatSetting) thermostat.Settings {
+	// the values were validated before they were saved
+	setpoint, _ := strconv.ParseFloat(db.SetpointF, 64)
+	hysteresis, _ := strconv.ParseFloat(db.HysteresisF, 64)
+
+	return thermostat.Settings{
+		Mode:             db.Mode,
+		SetpointF:        setpoint,
+		HysteresisF:      hysteresis,
+		MinOnSeconds:     int(db.MinOnSeconds),
+		MinOffSeconds:    int(db.MinOffSeconds),
+		MaxCyclesPerHour: int(db.MaxCyclesPerHour),
+	}
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
+)
+
+// mockThermostatSensors is a thermostat device, the other sensors aren't used.
+type mockThermostatSensors struct {
+	sensor.Sensors
+	on       bool
+	switched int
+}
+
+func (m *mockThermostatSensors) IsDeviceOn(ctx context.Context, id string) (bool, error) {
+	return m.on, nil
+}
+
+func (m *mockThermostatSensors) TurnDeviceOn(ctx context.Context, id string) error {
+	m.on = true
+	m.switched++
+	return nil
+}
+
+func (m *mockThermostatSensors) TurnDeviceOff(ctx context.Context, id string) error {
+	m.on = false
+	m.switched++
+	return nil
+}
+
+func TestThermostatRestart(t *testing.T) {
+	settings := thermostat.DefaultSettings()
+	settings.Mode = thermostat.MODE_COOL
+
+	t.Run("should wait for the minimum off time after a restart", func(t *testing.T) {
+		sensors := &mockThermostatSensors{}
+		mctx := testMonitorContext(&mockMonitorStore{})
+		mctx.sensors = sensors
+		mctx.thermostatDevice = "chiller"
+		mctx.thermostatSettings = settings
+		mctx.WaterTemperature = settings.SetpointF + 2*settings.HysteresisF
+		mctx.waterValid = true
+
+		mctx.loadThermostat()
+		mctx.runThermostat()
+
+		status := mctx.ThermostatStatus()
+		if sensors.switched != 0 || status.DeviceOn || status.Reason != thermostat.REASON_MIN_OFF {
+			t.Errorf("expected the device to stay off waiting for the minimum off time, switched %d times with status %+v", sensors.switched, status)
+		}
+	})
+
+	t.Run("should wait for the minimum on time after a restart", func(t *testing.T) {
+		sensors := &mockThermostatSensors{on: true}
+		mctx := testMonitorContext(&mockMonitorStore{})
+		mctx.sensors = sensors
+		mctx.thermostatDevice = "chiller"
+		mctx.thermostatSettings = settings
+		mctx.WaterTemperature = settings.SetpointF - settings.HysteresisF
+		mctx.waterValid = true
+
+		mctx.loadThermostat()
+		mctx.runThermostat()
+
+		status := mctx.ThermostatStatus()
+		if sensors.switched != 0 || !status.DeviceOn || status.Reason != thermostat.REASON_MIN_ON {
+			t.Errorf("expected the device to stay on waiting for the minimum on time, switched %d times with status %+v", sensors.switched, status)
+		}
+	})
+}
@@ -2,18 +2,68 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/KyleBrandon/plunger-server/config"
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
 	"github.com/google/uuid"
-	"github.com/nikoksr/notify"
 )
 
 const (
+	RETENTION_INTERVAL = time.Hour
+
+	// OZONE_SCHEDULE_INTERVAL is how often the ozone schedules are checked, it must be less than a minute.
+	OZONE_SCHEDULE_INTERVAL = 15 * time.Second
+
+	// Sources of the notification events raised by the monitor.
+	SOURCE_OZONE     = "ozone_generator"
+	SOURCE_LEAK      = "leak_sensor"
+	SOURCE_INTERLOCK = "interlock"
+	SOURCE_PLUNGE    = "plunge_timer"
+
+	// PLUNGE_TIMER_INTERVAL is how often the running plunge is checked against its expected duration.
+	PLUNGE_TIMER_INTERVAL = time.Second
+
+	// PLUNGE_SAMPLE_INTERVAL is how often the temperatures are sampled while a plunge is running.
+	PLUNGE_SAMPLE_INTERVAL = 5 * time.Second
+
 	OZONEACTION_START = 1
 	OZONEACTION_STOP  = 2
+
+	// Delivery status of a notification in the outbox.
+	NOTIFICATIONSTATUS_PENDING = "pending"
+	NOTIFICATIONSTATUS_SENT    = "sent"
+	NOTIFICATIONSTATUS_FAILED  = "failed"
+
+	// OUTBOX_INTERVAL is how often the outbox is checked for notifications to retry.
+	OUTBOX_INTERVAL = 30 * time.Second
+
+	// OUTBOX_BATCH_SIZE is the most notifications sent on each check of the outbox.
+	OUTBOX_BATCH_SIZE = 20
+
+	// OUTBOX_MAX_ATTEMPTS is how many times a notification is sent before it is marked as failed.
+	OUTBOX_MAX_ATTEMPTS = 8
+
+	// The delay before a retry doubles after each failed attempt, up to OUTBOX_MAX_RETRY_DELAY.
+	OUTBOX_RETRY_DELAY     = 30 * time.Second
+	OUTBOX_MAX_RETRY_DELAY = time.Hour
+)
+
+// plungeCountdown is when the time left in a plunge is announced, a countdown longer than the plunge is skipped.
+var plungeCountdown = []time.Duration{time.Minute, 30 * time.Second}
+
+var (
+	ErrOzoneRunning     = errors.New("the ozone generator is already running")
+	ErrLeakNotFound     = errors.New("the leak was not found")
+	ErrLeakActive       = errors.New("the leak sensor still detects the leak")
+	ErrLeakAcknowledged = errors.New("the leak was already acknowledged")
 )
 
 type (
@@ -27,21 +77,53 @@ type (
 	OzoneTask struct {
 		Action OzoneAction
 
-		// Duration to run the ozone generator in minutes.
+		// Duration to run the ozone generator in minutes, the configured duration is used when it is zero.
 		Duration int
 	}
 
-	// NotificationTask is a struct used to send messages to a destination.
-	NotificationTask struct {
-		// Message to send to the consumer.
-		Message string
+	// Notifier finds where an event should be sent, the channels routed for its type and the subscribed users,
+	// and delivers it to each of them.
+	Notifier interface {
+		Targets(event notification.Event, recipients ...notification.Recipient) (notification.Event, []notification.Target, error)
+		Deliver(ctx context.Context, event notification.Event, target notification.Target) error
+	}
+
+	// LeakZoneStatus is the state of the leak sensors in a zone.
+	LeakZoneStatus struct {
+		Zone           string   `json:"zone"`
+		LeakDetected   bool     `json:"leak_detected"`
+		Unacknowledged bool     `json:"unacknowledged"`
+		Sensors        []string `json:"sensors"`
+		ShutOff        []string `json:"shut_off"`
+	}
+
+	// plungeTimer completes the running plunge once its expected duration has passed, end is zero if it has none.
+	plungeTimer struct {
+		id        uuid.UUID
+		userID    uuid.NullUUID
+		start     time.Time
+		end       time.Time
+		countdown []time.Duration // countdown are the announcements of the time left that haven't been made, longest first
+
+		phases    []protocol.Phase // phases of the protocol the plunge follows, if any
+		recorded  int              // recorded is the number of phases stored on the plunge
+		announced int              // announced is the index of the last phase that was announced
+	}
+
+	// PlungePhaseStatus is the phase of the protocol a running plunge is in.
+	PlungePhaseStatus struct {
+		Number    int     `json:"number"`
+		Count     int     `json:"count"`
+		Name      string  `json:"name"`
+		Kind      string  `json:"kind"`
+		Remaining float64 `json:"remaining_time"`
 	}
 
-	// TemperatureTask will start monitoring the temperature indicated.
-	// If another TemperatureTask is received while already monitoring, it will replace the current monitor.
-	// Once the temperature has been reached the user will be notified once.
-	TemperatureTask struct {
-		TargetTemperature float64
+	// leakSensor is the debounced state of a leak sensor.
+	leakSensor struct {
+		id       string
+		zone     string
+		detected bool
 	}
 
 	MonitorContext struct {
@@ -55,26 +137,67 @@ type (
 
 		OzoneCh         chan OzoneTask // OzoneCh is a channel that receives an OzoneTask to start or stop the ozone generator.
 		ozoneCancelFunc context.CancelFunc
+		ozoneDuration   time.Duration
 		OzoneRunning    bool
 
-		NotifyCh chan NotificationTask // Channel to track notification tasks
-		notifier *notify.Notify
+		plungeTimer *plungeTimer // plungeTimer is the running plunge, nil if there isn't one
+
+		leakSensors        map[string]leakSensor // leakSensors holds the debounced reading of each leak sensor by device ID, used by the interlock rules
+		leakZones          map[string][]string   // leakZones are the devices a leak shuts off in each configured zone
+		leakUnacknowledged map[string]bool       // leakUnacknowledged latches the zones with a leak until every leak in them is acknowledged
+		leakDebounceCount  int
+		leakDebounceWindow time.Duration
+
+		NotifyCh chan notification.Event // Channel of the events to send to the notification channels
+		notifier Notifier
+		outboxCh chan struct{} // outboxCh wakes the outbox worker when a notification was queued
+
+		retention config.RetentionConfig
+
+		ThermostatCh       chan struct{} // ThermostatCh asks the monitor to evaluate the thermostat after the settings changed
+		thermostatDevice   string
+		thermostatSettings thermostat.Settings
+		thermostatState    thermostat.State
+		thermostatReason   string
+		thermostatError    string
+		waterValid         bool
 
-		TempMonitorCh         chan TemperatureTask // Channel to track temperature monitoring requests
-		TargetTemperature     float64
-		WaterTemperature      float64
-		RoomTemperature       float64
-		temperatureMonitoring bool
+		alertReadings    map[string]alerts.Reading // latest reading from each temperature device, used to evaluate the alert rules
+		WaterTemperature float64
+		RoomTemperature  float64
 	}
 
 	MonitorStore interface {
-		SaveTemperature(ctx context.Context, arg database.SaveTemperatureParams) (database.Temperature, error)
+		SaveTemperatureReading(ctx context.Context, arg database.SaveTemperatureReadingParams) (database.TemperatureReading, error)
 		GetLatestOzoneEntry(ctx context.Context) (database.Ozone, error)
 		StartOzoneGenerator(ctx context.Context, arg database.StartOzoneGeneratorParams) (database.Ozone, error)
 		StopOzoneGenerator(ctx context.Context, id uuid.UUID) (database.Ozone, error)
 		UpdateOzoneEntryStatus(ctx context.Context, args database.UpdateOzoneEntryStatusParams) (database.Ozone, error)
-		GetLatestLeakDetected(ctx context.Context) (database.Leak, error)
-		CreateLeakDetected(ctx context.Context, detectedAt time.Time) (database.Leak, error)
+		GetActiveLeak(ctx context.Context, sensorID string) (database.Leak, error)
+		CreateLeakDetected(ctx context.Context, arg database.CreateLeakDetectedParams) (database.Leak, error)
 		ClearDetectedLeak(ctx context.Context, id uuid.UUID) (database.Leak, error)
+		ClearSensorLeaks(ctx context.Context, sensorID string) ([]database.Leak, error)
+		RollupTemperatureReadings(ctx context.Context, readAt time.Time) (database.RollupTemperatureReadingsRow, error)
+		PruneTemperatureRollups(ctx context.Context, bucket time.Time) (int64, error)
+		CreateRetentionRun(ctx context.Context, arg database.CreateRetentionRunParams) (database.RetentionRun, error)
+		GetLatestThermostatSettings(ctx context.Context) (database.ThermostatSetting, error)
+		GetEnabledAlertRules(ctx context.Context) ([]database.AlertRule, error)
+		GetEnabledOzoneSchedules(ctx context.Context) ([]database.OzoneSchedule, error)
+		CreateOzoneScheduleRun(ctx context.Context, arg database.CreateOzoneScheduleRunParams) (database.OzoneScheduleRun, error)
+		GetLatestPlunge(ctx context.Context) (database.Plunge, error)
+		GetSubscribedUsers(ctx context.Context, eventType string) ([]database.User, error)
+		CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.NotificationOutbox, error)
+		GetPendingNotifications(ctx context.Context, arg database.GetPendingNotificationsParams) ([]database.NotificationOutbox, error)
+		MarkNotificationSent(ctx context.Context, arg database.MarkNotificationSentParams) (database.NotificationOutbox, error)
+		MarkNotificationFailed(ctx context.Context, arg database.MarkNotificationFailedParams) (database.NotificationOutbox, error)
+		MarkAlertRuleTriggered(ctx context.Context, arg database.MarkAlertRuleTriggeredParams) error
+		GetLeak(ctx context.Context, id uuid.UUID) (database.Leak, error)
+		AcknowledgeLeak(ctx context.Context, arg database.AcknowledgeLeakParams) (database.Leak, error)
+		GetUnacknowledgedLeaks(ctx context.Context) ([]database.Leak, error)
+		StopPlunge(ctx context.Context, arg database.StopPlungeParams) (database.Plunge, error)
+		SavePlungeTemperature(ctx context.Context, arg database.SavePlungeTemperatureParams) (database.PlungeTemperature, error)
+		GetLastPlungeTemperature(ctx context.Context, arg database.GetLastPlungeTemperatureParams) (database.PlungeTemperature, error)
+		UpdatePlungeTemperatureSummary(ctx context.Context, plungeID uuid.UUID) (database.Plunge, error)
+		RecordPlungePhase(ctx context.Context, arg database.RecordPlungePhaseParams) error
 	}
 )
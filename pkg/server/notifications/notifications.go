@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+var ErrInvalidStatus = errors.New("'status' must be pending, sent or failed")
+
+func NewHandler(store NotificationStore) *Handler {
+	return &Handler{
+		store,
+	}
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/notifications", h.handleNotificationsGet)
+}
+
+// handleNotificationsGet returns the delivery history from the outbox, newest first, optionally filtered by status.
+func (h *Handler) handleNotificationsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleNotificationsGet")
+	defer slog.Debug("<<handleNotificationsGet")
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", monitor.NOTIFICATIONSTATUS_PENDING, monitor.NOTIFICATIONSTATUS_SENT, monitor.NOTIFICATIONSTATUS_FAILED:
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, ErrInvalidStatus.Error(), ErrInvalidStatus)
+		return
+	}
+
+	limit := DefaultNotificationsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'limit' parameter", err)
+			return
+		}
+	}
+
+	dbNotifications, err := h.store.GetNotifications(r.Context(), database.GetNotificationsParams{
+		Status:   status,
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the notifications", err)
+		return
+	}
+
+	response := make([]NotificationResponse, 0, len(dbNotifications))
+	for _, db := range dbNotifications {
+		response = append(response, databaseToNotification(db))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func databaseToNotification(db database.NotificationOutbox) NotificationResponse {
+	n := NotificationResponse{
+		ID:         db.ID,
+		CreatedAt:  db.CreatedAt,
+		Type:       db.EventType,
+		Severity:   db.Severity,
+		Source:     db.Source,
+		Title:      db.Title,
+		Message:    db.Message,
+		Payload:    db.Payload,
+		OccurredAt: db.OccurredAt,
+		Channel:    db.Channel,
+		Status:     db.Status,
+		Attempts:   db.Attempts,
+		LastError:  db.LastError.String,
+	}
+
+	if db.PhoneNumber.Valid {
+		n.Recipient = db.PhoneNumber.String
+	} else if db.Email.Valid {
+		n.Recipient = db.Email.String
+	}
+
+	if db.Status == monitor.NOTIFICATIONSTATUS_PENDING {
+		n.NextAttemptAt = &db.NextAttemptAt
+	}
+
+	if db.SentAt.Valid {
+		n.SentAt = &db.SentAt.Time
+	}
+
+	return n
+}
@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func TestGetNotifications(t *testing.T) {
+	store := mockNotificationStore{}
+	h := NewHandler(&store)
+
+	t.Run("should fail with an invalid status", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/notifications?status=lost", nil, h.handleNotificationsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, ErrInvalidStatus.Error())
+	})
+
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/notifications?limit=-1", nil, h.handleNotificationsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail when the store fails", func(t *testing.T) {
+		store.err = errors.New("database is down")
+		defer func() { store.err = nil }()
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/notifications", nil, h.handleNotificationsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+	})
+
+	t.Run("should return the delivery history", func(t *testing.T) {
+		store.notifications = []database.NotificationOutbox{
+			{
+				EventType:   "leak",
+				Channel:     "twilio",
+				PhoneNumber: sql.NullString{String: "+15555550100", Valid: true},
+				Status:      "failed",
+				Attempts:    8,
+				Payload:     json.RawMessage(`{}`),
+				LastError:   sql.NullString{String: "twilio is down", Valid: true},
+			},
+			{
+				EventType: "ozone",
+				Channel:   "email",
+				Status:    "sent",
+				Attempts:  1,
+				Payload:   json.RawMessage(`{}`),
+				SentAt:    sql.NullTime{Time: time.Now(), Valid: true},
+			},
+		}
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/notifications?status=failed&limit=10", nil, h.handleNotificationsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.arg.Status != "failed" || store.arg.RowLimit != 10 {
+			t.Errorf("unexpected query %+v", store.arg)
+		}
+
+		var response []NotificationResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(response) != 2 || response[0].Recipient != "+15555550100" || response[0].LastError != "twilio is down" || response[1].SentAt == nil {
+			t.Errorf("unexpected response %+v", response)
+		}
+	})
+}
+
+type mockNotificationStore struct {
+	notifications []database.NotificationOutbox
+	arg           database.GetNotificationsParams
+	err           error
+}
+
+func (m *mockNotificationStore) GetNotifications(ctx context.Context, arg database.GetNotificationsParams) ([]database.NotificationOutbox, error) {
+	m.arg = arg
+	return m.notifications, m.err
+}
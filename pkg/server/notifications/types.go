@@ -0,0 +1,42 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// DefaultNotificationsLimit is how many notifications are returned when no limit is requested.
+const DefaultNotificationsLimit = 50
+
+type (
+	NotificationResponse struct {
+		ID            uuid.UUID       `json:"id"`
+		CreatedAt     time.Time       `json:"created_at"`
+		Type          string          `json:"type"`
+		Severity      string          `json:"severity"`
+		Source        string          `json:"source"`
+		Title         string          `json:"title"`
+		Message       string          `json:"message"`
+		Payload       json.RawMessage `json:"payload"`
+		OccurredAt    time.Time       `json:"occurred_at"`
+		Channel       string          `json:"channel"`
+		Recipient     string          `json:"recipient,omitempty"`
+		Status        string          `json:"status"`
+		Attempts      int32           `json:"attempts"`
+		NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+		LastError     string          `json:"last_error,omitempty"`
+		SentAt        *time.Time      `json:"sent_at,omitempty"`
+	}
+
+	Handler struct {
+		store NotificationStore
+	}
+
+	NotificationStore interface {
+		GetNotifications(ctx context.Context, arg database.GetNotificationsParams) ([]database.NotificationOutbox, error)
+	}
+)
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
@@ -23,6 +24,12 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /v1/ozone", h.handlerOzoneGet)
 	mux.HandleFunc("POST /v1/ozone/start", h.handlerOzoneStart)
 	mux.HandleFunc("POST /v1/ozone/stop", h.handlerOzoneStop)
+	mux.HandleFunc("GET /v1/ozone/schedules", h.handlerOzoneSchedulesGet)
+	mux.HandleFunc("POST /v1/ozone/schedules", h.handlerOzoneScheduleCreate)
+	mux.HandleFunc("GET /v1/ozone/schedules/{id}", h.handlerOzoneScheduleGet)
+	mux.HandleFunc("PUT /v1/ozone/schedules/{id}", h.handlerOzoneScheduleUpdate)
+	mux.HandleFunc("DELETE /v1/ozone/schedules/{id}", h.handlerOzoneScheduleDelete)
+	mux.HandleFunc("GET /v1/ozone/schedules/{id}/runs", h.handlerOzoneScheduleRunsGet)
 }
 
 func databaseToOzoneResult(db database.Ozone) OzoneResult {
@@ -72,14 +79,22 @@ func (h *Handler) handlerOzoneStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	durationStr := r.URL.Query().Get("duration")
-	if durationStr == "" {
-		durationStr = DefaultOzoneDurationMinutes
+	// the monitor uses the configured ozone_run_duration when no duration is given
+	duration := 0
+	if durationStr := r.URL.Query().Get("duration"); durationStr != "" {
+		duration, err = strconv.Atoi(durationStr)
+		if err != nil || duration < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'duration' parameter", err)
+			return
+		}
 	}
 
-	duration, err := strconv.Atoi(durationStr)
-	if err != nil || duration < 0 {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'duration' parameter", err)
+	err = h.mctx.CheckAction(r.Context(), interlock.ACTION_OZONE_START)
+	if interlock.IsViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, err.Error(), err)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to check the interlock state", err)
 		return
 	}
 
@@ -2,12 +2,14 @@ package ozone
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/KyleBrandon/plunger-server/config"
 	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
@@ -19,7 +21,7 @@ func TestOzoneGet(t *testing.T) {
 	t.Run("Get ozone status expect no job running", func(t *testing.T) {
 		store := mockOzoneStore{}
 		sensors := mockSensors{}
-		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors)
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
 		h := NewHandler(&store, &sensors, mctx)
 
 		store.SetError(errors.New("could not find any ozone job"))
@@ -37,7 +39,7 @@ func TestOzoneGet(t *testing.T) {
 	t.Run("Get ozone status expect a job running", func(t *testing.T) {
 		store := mockOzoneStore{}
 		sensors := mockSensors{}
-		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors)
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
 		h := NewHandler(&store, &sensors, mctx)
 
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/ozone", nil, h.handlerOzoneGet)
@@ -55,7 +57,7 @@ func TestOzoneGet(t *testing.T) {
 		store := mockOzoneStore{}
 		store.entry.Running = true
 		sensors := mockSensors{}
-		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors)
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
 		h := NewHandler(&store, &sensors, mctx)
 
 		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/start", nil, h.handlerOzoneStart)
@@ -73,7 +75,7 @@ func TestOzoneGet(t *testing.T) {
 	t.Run("Succeed to start ozone job", func(t *testing.T) {
 		store := mockOzoneStore{}
 		sensors := mockSensors{}
-		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors)
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
 		h := NewHandler(&store, &sensors, mctx)
 
 		go func() {
@@ -94,11 +96,23 @@ func TestOzoneGet(t *testing.T) {
 		}
 	})
 
+	t.Run("should refuse to start ozone during a plunge", func(t *testing.T) {
+		store := mockOzoneStore{}
+		store.plunge.Running = true
+		sensors := mockSensors{}
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
+		h := NewHandler(&store, &sensors, mctx)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/start", nil, h.handlerOzoneStart)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "the ozone generator can't run during a plunge")
+	})
+
 	t.Run("Succeed to stop ozone job", func(t *testing.T) {
 		store := mockOzoneStore{}
 		sensors := mockSensors{}
 		store.entry.Running = true
-		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors)
+		mctx := monitor.InitializeMonitorContext(nil, &store, &sensors, config.Config{})
 		h := NewHandler(&store, &sensors, mctx)
 
 		go func() {
@@ -121,16 +135,23 @@ func TestOzoneGet(t *testing.T) {
 }
 
 type mockOzoneStore struct {
-	entry database.Ozone
-	err   *error
+	entry    database.Ozone
+	plunge   database.Plunge
+	schedule database.OzoneSchedule
+	created  database.CreateOzoneScheduleParams
+	runs     []database.OzoneScheduleRun
+	runsArg  database.GetOzoneScheduleRunsParams
+	deleted  int64
+	storeErr error
+	err      *error
 }
 
 func (m *mockOzoneStore) SetError(err error) {
 	m.err = &err
 }
 
-func (m *mockOzoneStore) SaveTemperature(ctx context.Context, arg database.SaveTemperatureParams) (database.Temperature, error) {
-	return database.Temperature{}, nil
+func (m *mockOzoneStore) SaveTemperatureReading(ctx context.Context, arg database.SaveTemperatureReadingParams) (database.TemperatureReading, error) {
+	return database.TemperatureReading{}, nil
 }
 
 func (m *mockOzoneStore) GetLatestOzoneEntry(ctx context.Context) (database.Ozone, error) {
@@ -167,11 +188,11 @@ func (m *mockOzoneStore) UpdateOzoneEntryStatus(ctx context.Context, arg databas
 	return m.entry, nil
 }
 
-func (m *mockOzoneStore) GetLatestLeakDetected(ctx context.Context) (database.Leak, error) {
-	return database.Leak{}, nil
+func (m *mockOzoneStore) GetActiveLeak(ctx context.Context, sensorID string) (database.Leak, error) {
+	return database.Leak{}, sql.ErrNoRows
 }
 
-func (m *mockOzoneStore) CreateLeakDetected(ctx context.Context, detectedAt time.Time) (database.Leak, error) {
+func (m *mockOzoneStore) CreateLeakDetected(ctx context.Context, arg database.CreateLeakDetectedParams) (database.Leak, error) {
 	return database.Leak{}, nil
 }
 
@@ -179,38 +200,173 @@ func (m *mockOzoneStore) ClearDetectedLeak(ctx context.Context, id uuid.UUID) (d
 	return database.Leak{}, nil
 }
 
+func (m *mockOzoneStore) ClearSensorLeaks(ctx context.Context, sensorID string) ([]database.Leak, error) {
+	return nil, nil
+}
+
+func (m *mockOzoneStore) RollupTemperatureReadings(ctx context.Context, readAt time.Time) (database.RollupTemperatureReadingsRow, error) {
+	return database.RollupTemperatureReadingsRow{}, nil
+}
+
+func (m *mockOzoneStore) PruneTemperatureRollups(ctx context.Context, bucket time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockOzoneStore) CreateRetentionRun(ctx context.Context, arg database.CreateRetentionRunParams) (database.RetentionRun, error) {
+	return database.RetentionRun{}, nil
+}
+
+func (m *mockOzoneStore) GetEnabledAlertRules(ctx context.Context) ([]database.AlertRule, error) {
+	return []database.AlertRule{}, nil
+}
+
+func (m *mockOzoneStore) MarkAlertRuleTriggered(ctx context.Context, arg database.MarkAlertRuleTriggeredParams) error {
+	return nil
+}
+
+func (m *mockOzoneStore) GetLatestThermostatSettings(ctx context.Context) (database.ThermostatSetting, error) {
+	return database.ThermostatSetting{}, sql.ErrNoRows
+}
+
+func (m *mockOzoneStore) GetLatestPlunge(ctx context.Context) (database.Plunge, error) {
+	if !m.plunge.Running {
+		return database.Plunge{}, sql.ErrNoRows
+	}
+
+	return m.plunge, nil
+}
+
+func (m *mockOzoneStore) StopPlunge(ctx context.Context, arg database.StopPlungeParams) (database.Plunge, error) {
+	m.plunge.Running = false
+	return m.plunge, nil
+}
+
+func (m *mockOzoneStore) SavePlungeTemperature(ctx context.Context, arg database.SavePlungeTemperatureParams) (database.PlungeTemperature, error) {
+	return database.PlungeTemperature{}, nil
+}
+
+func (m *mockOzoneStore) GetLastPlungeTemperature(ctx context.Context, arg database.GetLastPlungeTemperatureParams) (database.PlungeTemperature, error) {
+	return database.PlungeTemperature{}, sql.ErrNoRows
+}
+
+func (m *mockOzoneStore) UpdatePlungeTemperatureSummary(ctx context.Context, plungeID uuid.UUID) (database.Plunge, error) {
+	return m.plunge, nil
+}
+
+func (m *mockOzoneStore) RecordPlungePhase(ctx context.Context, arg database.RecordPlungePhaseParams) error {
+	return nil
+}
+
+func (m *mockOzoneStore) GetSubscribedUsers(ctx context.Context, eventType string) ([]database.User, error) {
+	return []database.User{}, nil
+}
+
+func (m *mockOzoneStore) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockOzoneStore) GetPendingNotifications(ctx context.Context, arg database.GetPendingNotificationsParams) ([]database.NotificationOutbox, error) {
+	return []database.NotificationOutbox{}, nil
+}
+
+func (m *mockOzoneStore) MarkNotificationSent(ctx context.Context, arg database.MarkNotificationSentParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockOzoneStore) MarkNotificationFailed(ctx context.Context, arg database.MarkNotificationFailedParams) (database.NotificationOutbox, error) {
+	return database.NotificationOutbox{}, nil
+}
+
+func (m *mockOzoneStore) GetLeak(ctx context.Context, id uuid.UUID) (database.Leak, error) {
+	return database.Leak{}, sql.ErrNoRows
+}
+
+func (m *mockOzoneStore) AcknowledgeLeak(ctx context.Context, arg database.AcknowledgeLeakParams) (database.Leak, error) {
+	return database.Leak{}, nil
+}
+
+func (m *mockOzoneStore) GetUnacknowledgedLeaks(ctx context.Context) ([]database.Leak, error) {
+	return nil, nil
+}
+
+func (m *mockOzoneStore) GetEnabledOzoneSchedules(ctx context.Context) ([]database.OzoneSchedule, error) {
+	return []database.OzoneSchedule{}, nil
+}
+
+func (m *mockOzoneStore) CreateOzoneScheduleRun(ctx context.Context, arg database.CreateOzoneScheduleRunParams) (database.OzoneScheduleRun, error) {
+	return database.OzoneScheduleRun{}, nil
+}
+
+func (m *mockOzoneStore) GetOzoneSchedules(ctx context.Context) ([]database.OzoneSchedule, error) {
+	return []database.OzoneSchedule{m.schedule}, m.storeErr
+}
+
+func (m *mockOzoneStore) GetOzoneSchedule(ctx context.Context, id uuid.UUID) (database.OzoneSchedule, error) {
+	return m.schedule, m.storeErr
+}
+
+func (m *mockOzoneStore) CreateOzoneSchedule(ctx context.Context, arg database.CreateOzoneScheduleParams) (database.OzoneSchedule, error) {
+	m.created = arg
+	return m.schedule, m.storeErr
+}
+
+func (m *mockOzoneStore) UpdateOzoneSchedule(ctx context.Context, arg database.UpdateOzoneScheduleParams) (database.OzoneSchedule, error) {
+	return m.schedule, m.storeErr
+}
+
+func (m *mockOzoneStore) DeleteOzoneSchedule(ctx context.Context, id uuid.UUID) (int64, error) {
+	return m.deleted, m.storeErr
+}
+
+func (m *mockOzoneStore) GetOzoneScheduleRuns(ctx context.Context, arg database.GetOzoneScheduleRunsParams) ([]database.OzoneScheduleRun, error) {
+	m.runsArg = arg
+	return m.runs, m.storeErr
+}
+
 type mockSensors struct {
 	temperatures []sensor.TemperatureReading
 }
 
-func (m *mockSensors) ReadTemperatures() []sensor.TemperatureReading {
+func (m *mockSensors) ReadTemperatures(ctx context.Context) []sensor.TemperatureReading {
 	return m.temperatures
 }
 
-func (m *mockSensors) ReadRoomAndWaterTemperature() (sensor.TemperatureReading, sensor.TemperatureReading) {
-	return sensor.TemperatureReading{}, sensor.TemperatureReading{}
+func (m *mockSensors) ReadLeakSensors(ctx context.Context) []sensor.LeakReading {
+	return nil
 }
 
-func (m *mockSensors) IsLeakPresent() (bool, error) {
-	return false, nil
+func (m *mockSensors) TurnOzoneOn(ctx context.Context) error {
+	return nil
 }
 
-func (m *mockSensors) TurnOzoneOn() error {
+func (m *mockSensors) TurnOzoneOff(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) TurnOzoneOff() error {
+func (m *mockSensors) IsPumpOn(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *mockSensors) TurnPumpOn(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) IsPumpOn() (bool, error) {
-	return true, nil
+func (m *mockSensors) TurnPumpOff(ctx context.Context) error {
+	return nil
 }
 
-func (m *mockSensors) TurnPumpOn() error {
+func (m *mockSensors) IsDeviceOn(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockSensors) TurnDeviceOn(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *mockSensors) TurnPumpOff() error {
+func (m *mockSensors) TurnDeviceOff(ctx context.Context, id string) error {
 	return nil
 }
+
+func (m *mockSensors) DeviceHealth() []sensor.DeviceHealth {
+	return []sensor.DeviceHealth{}
+}
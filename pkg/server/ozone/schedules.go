@@ -0,0 +1,243 @@
+package ozone
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/KyleBrandon/plunger-server/internal/cron"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidScheduleBody = errors.New("Invalid body for ozone schedule")
+	ErrScheduleNameMissing = errors.New("ozone schedule name is required")
+	ErrInvalidDuration     = errors.New("'duration_minutes' can not be negative")
+)
+
+func (h *Handler) handlerOzoneSchedulesGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneSchedulesGet")
+	defer slog.Debug("<<handlerOzoneSchedulesGet")
+
+	dbSchedules, err := h.store.GetOzoneSchedules(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the ozone schedules", err)
+		return
+	}
+
+	response := make([]OzoneScheduleResponse, 0, len(dbSchedules))
+	for _, db := range dbSchedules {
+		response = append(response, databaseToOzoneSchedule(db))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handlerOzoneScheduleGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneScheduleGet")
+	defer slog.Debug("<<handlerOzoneScheduleGet")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid ozone schedule id", err)
+		return
+	}
+
+	dbSchedule, err := h.store.GetOzoneSchedule(r.Context(), id)
+	if err != nil {
+		respondWithScheduleError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseToOzoneSchedule(dbSchedule))
+}
+
+func (h *Handler) handlerOzoneScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneScheduleCreate")
+	defer slog.Debug("<<handlerOzoneScheduleCreate")
+
+	request, err := parseOzoneScheduleRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	arg := database.CreateOzoneScheduleParams{
+		Name:            request.Name,
+		CronExpression:  request.CronExpression,
+		DurationMinutes: int32(request.DurationMinutes),
+		Enabled:         request.Enabled == nil || *request.Enabled,
+	}
+
+	dbSchedule, err := h.store.CreateOzoneSchedule(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to create the ozone schedule", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, databaseToOzoneSchedule(dbSchedule))
+}
+
+func (h *Handler) handlerOzoneScheduleUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneScheduleUpdate")
+	defer slog.Debug("<<handlerOzoneScheduleUpdate")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid ozone schedule id", err)
+		return
+	}
+
+	request, err := parseOzoneScheduleRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	arg := database.UpdateOzoneScheduleParams{
+		ID:              id,
+		Name:            request.Name,
+		CronExpression:  request.CronExpression,
+		DurationMinutes: int32(request.DurationMinutes),
+		Enabled:         request.Enabled == nil || *request.Enabled,
+	}
+
+	dbSchedule, err := h.store.UpdateOzoneSchedule(r.Context(), arg)
+	if err != nil {
+		respondWithScheduleError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseToOzoneSchedule(dbSchedule))
+}
+
+func (h *Handler) handlerOzoneScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneScheduleDelete")
+	defer slog.Debug("<<handlerOzoneScheduleDelete")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid ozone schedule id", err)
+		return
+	}
+
+	count, err := h.store.DeleteOzoneSchedule(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to delete the ozone schedule", err)
+		return
+	}
+
+	if count == 0 {
+		respondWithScheduleError(w, sql.ErrNoRows)
+		return
+	}
+
+	utils.RespondWithNoContent(w, http.StatusNoContent)
+}
+
+// handlerOzoneScheduleRunsGet returns the most recent runs of a schedule, including the ones that were skipped.
+func (h *Handler) handlerOzoneScheduleRunsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerOzoneScheduleRunsGet")
+	defer slog.Debug("<<handlerOzoneScheduleRunsGet")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid ozone schedule id", err)
+		return
+	}
+
+	limit := DefaultScheduleRunsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'limit' parameter", err)
+			return
+		}
+	}
+
+	arg := database.GetOzoneScheduleRunsParams{
+		ScheduleID: id,
+		Limit:      int32(limit),
+	}
+
+	dbRuns, err := h.store.GetOzoneScheduleRuns(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the ozone schedule runs", err)
+		return
+	}
+
+	response := make([]OzoneScheduleRunResponse, 0, len(dbRuns))
+	for _, db := range dbRuns {
+		run := OzoneScheduleRunResponse{
+			ID:           db.ID,
+			ScheduleID:   db.ScheduleID,
+			ScheduledFor: db.ScheduledFor,
+			Started:      db.Started,
+		}
+
+		if db.SkipReason.Valid {
+			run.SkipReason = db.SkipReason.String
+		}
+
+		response = append(response, run)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// parseOzoneScheduleRequest reads and validates the schedule in the body.
+func parseOzoneScheduleRequest(r *http.Request) (OzoneScheduleRequest, error) {
+	var request OzoneScheduleRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, ErrInvalidScheduleBody
+	}
+
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return request, ErrInvalidScheduleBody
+	}
+
+	if len(request.Name) == 0 {
+		return request, ErrScheduleNameMissing
+	}
+
+	if request.DurationMinutes < 0 {
+		return request, ErrInvalidDuration
+	}
+
+	if _, err := cron.Parse(request.CronExpression); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+func respondWithScheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the ozone schedule", err)
+		return
+	}
+
+	utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the ozone schedule", err)
+}
+
+func databaseToOzoneSchedule(db database.OzoneSchedule) OzoneScheduleResponse {
+	return OzoneScheduleResponse{
+		ID:              db.ID,
+		CreatedAt:       db.CreatedAt,
+		UpdatedAt:       db.UpdatedAt,
+		Name:            db.Name,
+		CronExpression:  db.CronExpression,
+		DurationMinutes: db.DurationMinutes,
+		Enabled:         db.Enabled,
+	}
+}
@@ -0,0 +1,121 @@
+package ozone
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/cron"
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+func TestOzoneScheduleCreate(t *testing.T) {
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{}, &mockSensors{}, nil)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/schedules", bytes.NewBufferString("{"), h.handlerOzoneScheduleCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, ErrInvalidScheduleBody.Error())
+	})
+
+	t.Run("should fail with an invalid cron expression", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{}, &mockSensors{}, nil)
+
+		body := `{"name": "nightly", "cron_expression": "0 25 * * *"}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/schedules", bytes.NewBufferString(body), h.handlerOzoneScheduleCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, cron.ErrInvalidExpression.Error())
+	})
+
+	t.Run("should fail with a negative duration", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{}, &mockSensors{}, nil)
+
+		body := `{"name": "nightly", "cron_expression": "0 2 * * *", "duration_minutes": -5}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/schedules", bytes.NewBufferString(body), h.handlerOzoneScheduleCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, ErrInvalidDuration.Error())
+	})
+
+	t.Run("should create an enabled schedule by default", func(t *testing.T) {
+		store := mockOzoneStore{}
+		h := NewHandler(&store, &mockSensors{}, nil)
+
+		body := `{"name": "nightly", "cron_expression": "0 2 * * *", "duration_minutes": 45}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/ozone/schedules", bytes.NewBufferString(body), h.handlerOzoneScheduleCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if !store.created.Enabled || store.created.CronExpression != "0 2 * * *" || store.created.DurationMinutes != 45 {
+			t.Errorf("unexpected schedule %+v", store.created)
+		}
+	})
+}
+
+func TestOzoneScheduleGet(t *testing.T) {
+	t.Run("should fail with an invalid id", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{}, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/ozone/schedules/{id}", map[string]string{"id": "not-a-uuid"}, nil, h.handlerOzoneScheduleGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail if the schedule does not exist", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{storeErr: sql.ErrNoRows}, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/ozone/schedules/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handlerOzoneScheduleGet)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+}
+
+func TestOzoneScheduleDelete(t *testing.T) {
+	t.Run("should fail if the schedule does not exist", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{deleted: 0}, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodDelete, "/v1/ozone/schedules/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handlerOzoneScheduleDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should delete the schedule", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{deleted: 1}, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodDelete, "/v1/ozone/schedules/{id}", map[string]string{"id": uuid.NewString()}, nil, h.handlerOzoneScheduleDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNoContent)
+	})
+}
+
+func TestOzoneScheduleRuns(t *testing.T) {
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		h := NewHandler(&mockOzoneStore{}, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/ozone/schedules/{id}/runs?limit=0", map[string]string{"id": uuid.NewString()}, nil, h.handlerOzoneScheduleRunsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should return the skipped runs with their reason", func(t *testing.T) {
+		store := mockOzoneStore{
+			runs: []database.OzoneScheduleRun{
+				{Started: false, SkipReason: sql.NullString{Valid: true, String: "a plunge is running"}},
+			},
+		}
+		h := NewHandler(&store, &mockSensors{}, nil)
+
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/ozone/schedules/{id}/runs", map[string]string{"id": uuid.NewString()}, nil, h.handlerOzoneScheduleRunsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.runsArg.Limit != DefaultScheduleRunsLimit {
+			t.Errorf("expected limit %d, got %d", DefaultScheduleRunsLimit, store.runsArg.Limit)
+		}
+
+		var runs []OzoneScheduleRunResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &runs); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+
+		if len(runs) != 1 || runs[0].Started || runs[0].SkipReason != "a plunge is running" {
+			t.Errorf("unexpected runs %+v", runs)
+		}
+	})
+}
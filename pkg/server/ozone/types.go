@@ -10,7 +10,7 @@ import (
 	"github.com/google/uuid"
 )
 
-const DefaultOzoneDurationMinutes = "60"
+const DefaultScheduleRunsLimit = 50
 
 type (
 	OzoneResult struct {
@@ -22,6 +22,31 @@ type (
 		StatusMessage    string    `json:"status_message"`
 	}
 
+	OzoneScheduleRequest struct {
+		Name            string `json:"name"`
+		CronExpression  string `json:"cron_expression"`
+		DurationMinutes int    `json:"duration_minutes"`
+		Enabled         *bool  `json:"enabled,omitempty"`
+	}
+
+	OzoneScheduleResponse struct {
+		ID              uuid.UUID `json:"id"`
+		CreatedAt       time.Time `json:"created_at"`
+		UpdatedAt       time.Time `json:"updated_at"`
+		Name            string    `json:"name"`
+		CronExpression  string    `json:"cron_expression"`
+		DurationMinutes int32     `json:"duration_minutes"`
+		Enabled         bool      `json:"enabled"`
+	}
+
+	OzoneScheduleRunResponse struct {
+		ID           uuid.UUID `json:"id"`
+		ScheduleID   uuid.UUID `json:"schedule_id"`
+		ScheduledFor time.Time `json:"scheduled_for"`
+		Started      bool      `json:"started"`
+		SkipReason   string    `json:"skip_reason,omitempty"`
+	}
+
 	Handler struct {
 		store  OzoneStore
 		sensor sensor.Sensors
@@ -33,5 +58,11 @@ type (
 		StartOzoneGenerator(ctx context.Context, arg database.StartOzoneGeneratorParams) (database.Ozone, error)
 		StopOzoneGenerator(ctx context.Context, id uuid.UUID) (database.Ozone, error)
 		UpdateOzoneEntryStatus(ctx context.Context, arg database.UpdateOzoneEntryStatusParams) (database.Ozone, error)
+		GetOzoneSchedules(ctx context.Context) ([]database.OzoneSchedule, error)
+		GetOzoneSchedule(ctx context.Context, id uuid.UUID) (database.OzoneSchedule, error)
+		CreateOzoneSchedule(ctx context.Context, arg database.CreateOzoneScheduleParams) (database.OzoneSchedule, error)
+		UpdateOzoneSchedule(ctx context.Context, arg database.UpdateOzoneScheduleParams) (database.OzoneSchedule, error)
+		DeleteOzoneSchedule(ctx context.Context, id uuid.UUID) (int64, error)
+		GetOzoneScheduleRuns(ctx context.Context, arg database.GetOzoneScheduleRunsParams) ([]database.OzoneScheduleRun, error)
 	}
 )
@@ -0,0 +1,394 @@
+package plunges
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidGoalBody   = errors.New("Invalid body for plunge goal")
+	ErrInvalidGoalKind   = errors.New("goal kind must be one of 'minutes', 'dose' or 'sessions'")
+	ErrInvalidGoalTarget = errors.New("'target' of a goal must be greater than zero")
+	ErrInvalidGoalTemp   = errors.New("'below_temp' of a goal must be between 32 and 110 degrees Fahrenheit")
+)
+
+func (h *Handler) handleGoalsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleGoalsGet")
+	defer slog.Debug("<<handleGoalsGet")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	goals, err := TrackGoals(r.Context(), h.store, user.ID, time.Now(), time.Local)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge goals", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, goals)
+}
+
+func (h *Handler) handleGoalCreate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleGoalCreate")
+	defer slog.Debug("<<handleGoalCreate")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	request, err := parseGoalRequest(r, h.coldDoseThreshold)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	goal, err := h.store.CreatePlungeGoal(r.Context(), database.CreatePlungeGoalParams{
+		UserID:    user.ID,
+		Name:      request.Name,
+		Kind:      request.Kind,
+		Target:    request.Target,
+		BelowTemp: *request.BelowTemp,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to create the plunge goal", err)
+		return
+	}
+
+	h.respondWithGoal(w, r, goal, http.StatusCreated)
+}
+
+// handleGoalUpdate replaces one of the user's goals, its streaks are still counted from when it was created.
+func (h *Handler) handleGoalUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleGoalUpdate")
+	defer slog.Debug("<<handleGoalUpdate")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge goal id", err)
+		return
+	}
+
+	request, err := parseGoalRequest(r, h.coldDoseThreshold)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	goal, err := h.store.UpdatePlungeGoal(r.Context(), database.UpdatePlungeGoalParams{
+		ID:        id,
+		UserID:    user.ID,
+		Name:      request.Name,
+		Kind:      request.Kind,
+		Target:    request.Target,
+		BelowTemp: *request.BelowTemp,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the plunge goal", err)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to update the plunge goal", err)
+		return
+	}
+
+	h.respondWithGoal(w, r, goal, http.StatusOK)
+}
+
+func (h *Handler) handleGoalDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleGoalDelete")
+	defer slog.Debug("<<handleGoalDelete")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge goal id", err)
+		return
+	}
+
+	count, err := h.store.DeletePlungeGoal(r.Context(), database.DeletePlungeGoalParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to delete the plunge goal", err)
+		return
+	}
+
+	if count == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the plunge goal", nil)
+		return
+	}
+
+	utils.RespondWithNoContent(w, http.StatusNoContent)
+}
+
+// respondWithGoal responds with the goal and its progress this week.
+func (h *Handler) respondWithGoal(w http.ResponseWriter, r *http.Request, goal database.PlungeGoal, code int) {
+	now := time.Now()
+	from := goalFirstWeek(goal, now, time.Local)
+
+	dbPlunges, samples, err := readGoalPlunges(r.Context(), h.store, goal.UserID, from, now)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge history", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, code, trackGoal(goal, dbPlunges, samples, now, time.Local))
+}
+
+// parseGoalRequest reads and validates the goal in the body, a goal without a temperature is measured below defaultBelow.
+func parseGoalRequest(r *http.Request, defaultBelow float64) (PlungeGoalRequest, error) {
+	var request PlungeGoalRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, ErrInvalidGoalBody
+	}
+
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return request, ErrInvalidGoalBody
+	}
+
+	switch request.Kind {
+	case GOAL_MINUTES, GOAL_DOSE, GOAL_SESSIONS:
+	default:
+		return request, ErrInvalidGoalKind
+	}
+
+	if request.Target <= 0 {
+		return request, ErrInvalidGoalTarget
+	}
+
+	if request.BelowTemp == nil {
+		request.BelowTemp = &defaultBelow
+	}
+
+	if *request.BelowTemp < MinGoalTempF || *request.BelowTemp > MaxGoalTempF {
+		return request, ErrInvalidGoalTemp
+	}
+
+	return request, nil
+}
+
+// TrackGoals returns the user's goals with their progress in the week of now and their streaks.
+// Weeks start on Monday in loc.
+func TrackGoals(ctx context.Context, store GoalStore, userID uuid.UUID, now time.Time, loc *time.Location) ([]GoalStatus, error) {
+	goals, err := store.GetPlungeGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]GoalStatus, 0, len(goals))
+	if len(goals) == 0 {
+		return status, nil
+	}
+
+	from := weekStart(now, loc)
+	for _, goal := range goals {
+		if first := goalFirstWeek(goal, now, loc); first.Before(from) {
+			from = first
+		}
+	}
+
+	dbPlunges, samples, err := readGoalPlunges(ctx, store, userID, from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, goal := range goals {
+		status = append(status, trackGoal(goal, dbPlunges, samples, now, loc))
+	}
+
+	return status, nil
+}
+
+// readGoalPlunges reads the user's completed plunges that started between from and to with their temperature samples.
+func readGoalPlunges(ctx context.Context, store GoalStore, userID uuid.UUID, from, to time.Time) ([]database.Plunge, map[uuid.UUID][]database.PlungeTemperature, error) {
+	dbPlunges, err := store.GetCompletedPlunges(ctx, database.GetCompletedPlungesParams{
+		UserID:   userID,
+		FromTime: from.UTC(),
+		ToTime:   to.UTC(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbSamples, err := store.GetCompletedPlungeTemperatures(ctx, database.GetCompletedPlungeTemperaturesParams{
+		UserID:   userID,
+		FromTime: from.UTC(),
+		ToTime:   to.UTC(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dbPlunges, groupSamples(dbSamples), nil
+}
+
+// trackGoal totals the goal for each week from the week it was created, the plunges must include every week since then.
+func trackGoal(goal database.PlungeGoal, dbPlunges []database.Plunge, samples map[uuid.UUID][]database.PlungeTemperature, now time.Time, loc *time.Location) GoalStatus {
+	first := goalFirstWeek(goal, now, loc)
+	thisWeek := weekStart(now, loc)
+
+	totals := make(map[int64]float64)
+	for _, p := range dbPlunges {
+		week := weekStart(p.StartTime.Time, loc)
+		if week.Before(first) {
+			continue
+		}
+
+		dose := coldExposure(p, samples[p.ID], goal.BelowTemp)
+		switch goal.Kind {
+		case GOAL_MINUTES:
+			totals[week.Unix()] += dose.MinutesBelow
+		case GOAL_DOSE:
+			totals[week.Unix()] += dose.DegreeMinutes
+		case GOAL_SESSIONS:
+			if dose.MinutesBelow > 0 {
+				totals[week.Unix()]++
+			}
+		}
+	}
+
+	var current, longest int
+	for week := first; week.Before(thisWeek); week = week.AddDate(0, 0, 7) {
+		if totals[week.Unix()] >= goal.Target {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+
+	// this week only adds to the streak once the goal is met, until then the streak through last week stands
+	progress := totals[thisWeek.Unix()]
+	completed := progress >= goal.Target
+	if completed {
+		current++
+		longest = max(longest, current)
+	}
+
+	return GoalStatus{
+		ID:                 goal.ID,
+		UserID:             goal.UserID,
+		Name:               goal.Name,
+		Kind:               goal.Kind,
+		Target:             goal.Target,
+		BelowTemp:          goal.BelowTemp,
+		WeekStart:          thisWeek,
+		Progress:           progress,
+		Percent:            min(progress/goal.Target, 1) * 100,
+		Completed:          completed,
+		CurrentStreakWeeks: current,
+		LongestStreakWeeks: longest,
+	}
+}
+
+// coldExposure measures the time the water was below the threshold during a completed plunge and the
+// cold dose. Each temperature sample holds until the next one, and the first one from the start of the
+// plunge. A plunge without samples uses its average or starting water temperature for the whole plunge.
+func coldExposure(p database.Plunge, samples []database.PlungeTemperature, threshold float64) ColdDose {
+	dose := ColdDose{Threshold: threshold}
+	if !p.StartTime.Valid || !p.EndTime.Valid {
+		return dose
+	}
+
+	type reading struct {
+		at   time.Time
+		temp float64
+	}
+
+	start, end := p.StartTime.Time, p.EndTime.Time
+	readings := make([]reading, 0, len(samples))
+	for _, s := range samples {
+		t, err := strconv.ParseFloat(s.WaterTemp.String, 64)
+		if s.WaterTemp.Valid && err == nil {
+			readings = append(readings, reading{at: s.ReadAt, temp: t})
+		}
+	}
+
+	if len(readings) == 0 {
+		t, ok := plungeWaterTemp(p)
+		if !ok {
+			return dose
+		}
+
+		readings = append(readings, reading{at: start, temp: t})
+	}
+
+	for i, r := range readings {
+		from := r.at
+		if i == 0 || from.Before(start) {
+			from = start
+		}
+
+		to := end
+		if i+1 < len(readings) && readings[i+1].at.Before(end) {
+			to = readings[i+1].at
+		}
+
+		if !to.After(from) || r.temp >= threshold {
+			continue
+		}
+
+		minutes := to.Sub(from).Minutes()
+		dose.MinutesBelow += minutes
+		dose.DegreeMinutes += (threshold - r.temp) * minutes
+	}
+
+	return dose
+}
+
+// groupSamples groups the temperature samples by their plunge.
+func groupSamples(dbSamples []database.PlungeTemperature) map[uuid.UUID][]database.PlungeTemperature {
+	samples := make(map[uuid.UUID][]database.PlungeTemperature)
+	for _, s := range dbSamples {
+		samples[s.PlungeID] = append(samples[s.PlungeID], s)
+	}
+
+	return samples
+}
+
+// goalFirstWeek is the week the goal was created, or MaxGoalWeeks before the week of now if that is later.
+func goalFirstWeek(goal database.PlungeGoal, now time.Time, loc *time.Location) time.Time {
+	first := weekStart(goal.CreatedAt, loc)
+	if earliest := weekStart(now, loc).AddDate(0, 0, -7*(MaxGoalWeeks-1)); first.Before(earliest) {
+		return earliest
+	}
+
+	return first
+}
+
+// weekStart returns midnight of the Monday of the week t falls in in loc.
+func weekStart(t time.Time, loc *time.Location) time.Time {
+	d := day(t, loc)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
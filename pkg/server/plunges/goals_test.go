@@ -0,0 +1,227 @@
+package plunges
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// testPlunge is a completed plunge with an average water temperature.
+func testPlunge(start time.Time, duration time.Duration, avgWaterTemp string) database.Plunge {
+	return database.Plunge{
+		ID:           uuid.New(),
+		StartTime:    sql.NullTime{Time: start, Valid: true},
+		EndTime:      sql.NullTime{Time: start.Add(duration), Valid: true},
+		AvgWaterTemp: avgWaterTemp,
+	}
+}
+
+func TestColdExposure(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+	t.Run("should hold each sample until the next one", func(t *testing.T) {
+		p := testPlunge(start, 3*time.Minute, "0.0")
+		samples := []database.PlungeTemperature{
+			{ReadAt: start.Add(5 * time.Second), WaterTemp: sql.NullString{String: "48.00", Valid: true}},
+			{ReadAt: start.Add(time.Minute), WaterTemp: sql.NullString{}},
+			{ReadAt: start.Add(2 * time.Minute), WaterTemp: sql.NullString{String: "52.00", Valid: true}},
+		}
+
+		dose := coldExposure(p, samples, 50)
+
+		// 48°F from the start until 52°F was read at 2 minutes
+		if dose.MinutesBelow != 2 || dose.DegreeMinutes != 4 {
+			t.Errorf("unexpected dose %+v", dose)
+		}
+	})
+
+	t.Run("should use the average temperature without samples", func(t *testing.T) {
+		dose := coldExposure(testPlunge(start, 3*time.Minute, "45.5"), nil, 50)
+
+		if dose.MinutesBelow != 3 || dose.DegreeMinutes != 13.5 || dose.Threshold != 50 {
+			t.Errorf("unexpected dose %+v", dose)
+		}
+	})
+
+	t.Run("should not measure a running plunge", func(t *testing.T) {
+		p := testPlunge(start, 3*time.Minute, "45.5")
+		p.EndTime = sql.NullTime{}
+
+		if dose := coldExposure(p, nil, 50); dose.MinutesBelow != 0 {
+			t.Errorf("unexpected dose %+v", dose)
+		}
+	})
+}
+
+func TestTrackGoal(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC)
+	thisWeek := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	goal := database.PlungeGoal{ID: uuid.New(), Kind: GOAL_MINUTES, Target: 11, BelowTemp: 50, CreatedAt: thisWeek.AddDate(0, 0, -28)}
+
+	t.Run("should count the streak through last week until this week is met", func(t *testing.T) {
+		dbPlunges := []database.Plunge{
+			testPlunge(thisWeek.AddDate(0, 0, -21), 12*time.Minute, "45.0"),
+			testPlunge(thisWeek.AddDate(0, 0, -14), 6*time.Minute, "45.0"),
+			testPlunge(thisWeek.AddDate(0, 0, -13), 6*time.Minute, "45.0"),
+			testPlunge(thisWeek.AddDate(0, 0, -7), 11*time.Minute, "55.0"),
+			testPlunge(thisWeek.AddDate(0, 0, 1), 4*time.Minute, "45.0"),
+		}
+
+		status := trackGoal(goal, dbPlunges, nil, now, time.UTC)
+
+		if status.Progress != 4 || status.Completed || !status.WeekStart.Equal(thisWeek) {
+			t.Errorf("unexpected progress %+v", status)
+		}
+
+		// last week's plunges were too warm to count
+		if status.CurrentStreakWeeks != 0 || status.LongestStreakWeeks != 2 {
+			t.Errorf("unexpected streaks %d and %d", status.CurrentStreakWeeks, status.LongestStreakWeeks)
+		}
+	})
+
+	t.Run("should complete the goal this week", func(t *testing.T) {
+		dbPlunges := []database.Plunge{
+			testPlunge(thisWeek.AddDate(0, 0, -7), 11*time.Minute, "45.0"),
+			testPlunge(thisWeek, 8*time.Minute, "45.0"),
+			testPlunge(thisWeek.AddDate(0, 0, 1), 4*time.Minute, "45.0"),
+		}
+
+		status := trackGoal(goal, dbPlunges, nil, now, time.UTC)
+
+		if !status.Completed || status.Percent != 100 || status.CurrentStreakWeeks != 2 || status.LongestStreakWeeks != 2 {
+			t.Errorf("unexpected progress %+v", status)
+		}
+	})
+
+	t.Run("should not count plunges before the goal was created", func(t *testing.T) {
+		goal := goal
+		goal.Kind = GOAL_SESSIONS
+		goal.Target = 1
+		goal.CreatedAt = thisWeek.Add(time.Hour)
+
+		dbPlunges := []database.Plunge{testPlunge(thisWeek.AddDate(0, 0, -7), 3*time.Minute, "45.0")}
+
+		status := trackGoal(goal, dbPlunges, nil, now, time.UTC)
+
+		if status.Completed || status.LongestStreakWeeks != 0 {
+			t.Errorf("unexpected progress %+v", status)
+		}
+	})
+}
+
+func TestGoalCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"should fail with an invalid body", "{", ErrInvalidGoalBody.Error()},
+		{"should fail with an invalid kind", `{"kind": "hours", "target": 1, "below_temp": 50}`, ErrInvalidGoalKind.Error()},
+		{"should fail without a target", `{"kind": "minutes", "below_temp": 50}`, ErrInvalidGoalTarget.Error()},
+		{"should fail with a temperature below freezing", `{"kind": "minutes", "target": 11, "below_temp": 0}`, ErrInvalidGoalTemp.Error()},
+		{"should fail with a temperature that is too hot", `{"kind": "minutes", "target": 11, "below_temp": 212}`, ErrInvalidGoalTemp.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+			rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/goals", nil, bytes.NewBufferString(test.body), handler.handleGoalCreate)
+			utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+			utils.TestExpectedMessage(t, rr, test.message)
+		})
+	}
+
+	t.Run("should create the goal for the user", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		body := `{"name": "Søberg", "kind": "minutes", "target": 11, "below_temp": 50}`
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/goals", nil, bytes.NewBufferString(body), handler.handleGoalCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		var resp GoalStatus
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if plungeStore.goal.UserID != testUser.ID || resp.Target != 11 || resp.Completed {
+			t.Errorf("unexpected goal %+v", resp)
+		}
+	})
+
+	t.Run("should measure the goal below the cold dose threshold by default", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		body := `{"kind": "sessions", "target": 3}`
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/goals", nil, bytes.NewBufferString(body), handler.handleGoalCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if plungeStore.goal.BelowTemp != testColdDoseThreshold {
+			t.Errorf("expected the goal to be measured below %v, got %v", testColdDoseThreshold, plungeStore.goal.BelowTemp)
+		}
+	})
+}
+
+func TestGoalUpdate(t *testing.T) {
+	t.Run("should not update another user's goal", func(t *testing.T) {
+		plungeStore := mockPlungeStore{goals: []database.PlungeGoal{{ID: uuid.New(), UserID: uuid.New()}}}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.goals[0].ID.String()
+		body := `{"kind": "sessions", "target": 3, "below_temp": 55}`
+		rr := authorizedRequestWithBody(t, http.MethodPut, "/v1/plunges/goals/"+id, map[string]string{"id": id}, bytes.NewBufferString(body), handler.handleGoalUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+}
+
+func TestGoalDelete(t *testing.T) {
+	t.Run("should fail for an unknown goal", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		rr := authorizedRequest(t, http.MethodDelete, "/v1/plunges/goals/"+id, map[string]string{"id": id}, handler.handleGoalDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+
+		if plungeStore.deletedGoal.UserID != testUser.ID {
+			t.Errorf("expected the goal to be deleted for %s, got %s", testUser.ID, plungeStore.deletedGoal.UserID)
+		}
+	})
+}
+
+func TestPlungesStatsColdDose(t *testing.T) {
+	t.Run("should total the cold dose and track the goals", func(t *testing.T) {
+		now := time.Now()
+		plungeStore := mockPlungeStore{
+			plunges: []database.Plunge{testPlunge(weekStart(now, time.Local), 3*time.Minute, "50.0")},
+			goals:   []database.PlungeGoal{{ID: uuid.New(), UserID: testUser.ID, Kind: GOAL_SESSIONS, Target: 1, BelowTemp: 55, CreatedAt: now.AddDate(0, 0, -30)}},
+		}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/stats", nil, handler.handlePlungesStatsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeStatsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.ColdDose.Threshold != testColdDoseThreshold || resp.ColdDose.MinutesBelow != 3 || resp.ColdDose.DegreeMinutes != 30 {
+			t.Errorf("unexpected cold dose %+v", resp.ColdDose)
+		}
+
+		if len(resp.Goals) != 1 || !resp.Goals[0].Completed {
+			t.Errorf("unexpected goals %+v", resp.Goals)
+		}
+	})
+}
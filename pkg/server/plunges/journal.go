@@ -0,0 +1,223 @@
+package plunges
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/heartrate"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+var (
+	ErrInvalidJournalBody = errors.New("Invalid body for plunge journal")
+	ErrInvalidEffort      = errors.New("'perceived_effort' must be between 1 and 10")
+	ErrNotesTooLong       = errors.New("'notes' can't be longer than 2000 characters")
+	ErrInvalidTag         = errors.New("a tag must be between 1 and 32 characters")
+	ErrTooManyTags        = errors.New("a plunge can't have more than 10 tags")
+)
+
+// handlePlungeJournalUpdate replaces the notes, perceived effort and tags of one of the user's plunges.
+func (h *Handler) handlePlungeJournalUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungeJournalUpdate")
+	defer slog.Debug("<<handlePlungeJournalUpdate")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	p, ok := h.userPlunge(w, r, user)
+	if !ok {
+		return
+	}
+
+	request, err := parseJournalRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.UpdatePlungeJournalParams{
+		ID:    p.ID,
+		Notes: request.Notes,
+		Tags:  request.Tags,
+	}
+
+	if request.PerceivedEffort != nil {
+		params.PerceivedEffort = sql.NullInt32{Int32: int32(*request.PerceivedEffort), Valid: true}
+	}
+
+	p, err = h.store.UpdatePlungeJournal(r.Context(), params)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to update the plunge journal", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databasePlungeToPlunge(p))
+}
+
+// handlePlungeHeartRateImport reads a CSV or FIT export from a wearable and stores the heart rate around one
+// of the user's completed plunges, replacing any heart rate that was imported before.
+func (h *Handler) handlePlungeHeartRateImport(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungeHeartRateImport")
+	defer slog.Debug("<<handlePlungeHeartRateImport")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	p, ok := h.userPlunge(w, r, user)
+	if !ok {
+		return
+	}
+
+	if !p.StartTime.Valid || !p.EndTime.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "the plunge is still running", nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxHeartRateImportBytes))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "the heart rate file is too large", err)
+		return
+	}
+
+	defer r.Body.Close()
+
+	samples, err := heartrate.Parse(body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	samples, err = heartrate.Align(samples, p.StartTime.Time, p.EndTime.Time)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows := make([]heartRateRow, 0, len(samples))
+	for _, s := range samples {
+		rows = append(rows, heartRateRow{ReadAt: s.Time.UTC(), BPM: s.BPM})
+	}
+
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to save the heart rate", err)
+		return
+	}
+
+	// the heart rate imported before is kept if the new one can't be saved
+	err = h.store.ExecTx(r.Context(), func(store PlungeStore) error {
+		if err := store.DeletePlungeHeartRates(r.Context(), p.ID); err != nil {
+			return err
+		}
+
+		_, err := store.SavePlungeHeartRates(r.Context(), database.SavePlungeHeartRatesParams{
+			PlungeID: p.ID,
+			Samples:  encoded,
+		})
+		return err
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to save the heart rate", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, summarizeHeartRate(samples, p))
+}
+
+// parseJournalRequest reads and validates the journal in the body.
+// Tags are trimmed and lower cased, and a tag that is repeated is only kept once.
+func parseJournalRequest(r *http.Request) (PlungeJournalRequest, error) {
+	var request PlungeJournalRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, ErrInvalidJournalBody
+	}
+
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return request, ErrInvalidJournalBody
+	}
+
+	if request.PerceivedEffort != nil && (*request.PerceivedEffort < 1 || *request.PerceivedEffort > 10) {
+		return request, ErrInvalidEffort
+	}
+
+	if len([]rune(request.Notes)) > MaxJournalNotesLength {
+		return request, ErrNotesTooLong
+	}
+
+	tags := make([]string, 0, len(request.Tags))
+	for _, tag := range request.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 || len([]rune(tag)) > MaxJournalTagLength {
+			return request, ErrInvalidTag
+		}
+
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > MaxJournalTags {
+		return request, ErrTooManyTags
+	}
+
+	request.Tags = tags
+
+	return request, nil
+}
+
+// summarizeHeartRate converts the samples and summarizes them for the plunge, which must be completed.
+func summarizeHeartRate(samples []heartrate.Sample, p database.Plunge) *PlungeHeartRateResponse {
+	summary := heartrate.Summarize(samples, p.StartTime.Time, p.EndTime.Time)
+
+	response := PlungeHeartRateResponse{
+		RestingBPM: summary.RestingBPM,
+		PeakBPM:    summary.PeakBPM,
+		PeakAt:     summary.PeakAt,
+		AvgBPM:     summary.AvgBPM,
+		Samples:    make([]HeartRateSample, 0, len(samples)),
+	}
+
+	if summary.Recovery != nil {
+		recovery := summary.Recovery.Seconds()
+		response.RecoverySeconds = &recovery
+	}
+
+	for _, s := range samples {
+		response.Samples = append(response.Samples, HeartRateSample{ReadAt: s.Time, BPM: s.BPM})
+	}
+
+	return &response
+}
+
+func databaseHeartRatesToSamples(dbHeartRates []database.PlungeHeartRate) []heartrate.Sample {
+	samples := make([]heartrate.Sample, 0, len(dbHeartRates))
+	for _, dbHeartRate := range dbHeartRates {
+		samples = append(samples, heartrate.Sample{Time: dbHeartRate.ReadAt, BPM: int(dbHeartRate.Bpm)})
+	}
+
+	return samples
+}
+
+// heartRateRow is a sample as it is sent to the database.
+type heartRateRow struct {
+	ReadAt time.Time `json:"read_at"`
+	BPM    int       `json:"bpm"`
+}
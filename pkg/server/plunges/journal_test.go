@@ -0,0 +1,174 @@
+package plunges
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/heartrate"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var journalStart = time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// completedPlunge is a 3 minute plunge that belongs to the test user.
+func completedPlunge() mockPlungeStore {
+	plungeStore := mockPlungeStore{plungeID: uuid.New()}
+	plungeStore.plunge.ID = plungeStore.plungeID
+	plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+	plungeStore.plunge.StartTime = sql.NullTime{Time: journalStart, Valid: true}
+	plungeStore.plunge.EndTime = sql.NullTime{Time: journalStart.Add(3 * time.Minute), Valid: true}
+
+	return plungeStore
+}
+
+func TestPlungeJournalUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"should fail with an invalid body", "{", ErrInvalidJournalBody.Error()},
+		{"should fail with an effort out of range", `{"perceived_effort": 11}`, ErrInvalidEffort.Error()},
+		{"should fail with an empty tag", `{"tags": ["ice", " "]}`, ErrInvalidTag.Error()},
+		{"should fail with too many tags", `{"tags": ["a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"]}`, ErrTooManyTags.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plungeStore := completedPlunge()
+			handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+			id := plungeStore.plungeID.String()
+			rr := authorizedRequestWithBody(t, http.MethodPut, "/v1/plunges/"+id, map[string]string{"id": id}, bytes.NewBufferString(test.body), handler.handlePlungeJournalUpdate)
+			utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+			utils.TestExpectedMessage(t, rr, test.message)
+		})
+	}
+
+	t.Run("should not update another user's plunge", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequestWithBody(t, http.MethodPut, "/v1/plunges/"+id, map[string]string{"id": id}, bytes.NewBufferString(`{"notes": "cold"}`), handler.handlePlungeJournalUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should save the journal", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		body := `{"notes": "windy morning", "perceived_effort": 7, "tags": [" Morning", "morning", "wind"]}`
+		rr := authorizedRequestWithBody(t, http.MethodPut, "/v1/plunges/"+id, map[string]string{"id": id}, bytes.NewBufferString(body), handler.handlePlungeJournalUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Notes != "windy morning" || resp.PerceivedEffort == nil || *resp.PerceivedEffort != 7 || len(resp.Tags) != 2 || resp.Tags[0] != "morning" {
+			t.Errorf("unexpected journal %+v", resp)
+		}
+	})
+}
+
+func TestPlungeHeartRateImport(t *testing.T) {
+	t.Run("should refuse a running plunge", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		plungeStore.plunge.EndTime = sql.NullTime{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/"+id+"/heart-rate", map[string]string{"id": id}, bytes.NewBufferString("time,bpm\n"), handler.handlePlungeHeartRateImport)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+	})
+
+	t.Run("should fail if the samples aren't during the plunge", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		body := "time,bpm\n2024-06-02T07:00:00Z,70\n"
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/"+id+"/heart-rate", map[string]string{"id": id}, bytes.NewBufferString(body), handler.handlePlungeHeartRateImport)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, heartrate.ErrOutsideWindow.Error())
+	})
+
+	t.Run("should save the samples around the plunge and summarize them", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		body := "time,bpm\n2024-06-01T06:00:00Z,55\n2024-06-01T06:59:00Z,60\n2024-06-01T07:00:20Z,140\n2024-06-01T07:05:00Z,62\n"
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/"+id+"/heart-rate", map[string]string{"id": id}, bytes.NewBufferString(body), handler.handlePlungeHeartRateImport)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		var resp PlungeHeartRateResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.PeakBPM != 140 || resp.RestingBPM != 60 || resp.RecoverySeconds == nil || *resp.RecoverySeconds != 120 || len(resp.Samples) != 3 {
+			t.Errorf("unexpected heart rate %+v", resp)
+		}
+
+		var saved []heartRateRow
+		if err := json.Unmarshal(plungeStore.saved.Samples, &saved); err != nil {
+			t.Fatal(err)
+		}
+
+		if plungeStore.saved.PlungeID != plungeStore.plungeID || len(saved) != 3 || saved[1].BPM != 140 {
+			t.Errorf("unexpected saved samples %+v", saved)
+		}
+	})
+
+	t.Run("should keep the previous import if the samples can't be saved", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		plungeStore.heartRates = []database.PlungeHeartRate{{PlungeID: plungeStore.plungeID, Bpm: 60}}
+		plungeStore.saveErr = errors.New("database error")
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		body := "time,bpm\n2024-06-01T06:59:00Z,60\n2024-06-01T07:00:20Z,140\n"
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/"+id+"/heart-rate", map[string]string{"id": id}, bytes.NewBufferString(body), handler.handlePlungeHeartRateImport)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+
+		if len(plungeStore.heartRates) != 1 {
+			t.Errorf("expected the previous heart rate to be kept, got %+v", plungeStore.heartRates)
+		}
+	})
+}
+
+func TestPlungeGetHeartRate(t *testing.T) {
+	t.Run("should summarize the imported heart rate", func(t *testing.T) {
+		plungeStore := completedPlunge()
+		plungeStore.heartRates = []database.PlungeHeartRate{
+			{ReadAt: journalStart, Bpm: 70},
+			{ReadAt: journalStart.Add(time.Minute), Bpm: 125},
+		}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.HeartRate == nil || resp.HeartRate.PeakBPM != 125 || resp.HeartRate.RecoverySeconds != nil {
+			t.Errorf("unexpected heart rate %+v", resp.HeartRate)
+		}
+	})
+}
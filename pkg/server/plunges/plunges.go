@@ -3,31 +3,315 @@ package plunges
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/auth"
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
 )
 
-func NewHandler(store PlungeStore, sensors sensor.Sensors) *Handler {
+func NewStore(db *sql.DB, queries *database.Queries) *Store {
+	return &Store{
+		Queries: queries,
+		db:      db,
+	}
+}
+
+func (s *Store) ExecTx(ctx context.Context, fn func(PlungeStore) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(&Store{Queries: s.Queries.WithTx(tx), db: s.db}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func NewHandler(store PlungeStore, sensors sensor.Sensors, guard Guard, timer Timer, coldDoseThreshold float64) *Handler {
 	h := Handler{
 		store,
 		sensors,
+		guard,
+		timer,
+		coldDoseThreshold,
 	}
 
 	return &h
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/plunges", h.handlePlungesHistoryGet)
+	mux.HandleFunc("GET /v1/plunges/stats", h.handlePlungesStatsGet)
+	mux.HandleFunc("GET /v1/plunges/leaderboard", h.handlePlungesLeaderboardGet)
+	mux.HandleFunc("GET /v1/plunges/goals", h.handleGoalsGet)
+	mux.HandleFunc("POST /v1/plunges/goals", h.handleGoalCreate)
+	mux.HandleFunc("PUT /v1/plunges/goals/{id}", h.handleGoalUpdate)
+	mux.HandleFunc("DELETE /v1/plunges/goals/{id}", h.handleGoalDelete)
+	mux.HandleFunc("GET /v1/plunges/protocols", h.handleProtocolsGet)
+	mux.HandleFunc("POST /v1/plunges/protocols", h.handleProtocolCreate)
+	mux.HandleFunc("GET /v1/plunges/protocols/{id}", h.handleProtocolGet)
+	mux.HandleFunc("PUT /v1/plunges/protocols/{id}", h.handleProtocolUpdate)
+	mux.HandleFunc("DELETE /v1/plunges/protocols/{id}", h.handleProtocolDelete)
+	mux.HandleFunc("GET /v1/plunges/{id}", h.handlePlungeGet)
+	mux.HandleFunc("PUT /v1/plunges/{id}", h.handlePlungeJournalUpdate)
+	mux.HandleFunc("POST /v1/plunges/{id}/heart-rate", h.handlePlungeHeartRateImport)
 	mux.HandleFunc("GET /v1/plunges/status", h.handlePlungesGet)
 	mux.HandleFunc("POST /v1/plunges/start", h.handlePlungesStart)
 	mux.HandleFunc("PUT /v1/plunges/stop", h.handlePlungesStop)
 }
 
+// authorizedUser returns the user for the request's API key.
+func (h *Handler) authorizedUser(r *http.Request) (database.User, error) {
+	apiKey, err := auth.ParseApiKey(r)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return h.store.GetUserByApiKey(r.Context(), apiKey)
+}
+
+// handlePlungesHistoryGet returns a page of the user's plunges that started between 'from' and 'to', newest first.
+func (h *Handler) handlePlungesHistoryGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungesHistoryGet")
+	defer slog.Debug("<<handlePlungesHistoryGet")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	from, to, err := utils.ParseTimeRange(r, DefaultPlungeRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	page, err := utils.ParsePagination(r, DefaultPlungesLimit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbPlunges, err := h.store.GetPlunges(r.Context(), database.GetPlungesParams{
+		UserID:    user.ID,
+		FromTime:  from,
+		ToTime:    to,
+		RowLimit:  int32(page.Limit),
+		RowOffset: int32(page.Offset),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge history", err)
+		return
+	}
+
+	total, err := h.store.CountPlunges(r.Context(), database.CountPlungesParams{
+		UserID:   user.ID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to count the plunge history", err)
+		return
+	}
+
+	plunges := make([]PlungeResponse, 0, len(dbPlunges))
+	for _, p := range dbPlunges {
+		plunges = append(plunges, databasePlungeToPlunge(p))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, utils.NewPage(plunges, page, total))
+}
+
+// handlePlungeGet returns one of the user's plunges with the temperatures sampled while it was running,
+// the phases of its protocol, the heart rate imported for it and its cold dose.
+func (h *Handler) handlePlungeGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungeGet")
+	defer slog.Debug("<<handlePlungeGet")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	p, ok := h.userPlunge(w, r, user)
+	if !ok {
+		return
+	}
+
+	samples, err := h.store.GetPlungeTemperatures(r.Context(), p.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge temperatures", err)
+		return
+	}
+
+	phases, err := h.store.GetPlungePhases(r.Context(), p.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge phases", err)
+		return
+	}
+
+	heartRates, err := h.store.GetPlungeHeartRates(r.Context(), p.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge heart rate", err)
+		return
+	}
+
+	response := databasePlungeToPlunge(p)
+	response.Temperatures = databaseSamplesToSamples(samples)
+	response.Phases = databasePhasesToPhases(phases)
+	if len(heartRates) != 0 && p.EndTime.Valid {
+		response.HeartRate = summarizeHeartRate(databaseHeartRatesToSamples(heartRates), p)
+	}
+	if p.EndTime.Valid {
+		dose := coldExposure(p, samples, h.coldDoseThreshold)
+		response.ColdDose = &dose
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// userPlunge reads the plunge in the path if it belongs to the user, otherwise it responds with the error.
+// Another user's plunge is reported as missing rather than forbidden so its id can't be probed.
+func (h *Handler) userPlunge(w http.ResponseWriter, r *http.Request, user database.User) (database.Plunge, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge id", err)
+		return database.Plunge{}, false
+	}
+
+	p, err := h.store.GetPlungeByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !ownedBy(p, user)) {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the plunge", err)
+		return p, false
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge", err)
+		return p, false
+	}
+
+	return p, true
+}
+
+// handlePlungesStatsGet summarizes the user's completed plunges between 'from' and 'to' and tracks their goals this week.
+// The optional 'below' parameter is the water temperature in Fahrenheit for the longest plunge below it, and the cold dose
+// is measured below it rather than the configured threshold.
+func (h *Handler) handlePlungesStatsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungesStatsGet")
+	defer slog.Debug("<<handlePlungesStatsGet")
+
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	from, to, err := utils.ParseTimeRange(r, DefaultPlungeRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	var below *float64
+	if belowStr := r.URL.Query().Get("below"); belowStr != "" {
+		b, err := strconv.ParseFloat(belowStr, 64)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'below' parameter", err)
+			return
+		}
+		below = &b
+	}
+
+	dbPlunges, samples, err := readGoalPlunges(r.Context(), h.store, user.ID, from, to)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge history", err)
+		return
+	}
+
+	now := time.Now()
+	stats := summarizePlunges(dbPlunges, from, to, below, now, time.Local)
+
+	threshold := h.coldDoseThreshold
+	if below != nil {
+		threshold = *below
+	}
+
+	stats.ColdDose = ColdDose{Threshold: threshold}
+	for _, p := range dbPlunges {
+		dose := coldExposure(p, samples[p.ID], threshold)
+		stats.ColdDose.MinutesBelow += dose.MinutesBelow
+		stats.ColdDose.DegreeMinutes += dose.DegreeMinutes
+	}
+
+	stats.Goals, err = TrackGoals(r.Context(), h.store, user.ID, now, time.Local)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge goals", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, stats)
+}
+
+// handlePlungesLeaderboardGet compares the completed plunges of every user between 'from' and 'to'.
+func (h *Handler) handlePlungesLeaderboardGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlePlungesLeaderboardGet")
+	defer slog.Debug("<<handlePlungesLeaderboardGet")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	from, to, err := utils.ParseTimeRange(r, DefaultPlungeRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	rows, err := h.store.GetPlungeLeaderboard(r.Context(), database.GetPlungeLeaderboardParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge leaderboard", err)
+		return
+	}
+
+	response := PlungeLeaderboardResponse{
+		From:  from,
+		To:    to,
+		Users: make([]PlungeLeaderboardEntry, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		response.Users = append(response.Users, PlungeLeaderboardEntry{
+			UserID:         row.UserID,
+			Email:          row.Email,
+			Sessions:       row.Sessions,
+			TotalMinutes:   row.TotalSeconds / 60,
+			LongestSeconds: row.LongestSeconds,
+			LastPlunge:     row.LastPlunge,
+		})
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
 func (h *Handler) handlePlungesGet(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlePlungesGet")
 	defer slog.Debug("<<handlePlungesGet")
@@ -43,12 +327,25 @@ func (h *Handler) handlePlungesGet(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, plunges)
 }
 
+// handlePlungesStart starts a plunge for the user, only one plunge can run at a time.
 func (h *Handler) handlePlungesStart(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlePlungesStart")
 	defer slog.Debug("<<handlePlungesStart")
 
+	user, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
 	// TODO: change this to be in the body
 	durationStr := r.URL.Query().Get("duration")
+	protocolStr := r.URL.Query().Get("protocol")
+	if durationStr != "" && protocolStr != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Only one of 'duration' or 'protocol' can be given", nil)
+		return
+	}
+
 	if durationStr == "" {
 		durationStr = DefaultPlungeDurationSeconds
 	}
@@ -59,6 +356,58 @@ func (h *Handler) handlePlungesStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// a guided plunge runs for as long as the phases of its protocol
+	var protocolID uuid.NullUUID
+	phases := json.RawMessage("[]")
+	if protocolStr != "" {
+		id, err := uuid.Parse(protocolStr)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'protocol' parameter", err)
+			return
+		}
+
+		dbProtocol, err := h.store.GetPlungeProtocol(r.Context(), id)
+		if err != nil {
+			respondWithProtocolError(w, err)
+			return
+		}
+
+		expanded := databaseToProtocol(dbProtocol).Expand()
+		phases, err = json.Marshal(expanded)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "failed to start the plunge timer", err)
+			return
+		}
+
+		protocolID = uuid.NullUUID{UUID: id, Valid: true}
+		duration = int(protocol.Duration(expanded).Seconds())
+	}
+
+	running, err := h.store.GetLatestPlunge(r.Context())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the current plunge", err)
+		return
+	}
+
+	if err == nil && running.Running {
+		message := "another user's plunge is already running"
+		if ownedBy(running, user) {
+			message = "your plunge is already running"
+		}
+
+		utils.RespondWithError(w, http.StatusConflict, message, nil)
+		return
+	}
+
+	err = h.guard.CheckAction(r.Context(), interlock.ACTION_PLUNGE_START)
+	if interlock.IsViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, err.Error(), err)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to check the interlock state", err)
+		return
+	}
+
 	roomTemp, waterTemp, err := h.getRecentTemperatures(r.Context())
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "failed to start the plunge timer", err)
@@ -70,28 +419,50 @@ func (h *Handler) handlePlungesStart(w http.ResponseWriter, r *http.Request) {
 		StartWaterTemp:   waterTemp,
 		StartRoomTemp:    roomTemp,
 		ExpectedDuration: int32(duration),
+		UserID:           uuid.NullUUID{UUID: user.ID, Valid: true},
+		ProtocolID:       protocolID,
+		ProtocolPhases:   phases,
 	}
 
-	// Save start to database
+	// Save start to database, the database refuses a second running plunge if another user started one since it was read
 	plunge, err := h.store.StartPlunge(r.Context(), params)
-	if err != nil {
+	if database.IsUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "another plunge is already running", err)
+		return
+	} else if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "failed to start the plunge timer", err)
 		return
 	}
 
+	// the monitor stops the plunge once the expected duration has passed
+	h.timer.StartPlungeTimer(plunge)
+
 	utils.RespondWithJSON(w, http.StatusCreated, databasePlungeToPlunge(plunge))
 }
 
+// handlePlungesStop stops the running plunge if it belongs to the user.
 func (h *Handler) handlePlungesStop(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlePlungesStop")
 	defer slog.Debug("<<handlePlungesStop")
 
-	p, err := h.store.GetLatestPlunge(r.Context())
+	user, err := h.authorizedUser(r)
 	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	// a plunge that was already completed by its timer keeps the end time it was completed at
+	p, err := h.store.GetLatestPlunge(r.Context())
+	if err != nil || !p.Running {
 		utils.RespondWithError(w, http.StatusNotFound, "No plunge timer running", nil)
 		return
 	}
 
+	if p.UserID.Valid && !ownedBy(p, user) {
+		utils.RespondWithError(w, http.StatusConflict, "another user's plunge is running", nil)
+		return
+	}
+
 	roomTemp, waterTemp, err := h.getRecentTemperatures(r.Context())
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "failed to stop the plunge timer", err)
@@ -105,12 +476,18 @@ func (h *Handler) handlePlungesStop(w http.ResponseWriter, r *http.Request) {
 		EndRoomTemp:  roomTemp,
 	}
 
+	// the timer may have completed the plunge since it was read
 	plunge, err := h.store.StopPlunge(r.Context(), params)
-	if err != nil {
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "No plunge timer running", err)
+		return
+	} else if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "failed to stop the plunge timer", err)
 		return
 	}
 
+	h.timer.StopPlungeTimer(plunge.ID)
+
 	utils.RespondWithJSON(w, http.StatusOK, databasePlungeToPlunge(plunge))
 }
 
@@ -127,6 +504,16 @@ func databasePlungeToPlunge(dbPlunge database.Plunge) PlungeResponse {
 		ExpectedDuration: dbPlunge.ExpectedDuration,
 		AvgWaterTemp:     dbPlunge.AvgWaterTemp,
 		AvgRoomTemp:      dbPlunge.AvgRoomTemp,
+		MinWaterTemp:     dbPlunge.MinWaterTemp,
+		MaxWaterTemp:     dbPlunge.MaxWaterTemp,
+		MinRoomTemp:      dbPlunge.MinRoomTemp,
+		MaxRoomTemp:      dbPlunge.MaxRoomTemp,
+		Notes:            dbPlunge.Notes,
+		Tags:             dbPlunge.Tags,
+	}
+
+	if resp.Tags == nil {
+		resp.Tags = make([]string, 0)
 	}
 
 	if dbPlunge.StartTime.Valid {
@@ -135,25 +522,90 @@ func databasePlungeToPlunge(dbPlunge database.Plunge) PlungeResponse {
 	if dbPlunge.EndTime.Valid {
 		resp.EndTime = dbPlunge.EndTime.Time
 	}
+	if dbPlunge.UserID.Valid {
+		resp.UserID = &dbPlunge.UserID.UUID
+	}
+	if dbPlunge.ProtocolID.Valid {
+		resp.ProtocolID = &dbPlunge.ProtocolID.UUID
+	}
+	if dbPlunge.PerceivedEffort.Valid {
+		resp.PerceivedEffort = &dbPlunge.PerceivedEffort.Int32
+	}
+	if dbPlunge.StartTime.Valid && dbPlunge.EndTime.Valid {
+		resp.DurationSeconds = dbPlunge.EndTime.Time.Sub(dbPlunge.StartTime.Time).Seconds()
+	}
 
 	return resp
 }
 
+// databaseSamplesToSamples converts the temperature samples, a temperature that couldn't be read is left out.
+func databaseSamplesToSamples(dbSamples []database.PlungeTemperature) []PlungeTemperatureSample {
+	samples := make([]PlungeTemperatureSample, 0, len(dbSamples))
+
+	for _, dbSample := range dbSamples {
+		sample := PlungeTemperatureSample{ReadAt: dbSample.ReadAt}
+
+		if t, err := strconv.ParseFloat(dbSample.WaterTemp.String, 64); dbSample.WaterTemp.Valid && err == nil {
+			sample.WaterTemp = &t
+		}
+
+		if t, err := strconv.ParseFloat(dbSample.RoomTemp.String, 64); dbSample.RoomTemp.Valid && err == nil {
+			sample.RoomTemp = &t
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples
+}
+
+// ownedBy reports if the plunge was started by the user.
+func ownedBy(p database.Plunge, user database.User) bool {
+	return p.UserID.Valid && p.UserID.UUID == user.ID
+}
+
 func (h *Handler) getRecentTemperatures(ctx context.Context) (string, string, error) {
-	temperature, err := h.store.FindMostRecentTemperatures(ctx)
+	roomTemp, err := h.getRecentTemperature(ctx, sensor.ROLE_ROOM)
 	if err != nil {
 		return "", "", err
 	}
 
-	waterTemp := "0.0"
-	roomTemp := "0.0"
-	if temperature.RoomTemp.Valid {
-		roomTemp = temperature.RoomTemp.String
+	waterTemp, err := h.getRecentTemperature(ctx, sensor.ROLE_WATER)
+	if err != nil {
+		return "", "", err
 	}
 
-	if temperature.WaterTemp.Valid {
-		waterTemp = temperature.WaterTemp.String
+	return roomTemp, waterTemp, nil
+}
+
+func (h *Handler) getRecentTemperature(ctx context.Context, role string) (string, error) {
+	temperature, err := h.store.GetLatestTemperatureByRole(ctx, role)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && len(temperature.TemperatureF) == 0) {
+		// nothing has been read from the device yet
+		return "0.0", nil
 	}
 
-	return roomTemp, waterTemp, nil
+	if err != nil {
+		return "", err
+	}
+
+	return temperature.TemperatureF, nil
+}
+
+func databasePhasesToPhases(dbPhases []database.PlungePhase) []PlungePhaseResponse {
+	phases := make([]PlungePhaseResponse, 0, len(dbPhases))
+
+	for _, dbPhase := range dbPhases {
+		phases = append(phases, PlungePhaseResponse{
+			Number:          int(dbPhase.Position) + 1,
+			Name:            dbPhase.Name,
+			Kind:            dbPhase.Kind,
+			DurationSeconds: int(dbPhase.DurationSeconds),
+			StartedAt:       dbPhase.StartedAt,
+			EndedAt:         dbPhase.EndedAt,
+			Completed:       dbPhase.Completed,
+		})
+	}
+
+	return phases
 }
@@ -2,15 +2,21 @@ package plunges
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 func TestPlungesGet(t *testing.T) {
@@ -18,7 +24,7 @@ func TestPlungesGet(t *testing.T) {
 		plungeStore := mockPlungeStore{}
 		sensors := mockSensors{}
 
-		handler := NewHandler(&plungeStore, &sensors)
+		handler := NewHandler(&plungeStore, &sensors, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
 		plungeStore.plunge = database.Plunge{}
 		rr := utils.TestRequest(t, http.MethodGet, "/v2/plunges/status", nil, handler.handlePlungesGet)
 		utils.TestExpectedStatus(t, rr, http.StatusOK)
@@ -30,7 +36,7 @@ func TestPlungesGet(t *testing.T) {
 		plungeStore := mockPlungeStore{}
 		sensors := mockSensors{}
 
-		handler := NewHandler(&plungeStore, &sensors)
+		handler := NewHandler(&plungeStore, &sensors, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
 
 		plungeStore.plungeID = uuid.New()
 		plungeStore.plunge.Running = true
@@ -44,9 +50,9 @@ func TestPlungeStart(t *testing.T) {
 		plungeStore := mockPlungeStore{}
 		sensors := mockSensors{}
 
-		handler := NewHandler(&plungeStore, &sensors)
+		handler := NewHandler(&plungeStore, &sensors, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
 
-		rr := utils.TestRequest(t, http.MethodPost, "/v2/plunges/start?duration=abcd", nil, handler.handlePlungesStart)
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start?duration=abcd", nil, handler.handlePlungesStart)
 		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
 		utils.TestExpectedMessage(t, rr, "Invalid 'duration' parameter")
 	})
@@ -55,9 +61,9 @@ func TestPlungeStart(t *testing.T) {
 		plungeStore := mockPlungeStore{}
 		sensors := mockSensors{}
 
-		handler := NewHandler(&plungeStore, &sensors)
+		handler := NewHandler(&plungeStore, &sensors, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
 
-		rr := utils.TestRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
 		utils.TestExpectedStatus(t, rr, http.StatusCreated)
 
 		var resp PlungeResponse
@@ -74,9 +80,9 @@ func TestPlungeStart(t *testing.T) {
 		plungeStore := mockPlungeStore{}
 		sensors := mockSensors{}
 
-		handler := NewHandler(&plungeStore, &sensors)
+		handler := NewHandler(&plungeStore, &sensors, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
 
-		rr := utils.TestRequest(t, http.MethodPost, "/v2/plunges/start?duration=240", nil, handler.handlePlungesStart)
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start?duration=240", nil, handler.handlePlungesStart)
 		utils.TestExpectedStatus(t, rr, http.StatusCreated)
 
 		var resp PlungeResponse
@@ -91,10 +97,432 @@ func TestPlungeStart(t *testing.T) {
 	})
 }
 
+func TestPlungeOwnership(t *testing.T) {
+	t.Run("should fail to start a plunge without an API key", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+		utils.TestExpectedMessage(t, rr, "not authorized")
+	})
+
+	t.Run("should record the user that started the plunge", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.UserID == nil || *resp.UserID != testUser.ID {
+			t.Errorf("expected the plunge to belong to %s, got %v", testUser.ID, resp.UserID)
+		}
+	})
+
+	t.Run("should refuse to start a plunge while another user's plunge is running", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		plungeStore.plunge.Running = true
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "another user's plunge is already running")
+	})
+
+	t.Run("should refuse to start a plunge another user started at the same time", func(t *testing.T) {
+		plungeStore := mockPlungeStore{startErr: &pq.Error{Code: "23505"}}
+		timer := mockTimer{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &timer, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "another plunge is already running")
+
+		if timer.started != nil {
+			t.Errorf("expected no timer to start, got %+v", timer.started)
+		}
+	})
+
+	t.Run("should refuse to stop another user's plunge", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		plungeStore.plunge.Running = true
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPut, "/v2/plunges/stop", nil, handler.handlePlungesStop)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "another user's plunge is running")
+
+		if !plungeStore.plunge.Running {
+			t.Errorf("expected the plunge to keep running")
+		}
+	})
+}
+
+func TestPlungeTimer(t *testing.T) {
+	t.Run("should time the plunge once it starts", func(t *testing.T) {
+		timer := mockTimer{}
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &timer, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start?duration=120", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if timer.started == nil || timer.started.ExpectedDuration != 120 {
+			t.Errorf("expected the timer to start for 120 seconds, got %+v", timer.started)
+		}
+	})
+
+	t.Run("should stop timing the plunge when it is stopped", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		plungeStore.plunge.ID = uuid.New()
+		plungeStore.plunge.Running = true
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+		timer := mockTimer{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &timer, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPut, "/v2/plunges/stop", nil, handler.handlePlungesStop)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if timer.stopped != plungeStore.plunge.ID {
+			t.Errorf("expected the timer for %s to stop, got %s", plungeStore.plunge.ID, timer.stopped)
+		}
+	})
+
+	t.Run("should not stop a plunge the timer already completed", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		plungeStore.plunge.ID = uuid.New()
+		plungeStore.plunge.EndTime = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPut, "/v2/plunges/stop", nil, handler.handlePlungesStop)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+		utils.TestExpectedMessage(t, rr, "No plunge timer running")
+	})
+
+	t.Run("should not stop a plunge the timer completed while it was being stopped", func(t *testing.T) {
+		plungeStore := mockPlungeStore{stopErr: sql.ErrNoRows}
+		plungeStore.plunge.ID = uuid.New()
+		plungeStore.plunge.Running = true
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+		timer := mockTimer{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &timer, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPut, "/v2/plunges/stop", nil, handler.handlePlungesStop)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+		utils.TestExpectedMessage(t, rr, "No plunge timer running")
+
+		if timer.stopped != uuid.Nil {
+			t.Errorf("expected the timer to be left to the monitor, it was stopped for %s", timer.stopped)
+		}
+	})
+}
+
+func TestPlungeInterlock(t *testing.T) {
+	t.Run("should refuse to start a plunge while the ozone is running", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		sensors := mockSensors{}
+		guard := mockGuard{state: interlock.State{PumpOn: true, OzoneRunning: true}}
+
+		handler := NewHandler(&plungeStore, &sensors, &guard, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v2/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "a plunge can't start while the ozone generator is running")
+	})
+}
+
+func TestPlungesHistoryGet(t *testing.T) {
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges?limit=0", nil, handler.handlePlungesHistoryGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, utils.ErrInvalidLimit.Error())
+	})
+
+	t.Run("should return a page of plunges with their durations", func(t *testing.T) {
+		start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+		plungeStore := mockPlungeStore{
+			plunges: []database.Plunge{
+				{ID: uuid.New(), StartTime: sql.NullTime{Time: start, Valid: true}, EndTime: sql.NullTime{Time: start.Add(3 * time.Minute), Valid: true}},
+				{ID: uuid.New(), StartTime: sql.NullTime{Time: start.Add(time.Hour), Valid: true}, Running: true},
+			},
+			count: 7,
+		}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges?from=2024-06-01T00:00:00Z&to=2024-06-02T00:00:00Z&limit=2&offset=2", nil, handler.handlePlungesHistoryGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if plungeStore.arg.UserID != testUser.ID || plungeStore.arg.RowLimit != 2 || plungeStore.arg.RowOffset != 2 || !plungeStore.arg.ToTime.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected query %+v", plungeStore.arg)
+		}
+
+		var page utils.Page[PlungeResponse]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+
+		if page.Total != 7 || len(page.Items) != 2 || page.Items[0].DurationSeconds != 180 || page.Items[1].DurationSeconds != 0 {
+			t.Errorf("unexpected page %+v", page)
+		}
+	})
+}
+
+func TestPlungeGet(t *testing.T) {
+	t.Run("should fail with an invalid id", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/abc", map[string]string{"id": "abc"}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail without an API key", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		rr := utils.TestRequestWithPathValues(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, nil, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+		utils.TestExpectedMessage(t, rr, "not authorized")
+	})
+
+	t.Run("should not find another user's plunge", func(t *testing.T) {
+		plungeStore := mockPlungeStore{plungeID: uuid.New()}
+		plungeStore.plunge.ID = plungeStore.plungeID
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should fail for an unknown plunge", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{plungeID: uuid.New()}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should return the plunge", func(t *testing.T) {
+		plungeStore := mockPlungeStore{plungeID: uuid.New()}
+		plungeStore.plunge.ID = plungeStore.plungeID
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.ID != plungeStore.plungeID {
+			t.Errorf("expected plunge %s, got %s", plungeStore.plungeID, resp.ID)
+		}
+	})
+
+	t.Run("should return the temperature curve", func(t *testing.T) {
+		start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+		plungeStore := mockPlungeStore{
+			plungeID: uuid.New(),
+			samples: []database.PlungeTemperature{
+				{ReadAt: start, WaterTemp: sql.NullString{String: "45.50", Valid: true}, RoomTemp: sql.NullString{String: "68.00", Valid: true}},
+				{ReadAt: start.Add(5 * time.Second), RoomTemp: sql.NullString{String: "68.25", Valid: true}},
+			},
+		}
+		plungeStore.plunge.ID = plungeStore.plungeID
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Temperatures) != 2 || *resp.Temperatures[0].WaterTemp != 45.5 || resp.Temperatures[1].WaterTemp != nil || *resp.Temperatures[1].RoomTemp != 68.25 {
+			t.Errorf("unexpected temperatures %+v", resp.Temperatures)
+		}
+	})
+}
+
+func TestPlungesStatsGet(t *testing.T) {
+	t.Run("should fail with an invalid temperature", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/stats?below=cold", nil, handler.handlePlungesStatsGet)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, "Invalid 'below' parameter")
+	})
+
+	t.Run("should summarize the plunges", func(t *testing.T) {
+		day := func(d int, minutes int, waterTemp string) database.Plunge {
+			start := time.Date(2024, 6, d, 7, 0, 0, 0, time.UTC)
+			return database.Plunge{
+				ID:           uuid.New(),
+				StartTime:    sql.NullTime{Time: start, Valid: true},
+				EndTime:      sql.NullTime{Time: start.Add(time.Duration(minutes) * time.Minute), Valid: true},
+				AvgWaterTemp: waterTemp,
+			}
+		}
+
+		plunges := []database.Plunge{
+			day(1, 2, "45.0"),
+			day(2, 3, "40.0"),
+			day(3, 5, "48.0"),
+			day(3, 1, "38.0"),
+			day(10, 4, "42.0"),
+			day(11, 2, "0.0"),
+		}
+
+		from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 0, 14)
+		below := 44.0
+		now := time.Date(2024, 6, 12, 20, 0, 0, 0, time.UTC)
+
+		stats := summarizePlunges(plunges, from, to, &below, now, time.UTC)
+
+		if stats.Sessions != 6 || stats.TotalMinutes != 17 || stats.SessionsPerWeek != 3 {
+			t.Errorf("unexpected totals %+v", stats)
+		}
+
+		if stats.AvgWaterTemp != 42.6 {
+			t.Errorf("expected the average of the recorded temperatures, got %v", stats.AvgWaterTemp)
+		}
+
+		if stats.CurrentStreakDays != 2 || stats.LongestStreakDays != 3 {
+			t.Errorf("expected a current streak of 2 and longest of 3, got %d and %d", stats.CurrentStreakDays, stats.LongestStreakDays)
+		}
+
+		if stats.Longest.ID != plunges[2].ID || stats.Coldest.ID != plunges[3].ID || stats.LongestBelow.ID != plunges[4].ID {
+			t.Errorf("unexpected personal bests %+v %+v %+v", stats.Longest, stats.Coldest, stats.LongestBelow)
+		}
+	})
+
+	t.Run("should end the current streak after a day without a plunge", func(t *testing.T) {
+		days := []time.Time{time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+		current, longest := streaks(days, time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC))
+		if current != 0 || longest != 1 {
+			t.Errorf("expected the streak to be broken, got %d and %d", current, longest)
+		}
+	})
+}
+
+const (
+	testApiKey            = "12345"
+	testColdDoseThreshold = 60.0
+)
+
+var testUser = database.User{ID: uuid.New(), Email: "plunger@example.com"}
+
+// authorizedRequest sends the request with the test user's API key.
+func authorizedRequest(t *testing.T, method string, url string, values map[string]string, handler func(http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+	return authorizedRequestWithBody(t, method, url, values, nil, handler)
+}
+
+func authorizedRequestWithBody(t *testing.T, method string, url string, values map[string]string, body io.Reader, handler func(http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Authorization", "ApiKey "+testApiKey)
+	for k, v := range values {
+		req.SetPathValue(k, v)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(handler).ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestPlungesLeaderboardGet(t *testing.T) {
+	t.Run("should fail without an API key", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/plunges/leaderboard", nil, handler.handlePlungesLeaderboardGet)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+	})
+
+	t.Run("should rank the users", func(t *testing.T) {
+		plungeStore := mockPlungeStore{
+			leaderboard: []database.GetPlungeLeaderboardRow{
+				{UserID: testUser.ID, Email: testUser.Email, Sessions: 4, TotalSeconds: 900, LongestSeconds: 300},
+				{UserID: uuid.New(), Email: "other@example.com", Sessions: 2, TotalSeconds: 240, LongestSeconds: 180},
+			},
+		}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/leaderboard", nil, handler.handlePlungesLeaderboardGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeLeaderboardResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Users) != 2 || resp.Users[0].UserID != testUser.ID || resp.Users[0].TotalMinutes != 15 || resp.Users[1].TotalMinutes != 4 {
+			t.Errorf("unexpected leaderboard %+v", resp.Users)
+		}
+	})
+}
+
+type mockTimer struct {
+	started *database.Plunge
+	stopped uuid.UUID
+}
+
+func (m *mockTimer) StartPlungeTimer(p database.Plunge) {
+	m.started = &p
+}
+
+func (m *mockTimer) StopPlungeTimer(id uuid.UUID) {
+	m.stopped = id
+}
+
+type mockGuard struct {
+	state interlock.State
+}
+
+func (m *mockGuard) CheckAction(ctx context.Context, action string) error {
+	return interlock.Check(m.state, action)
+}
+
 type mockPlungeStore struct {
 	plungeID    uuid.UUID
 	plunge      database.Plunge
-	temperature database.Temperature
+	plunges     []database.Plunge
+	count       int64
+	arg         database.GetPlungesParams
+	leaderboard []database.GetPlungeLeaderboardRow
+	samples     []database.PlungeTemperature
+	phases      []database.PlungePhase
+	protocol    database.PlungeProtocol
+	created     database.CreatePlungeProtocolParams
+	deleted     int64
+	journal     database.UpdatePlungeJournalParams
+	heartRates  []database.PlungeHeartRate
+	saved       database.SavePlungeHeartRatesParams
+	saveErr     error
+	goals       []database.PlungeGoal
+	goal        database.CreatePlungeGoalParams
+	deletedGoal database.DeletePlungeGoalParams
+	temperature database.TemperatureReading
+	startErr    error
+	stopErr     error
 	err         error
 }
 
@@ -104,28 +532,47 @@ func (m *mockPlungeStore) GetLatestPlunge(ctx context.Context) (database.Plunge,
 
 func (m *mockPlungeStore) GetPlungeByID(ctx context.Context, id uuid.UUID) (database.Plunge, error) {
 	if id != m.plungeID {
-		return m.plunge, errors.New("invalid plunge id")
+		return m.plunge, sql.ErrNoRows
 	}
 	return m.plunge, m.err
 }
 
-func (m *mockPlungeStore) GetPlunges(ctx context.Context) ([]database.Plunge, error) {
-	result := []database.Plunge{m.plunge}
-	return result, m.err
+func (m *mockPlungeStore) GetPlunges(ctx context.Context, arg database.GetPlungesParams) ([]database.Plunge, error) {
+	m.arg = arg
+	return m.plunges, m.err
+}
+
+func (m *mockPlungeStore) CountPlunges(ctx context.Context, arg database.CountPlungesParams) (int64, error) {
+	return m.count, m.err
+}
+
+func (m *mockPlungeStore) GetCompletedPlunges(ctx context.Context, arg database.GetCompletedPlungesParams) ([]database.Plunge, error) {
+	return m.plunges, m.err
 }
 
 func (m *mockPlungeStore) StartPlunge(ctx context.Context, arg database.StartPlungeParams) (database.Plunge, error) {
+	if m.startErr != nil {
+		return database.Plunge{}, m.startErr
+	}
+
 	m.plunge.Running = true
 	m.plunge.StartTime.Valid = arg.StartTime.Valid
 	m.plunge.StartTime.Time = arg.StartTime.Time
 	m.plunge.StartWaterTemp = arg.StartWaterTemp
 	m.plunge.StartRoomTemp = arg.StartRoomTemp
 	m.plunge.ExpectedDuration = arg.ExpectedDuration
+	m.plunge.UserID = arg.UserID
+	m.plunge.ProtocolID = arg.ProtocolID
+	m.plunge.ProtocolPhases = arg.ProtocolPhases
 
 	return m.plunge, m.err
 }
 
 func (m *mockPlungeStore) StopPlunge(ctx context.Context, arg database.StopPlungeParams) (database.Plunge, error) {
+	if m.stopErr != nil {
+		return database.Plunge{}, m.stopErr
+	}
+
 	m.plunge.Running = false
 	m.plunge.EndTime.Valid = arg.EndTime.Valid
 	m.plunge.EndTime.Time = arg.EndTime.Time
@@ -134,15 +581,117 @@ func (m *mockPlungeStore) StopPlunge(ctx context.Context, arg database.StopPlung
 	return m.plunge, m.err
 }
 
-func (m *mockPlungeStore) UpdatePlungeAvgTemp(ctx context.Context, arg database.UpdatePlungeAvgTempParams) (database.Plunge, error) {
+func (m *mockPlungeStore) GetPlungeTemperatures(ctx context.Context, plungeID uuid.UUID) ([]database.PlungeTemperature, error) {
+	return m.samples, m.err
+}
+
+func (m *mockPlungeStore) GetPlungePhases(ctx context.Context, plungeID uuid.UUID) ([]database.PlungePhase, error) {
+	return m.phases, m.err
+}
+
+func (m *mockPlungeStore) GetPlungeProtocols(ctx context.Context) ([]database.PlungeProtocol, error) {
+	return []database.PlungeProtocol{m.protocol}, m.err
+}
+
+func (m *mockPlungeStore) GetPlungeProtocol(ctx context.Context, id uuid.UUID) (database.PlungeProtocol, error) {
+	if id != m.protocol.ID {
+		return m.protocol, sql.ErrNoRows
+	}
+	return m.protocol, m.err
+}
+
+func (m *mockPlungeStore) CreatePlungeProtocol(ctx context.Context, arg database.CreatePlungeProtocolParams) (database.PlungeProtocol, error) {
+	m.created = arg
+	return database.PlungeProtocol{ID: uuid.New(), Name: arg.Name, Description: arg.Description, Rounds: arg.Rounds, Phases: arg.Phases}, m.err
+}
+
+func (m *mockPlungeStore) UpdatePlungeProtocol(ctx context.Context, arg database.UpdatePlungeProtocolParams) (database.PlungeProtocol, error) {
+	if arg.ID != m.protocol.ID {
+		return m.protocol, sql.ErrNoRows
+	}
+	return database.PlungeProtocol{ID: arg.ID, Name: arg.Name, Description: arg.Description, Rounds: arg.Rounds, Phases: arg.Phases}, m.err
+}
+
+func (m *mockPlungeStore) DeletePlungeProtocol(ctx context.Context, id uuid.UUID) (int64, error) {
+	return m.deleted, m.err
+}
+
+func (m *mockPlungeStore) UpdatePlungeJournal(ctx context.Context, arg database.UpdatePlungeJournalParams) (database.Plunge, error) {
+	m.journal = arg
+	m.plunge.Notes = arg.Notes
+	m.plunge.PerceivedEffort = arg.PerceivedEffort
+	m.plunge.Tags = arg.Tags
 	return m.plunge, m.err
 }
 
-func (m *mockPlungeStore) FindMostRecentTemperatures(ctx context.Context) (database.Temperature, error) {
-	return m.temperature, m.err
+func (m *mockPlungeStore) DeletePlungeHeartRates(ctx context.Context, plungeID uuid.UUID) error {
+	m.heartRates = nil
+	return m.err
+}
+
+func (m *mockPlungeStore) SavePlungeHeartRates(ctx context.Context, arg database.SavePlungeHeartRatesParams) (int64, error) {
+	if m.saveErr != nil {
+		return 0, m.saveErr
+	}
+
+	m.saved = arg
+	return 0, m.err
 }
 
-func (m *mockPlungeStore) SaveTemperature(ctx context.Context, arg database.SaveTemperatureParams) (database.Temperature, error) {
+func (m *mockPlungeStore) ExecTx(ctx context.Context, fn func(PlungeStore) error) error {
+	heartRates := m.heartRates
+
+	err := fn(m)
+	if err != nil {
+		m.heartRates = heartRates
+	}
+
+	return err
+}
+
+func (m *mockPlungeStore) GetPlungeHeartRates(ctx context.Context, plungeID uuid.UUID) ([]database.PlungeHeartRate, error) {
+	return m.heartRates, m.err
+}
+
+func (m *mockPlungeStore) GetCompletedPlungeTemperatures(ctx context.Context, arg database.GetCompletedPlungeTemperaturesParams) ([]database.PlungeTemperature, error) {
+	return m.samples, m.err
+}
+
+func (m *mockPlungeStore) GetPlungeGoals(ctx context.Context, userID uuid.UUID) ([]database.PlungeGoal, error) {
+	return m.goals, m.err
+}
+
+func (m *mockPlungeStore) CreatePlungeGoal(ctx context.Context, arg database.CreatePlungeGoalParams) (database.PlungeGoal, error) {
+	m.goal = arg
+	return database.PlungeGoal{ID: uuid.New(), CreatedAt: time.Now().UTC(), UserID: arg.UserID, Name: arg.Name, Kind: arg.Kind, Target: arg.Target, BelowTemp: arg.BelowTemp}, m.err
+}
+
+func (m *mockPlungeStore) UpdatePlungeGoal(ctx context.Context, arg database.UpdatePlungeGoalParams) (database.PlungeGoal, error) {
+	for _, goal := range m.goals {
+		if goal.ID == arg.ID && goal.UserID == arg.UserID {
+			return database.PlungeGoal{ID: arg.ID, CreatedAt: goal.CreatedAt, UserID: arg.UserID, Name: arg.Name, Kind: arg.Kind, Target: arg.Target, BelowTemp: arg.BelowTemp}, m.err
+		}
+	}
+	return database.PlungeGoal{}, sql.ErrNoRows
+}
+
+func (m *mockPlungeStore) DeletePlungeGoal(ctx context.Context, arg database.DeletePlungeGoalParams) (int64, error) {
+	m.deletedGoal = arg
+	return m.deleted, m.err
+}
+
+func (m *mockPlungeStore) GetPlungeLeaderboard(ctx context.Context, arg database.GetPlungeLeaderboardParams) ([]database.GetPlungeLeaderboardRow, error) {
+	return m.leaderboard, m.err
+}
+
+func (m *mockPlungeStore) GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error) {
+	if apiKey != testApiKey {
+		return database.User{}, errors.New("invalid API key")
+	}
+	return testUser, nil
+}
+
+func (m *mockPlungeStore) GetLatestTemperatureByRole(ctx context.Context, deviceRole string) (database.TemperatureReading, error) {
 	return m.temperature, m.err
 }
 
@@ -151,34 +700,46 @@ type mockSensors struct {
 	err          error
 }
 
-func (m *mockSensors) ReadTemperatures() []sensor.TemperatureReading {
+func (m *mockSensors) ReadTemperatures(ctx context.Context) []sensor.TemperatureReading {
 	return m.temperatures
 }
 
-func (m *mockSensors) ReadRoomAndWaterTemperature() (sensor.TemperatureReading, sensor.TemperatureReading) {
-	return sensor.TemperatureReading{}, sensor.TemperatureReading{}
+func (m *mockSensors) ReadLeakSensors(ctx context.Context) []sensor.LeakReading {
+	return nil
 }
 
-func (m *mockSensors) IsLeakPresent() (bool, error) {
-	return false, nil
+func (m *mockSensors) TurnOzoneOn(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockSensors) TurnOzoneOff(ctx context.Context) error {
+	return nil
 }
 
-func (m *mockSensors) TurnOzoneOn() error {
+func (m *mockSensors) IsPumpOn(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *mockSensors) TurnPumpOn(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) TurnOzoneOff() error {
+func (m *mockSensors) TurnPumpOff(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) IsPumpOn() (bool, error) {
-	return true, nil
+func (m *mockSensors) IsDeviceOn(ctx context.Context, id string) (bool, error) {
+	return false, nil
 }
 
-func (m *mockSensors) TurnPumpOn() error {
+func (m *mockSensors) TurnDeviceOn(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *mockSensors) TurnPumpOff() error {
+func (m *mockSensors) TurnDeviceOff(ctx context.Context, id string) error {
 	return nil
 }
+
+func (m *mockSensors) DeviceHealth() []sensor.DeviceHealth {
+	return []sensor.DeviceHealth{}
+}
@@ -0,0 +1,226 @@
+package plunges
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidProtocolBody = errors.New("Invalid body for plunge protocol")
+
+func (h *Handler) handleProtocolsGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleProtocolsGet")
+	defer slog.Debug("<<handleProtocolsGet")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	dbProtocols, err := h.store.GetPlungeProtocols(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge protocols", err)
+		return
+	}
+
+	response := make([]ProtocolResponse, 0, len(dbProtocols))
+	for _, db := range dbProtocols {
+		response = append(response, databaseToProtocol(db))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleProtocolGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleProtocolGet")
+	defer slog.Debug("<<handleProtocolGet")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge protocol id", err)
+		return
+	}
+
+	dbProtocol, err := h.store.GetPlungeProtocol(r.Context(), id)
+	if err != nil {
+		respondWithProtocolError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseToProtocol(dbProtocol))
+}
+
+func (h *Handler) handleProtocolCreate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleProtocolCreate")
+	defer slog.Debug("<<handleProtocolCreate")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	request, phases, err := parseProtocolRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbProtocol, err := h.store.CreatePlungeProtocol(r.Context(), database.CreatePlungeProtocolParams{
+		Name:        request.Name,
+		Description: request.Description,
+		Rounds:      int32(request.Rounds),
+		Phases:      phases,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to create the plunge protocol", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, databaseToProtocol(dbProtocol))
+}
+
+func (h *Handler) handleProtocolUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleProtocolUpdate")
+	defer slog.Debug("<<handleProtocolUpdate")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge protocol id", err)
+		return
+	}
+
+	request, phases, err := parseProtocolRequest(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// a running plunge keeps the phases it started with
+	dbProtocol, err := h.store.UpdatePlungeProtocol(r.Context(), database.UpdatePlungeProtocolParams{
+		ID:          id,
+		Name:        request.Name,
+		Description: request.Description,
+		Rounds:      int32(request.Rounds),
+		Phases:      phases,
+	})
+	if err != nil {
+		respondWithProtocolError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, databaseToProtocol(dbProtocol))
+}
+
+func (h *Handler) handleProtocolDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleProtocolDelete")
+	defer slog.Debug("<<handleProtocolDelete")
+
+	_, err := h.authorizedUser(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid plunge protocol id", err)
+		return
+	}
+
+	count, err := h.store.DeletePlungeProtocol(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to delete the plunge protocol", err)
+		return
+	}
+
+	if count == 0 {
+		respondWithProtocolError(w, sql.ErrNoRows)
+		return
+	}
+
+	utils.RespondWithNoContent(w, http.StatusNoContent)
+}
+
+// parseProtocolRequest reads and validates the protocol in the body and encodes its phases for the database.
+// A protocol without rounds runs its phases once.
+func parseProtocolRequest(r *http.Request) (protocol.Protocol, json.RawMessage, error) {
+	var request protocol.Protocol
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, nil, ErrInvalidProtocolBody
+	}
+
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return request, nil, ErrInvalidProtocolBody
+	}
+
+	if request.Rounds == 0 {
+		request.Rounds = 1
+	}
+
+	if err := request.Validate(); err != nil {
+		return request, nil, err
+	}
+
+	phases, err := json.Marshal(request.Phases)
+	if err != nil {
+		return request, nil, ErrInvalidProtocolBody
+	}
+
+	return request, phases, nil
+}
+
+func respondWithProtocolError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "could not find the plunge protocol", err)
+		return
+	}
+
+	utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the plunge protocol", err)
+}
+
+// databaseToProtocol converts the protocol, the phases were validated before they were stored.
+func databaseToProtocol(db database.PlungeProtocol) ProtocolResponse {
+	p := protocol.Protocol{
+		Name:        db.Name,
+		Description: db.Description,
+		Rounds:      int(db.Rounds),
+	}
+
+	if err := json.Unmarshal(db.Phases, &p.Phases); err != nil {
+		slog.Warn("failed to read the phases of the plunge protocol", "id", db.ID, "error", err)
+	}
+
+	return ProtocolResponse{
+		ID:              db.ID,
+		CreatedAt:       db.CreatedAt,
+		UpdatedAt:       db.UpdatedAt,
+		Protocol:        p,
+		DurationSeconds: protocol.Duration(p.Expand()).Seconds(),
+	}
+}
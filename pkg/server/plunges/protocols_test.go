@@ -0,0 +1,174 @@
+package plunges
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var testProtocol = database.PlungeProtocol{
+	ID:     uuid.New(),
+	Name:   "contrast",
+	Rounds: 3,
+	Phases: json.RawMessage(`[{"name": "", "kind": "cold", "duration_seconds": 60}, {"name": "warm up", "kind": "rest", "duration_seconds": 180}]`),
+}
+
+func TestProtocolCreate(t *testing.T) {
+	t.Run("should fail without an API key", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/plunges/protocols", bytes.NewBufferString("{}"), handler.handleProtocolCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+	})
+
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/protocols", nil, bytes.NewBufferString("{"), handler.handleProtocolCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, ErrInvalidProtocolBody.Error())
+	})
+
+	t.Run("should fail with an invalid phase", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		body := `{"name": "sauna", "phases": [{"kind": "sauna", "duration_seconds": 600}]}`
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/protocols", nil, bytes.NewBufferString(body), handler.handleProtocolCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, protocol.ErrInvalidPhaseKind.Error())
+	})
+
+	t.Run("should run the phases once by default", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		body := `{"name": "3 min cold", "phases": [{"kind": "cold", "duration_seconds": 180}]}`
+		rr := authorizedRequestWithBody(t, http.MethodPost, "/v1/plunges/protocols", nil, bytes.NewBufferString(body), handler.handleProtocolCreate)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		var resp ProtocolResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if plungeStore.created.Rounds != 1 || resp.DurationSeconds != 180 || len(resp.Phases) != 1 {
+			t.Errorf("unexpected protocol %+v", resp)
+		}
+	})
+}
+
+func TestProtocolUpdate(t *testing.T) {
+	t.Run("should fail for an unknown protocol", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{protocol: testProtocol}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		body := `{"name": "3 min cold", "phases": [{"kind": "cold", "duration_seconds": 180}]}`
+		rr := authorizedRequestWithBody(t, http.MethodPut, "/v1/plunges/protocols/"+id, map[string]string{"id": id}, bytes.NewBufferString(body), handler.handleProtocolUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+}
+
+func TestProtocolDelete(t *testing.T) {
+	t.Run("should fail for an unknown protocol", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		rr := authorizedRequest(t, http.MethodDelete, "/v1/plunges/protocols/"+id, map[string]string{"id": id}, handler.handleProtocolDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should delete the protocol", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{deleted: 1}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := uuid.New().String()
+		rr := authorizedRequest(t, http.MethodDelete, "/v1/plunges/protocols/"+id, map[string]string{"id": id}, handler.handleProtocolDelete)
+		utils.TestExpectedStatus(t, rr, http.StatusNoContent)
+	})
+}
+
+func TestPlungeStartWithProtocol(t *testing.T) {
+	t.Run("should fail with both a duration and a protocol", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{protocol: testProtocol}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		url := "/v1/plunges/start?duration=120&protocol=" + testProtocol.ID.String()
+		rr := authorizedRequest(t, http.MethodPost, url, nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail for an unknown protocol", func(t *testing.T) {
+		handler := NewHandler(&mockPlungeStore{protocol: testProtocol}, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v1/plunges/start?protocol="+uuid.New().String(), nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusNotFound)
+	})
+
+	t.Run("should time the plunge by the phases of the protocol", func(t *testing.T) {
+		plungeStore := mockPlungeStore{protocol: testProtocol}
+		timer := mockTimer{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &timer, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v1/plunges/start?protocol="+testProtocol.ID.String(), nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if timer.started == nil || timer.started.ExpectedDuration != 720 || timer.started.ProtocolID.UUID != testProtocol.ID {
+			t.Fatalf("unexpected plunge %+v", timer.started)
+		}
+
+		var phases []protocol.Phase
+		if err := json.Unmarshal(timer.started.ProtocolPhases, &phases); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(phases) != 6 || phases[0].Name != protocol.PHASE_COLD || phases[5].Name != "warm up" {
+			t.Errorf("unexpected phases %+v", phases)
+		}
+	})
+
+	t.Run("should start a plunge without phases by default", func(t *testing.T) {
+		plungeStore := mockPlungeStore{}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		rr := authorizedRequest(t, http.MethodPost, "/v1/plunges/start", nil, handler.handlePlungesStart)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if plungeStore.plunge.ProtocolID.Valid || string(plungeStore.plunge.ProtocolPhases) != "[]" {
+			t.Errorf("unexpected protocol %v %s", plungeStore.plunge.ProtocolID, plungeStore.plunge.ProtocolPhases)
+		}
+	})
+}
+
+func TestPlungeGetPhases(t *testing.T) {
+	t.Run("should return the recorded phases", func(t *testing.T) {
+		start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+		plungeStore := mockPlungeStore{
+			plungeID: uuid.New(),
+			phases: []database.PlungePhase{
+				{Position: 0, Name: "cold", Kind: protocol.PHASE_COLD, DurationSeconds: 60, StartedAt: start, EndedAt: start.Add(time.Minute), Completed: true},
+				{Position: 1, Name: "rest", Kind: protocol.PHASE_REST, DurationSeconds: 180, StartedAt: start.Add(time.Minute), EndedAt: start.Add(2 * time.Minute)},
+			},
+		}
+		plungeStore.plunge.ID = plungeStore.plungeID
+		plungeStore.plunge.UserID = uuid.NullUUID{UUID: testUser.ID, Valid: true}
+		handler := NewHandler(&plungeStore, &mockSensors{}, &mockGuard{}, &mockTimer{}, testColdDoseThreshold)
+
+		id := plungeStore.plungeID.String()
+		rr := authorizedRequest(t, http.MethodGet, "/v1/plunges/"+id, map[string]string{"id": id}, handler.handlePlungeGet)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var resp PlungeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Phases) != 2 || resp.Phases[0].Number != 1 || !resp.Phases[0].Completed || resp.Phases[1].Completed {
+			t.Errorf("unexpected phases %+v", resp.Phases)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package plunges
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+)
+
+// summarizePlunges totals the completed plunges and finds the streaks and personal bests.
+// Days are counted in loc, and the current streak is still alive if the last plunge was yesterday.
+func summarizePlunges(dbPlunges []database.Plunge, from, to time.Time, below *float64, now time.Time, loc *time.Location) PlungeStatsResponse {
+	stats := PlungeStatsResponse{
+		From:      from,
+		To:        to,
+		BelowTemp: below,
+	}
+
+	var totalTemp float64
+	var tempCount int
+	days := make([]time.Time, 0, len(dbPlunges))
+
+	for _, p := range dbPlunges {
+		if !p.StartTime.Valid || !p.EndTime.Valid {
+			continue
+		}
+
+		duration := p.EndTime.Time.Sub(p.StartTime.Time).Seconds()
+		waterTemp, hasTemp := plungeWaterTemp(p)
+		best := &PlungeBest{
+			ID:              p.ID,
+			StartTime:       p.StartTime.Time,
+			DurationSeconds: duration,
+			WaterTemp:       waterTemp,
+		}
+
+		stats.Sessions++
+		stats.TotalMinutes += duration / 60
+		days = append(days, day(p.StartTime.Time, loc))
+
+		if stats.Longest == nil || duration > stats.Longest.DurationSeconds {
+			stats.Longest = best
+		}
+
+		if !hasTemp {
+			continue
+		}
+
+		totalTemp += waterTemp
+		tempCount++
+
+		if stats.Coldest == nil || waterTemp < stats.Coldest.WaterTemp {
+			stats.Coldest = best
+		}
+
+		if below != nil && waterTemp < *below && (stats.LongestBelow == nil || duration > stats.LongestBelow.DurationSeconds) {
+			stats.LongestBelow = best
+		}
+	}
+
+	if tempCount != 0 {
+		stats.AvgWaterTemp = totalTemp / float64(tempCount)
+	}
+
+	if weeks := to.Sub(from).Hours() / (24 * 7); weeks > 0 {
+		stats.SessionsPerWeek = float64(stats.Sessions) / weeks
+	}
+
+	stats.CurrentStreakDays, stats.LongestStreakDays = streaks(days, day(now, loc))
+
+	return stats
+}
+
+// streaks returns the current and longest runs of consecutive days, the days must be in ascending order.
+func streaks(days []time.Time, today time.Time) (int, int) {
+	var current, longest int
+	var last time.Time
+
+	for _, d := range days {
+		switch {
+		case d.Equal(last):
+			// more than one plunge on the same day
+			continue
+		case !last.IsZero() && last.AddDate(0, 0, 1).Equal(d):
+			current++
+		default:
+			current = 1
+		}
+
+		last = d
+		longest = max(longest, current)
+	}
+
+	// the streak is broken once a whole day has passed without a plunge
+	if last.IsZero() || last.AddDate(0, 0, 1).Before(today) {
+		current = 0
+	}
+
+	return current, longest
+}
+
+// day returns midnight of the day t falls on in loc.
+func day(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// plungeWaterTemp returns the average water temperature of the plunge, or the temperature at the start
+// if the average wasn't recorded. A temperature of zero was never read.
+func plungeWaterTemp(p database.Plunge) (float64, bool) {
+	for _, s := range []string{p.AvgWaterTemp, p.StartWaterTemp} {
+		t, err := strconv.ParseFloat(s, 64)
+		if err == nil && t != 0 {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
@@ -2,44 +2,283 @@ package plunges
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/protocol"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/google/uuid"
 )
 
-const DefaultPlungeDurationSeconds = "180"
+const (
+	GOAL_MINUTES  = "minutes"
+	GOAL_DOSE     = "dose"
+	GOAL_SESSIONS = "sessions"
+
+	// MaxGoalWeeks is how many weeks back the streaks of a goal are counted.
+	MaxGoalWeeks = 52
+
+	// MinGoalTempF and MaxGoalTempF bound the water temperature a goal is measured below.
+	MinGoalTempF = 32.0
+	MaxGoalTempF = 110.0
+)
+
+const (
+	DefaultPlungeDurationSeconds = "180"
+
+	// DefaultPlungeRange is the window of the plunge history and stats when 'from' isn't given.
+	DefaultPlungeRange = 365 * 24 * time.Hour
+
+	// DefaultPlungesLimit is the number of plunges returned when 'limit' isn't given.
+	DefaultPlungesLimit = 50
+
+	MaxJournalNotesLength = 2000
+	MaxJournalTags        = 10
+	MaxJournalTagLength   = 32
+
+	// MaxHeartRateImportBytes is the largest heart rate file that can be imported.
+	MaxHeartRateImportBytes = 10 << 20
+)
 
 type (
 	PlungeResponse struct {
-		ID               uuid.UUID `json:"id"`
-		CreatedAt        time.Time `json:"created_at"`
-		UpdatedAt        time.Time `json:"updated_at"`
-		StartTime        time.Time `json:"start_time"`
-		StartWaterTemp   string    `json:"start_water_temp"`
-		StartRoomTemp    string    `json:"start_room_temp"`
-		EndTime          time.Time `json:"end_time"`
-		EndWaterTemp     string    `json:"end_water_temp"`
-		EndRoomTemp      string    `json:"end_room_temp"`
-		Running          bool      `json:"running"`
-		ExpectedDuration int32     `json:"expected_duration"`
-		AvgWaterTemp     string    `json:"average_water_temp"`
-		AvgRoomTemp      string    `json:"average_room_temp"`
+		ID               uuid.UUID  `json:"id"`
+		CreatedAt        time.Time  `json:"created_at"`
+		UpdatedAt        time.Time  `json:"updated_at"`
+		StartTime        time.Time  `json:"start_time"`
+		StartWaterTemp   string     `json:"start_water_temp"`
+		StartRoomTemp    string     `json:"start_room_temp"`
+		EndTime          time.Time  `json:"end_time"`
+		EndWaterTemp     string     `json:"end_water_temp"`
+		EndRoomTemp      string     `json:"end_room_temp"`
+		Running          bool       `json:"running"`
+		ExpectedDuration int32      `json:"expected_duration"`
+		AvgWaterTemp     string     `json:"average_water_temp"`
+		AvgRoomTemp      string     `json:"average_room_temp"`
+		MinWaterTemp     string     `json:"min_water_temp"`
+		MaxWaterTemp     string     `json:"max_water_temp"`
+		MinRoomTemp      string     `json:"min_room_temp"`
+		MaxRoomTemp      string     `json:"max_room_temp"`
+		UserID           *uuid.UUID `json:"user_id,omitempty"`
+		ProtocolID       *uuid.UUID `json:"protocol_id,omitempty"`
+		Notes            string     `json:"notes"`
+		PerceivedEffort  *int32     `json:"perceived_effort"`
+		Tags             []string   `json:"tags"`
+
+		// DurationSeconds is from start_time to end_time, it is zero until the plunge is stopped.
+		DurationSeconds float64 `json:"duration_seconds"`
+
+		// Temperatures are sampled while the plunge is running, they are only returned for a single plunge.
+		Temperatures []PlungeTemperatureSample `json:"temperatures,omitempty"`
+
+		// Phases of a guided plunge as they were recorded, they are only returned for a single plunge.
+		Phases []PlungePhaseResponse `json:"phases,omitempty"`
+
+		// HeartRate is imported from a wearable, it is only returned for a single plunge.
+		HeartRate *PlungeHeartRateResponse `json:"heart_rate,omitempty"`
+
+		// ColdDose is measured from the temperature samples, it is only returned for a single completed plunge.
+		ColdDose *ColdDose `json:"cold_dose,omitempty"`
+	}
+
+	// ColdDose is the exposure to water below a threshold temperature in Fahrenheit, DegreeMinutes is the
+	// number of degrees below the threshold multiplied by the minutes spent there.
+	ColdDose struct {
+		Threshold     float64 `json:"threshold"`
+		MinutesBelow  float64 `json:"minutes_below"`
+		DegreeMinutes float64 `json:"degree_minutes"`
+	}
+
+	// PlungeGoalRequest creates or replaces a weekly goal, e.g. 11 minutes per week below 50°F is
+	// {"kind": "minutes", "target": 11, "below_temp": 50}. A 'dose' goal is in degree minutes and a
+	// 'sessions' goal counts the plunges with any time below the temperature, which defaults to the
+	// configured cold dose threshold.
+	PlungeGoalRequest struct {
+		Name      string   `json:"name"`
+		Kind      string   `json:"kind"`
+		Target    float64  `json:"target"`
+		BelowTemp *float64 `json:"below_temp"`
+	}
+
+	// GoalStatus is the progress of a weekly goal in the current week, which starts on Monday.
+	// Streaks are counted in weeks the goal was met since it was created, the current streak
+	// includes this week once the goal has been met.
+	GoalStatus struct {
+		ID                 uuid.UUID `json:"id"`
+		UserID             uuid.UUID `json:"user_id"`
+		Name               string    `json:"name"`
+		Kind               string    `json:"kind"`
+		Target             float64   `json:"target"`
+		BelowTemp          float64   `json:"below_temp"`
+		WeekStart          time.Time `json:"week_start"`
+		Progress           float64   `json:"progress"`
+		Percent            float64   `json:"percent"`
+		Completed          bool      `json:"completed"`
+		CurrentStreakWeeks int       `json:"current_streak_weeks"`
+		LongestStreakWeeks int       `json:"longest_streak_weeks"`
+	}
+
+	// PlungeJournalRequest replaces the journal of a plunge, PerceivedEffort is from 1 to 10 and can be left out.
+	PlungeJournalRequest struct {
+		Notes           string   `json:"notes"`
+		PerceivedEffort *int     `json:"perceived_effort"`
+		Tags            []string `json:"tags"`
+	}
+
+	// PlungeHeartRateResponse summarizes the heart rate from before a plunge until after it.
+	// RecoverySeconds is nil if the heart rate didn't get back near the resting heart rate before the samples end.
+	PlungeHeartRateResponse struct {
+		RestingBPM      float64           `json:"resting_bpm"`
+		PeakBPM         int               `json:"peak_bpm"`
+		PeakAt          time.Time         `json:"peak_at"`
+		AvgBPM          float64           `json:"average_bpm"`
+		RecoverySeconds *float64          `json:"recovery_seconds"`
+		Samples         []HeartRateSample `json:"samples"`
+	}
+
+	HeartRateSample struct {
+		ReadAt time.Time `json:"read_at"`
+		BPM    int       `json:"bpm"`
+	}
+
+	// PlungePhaseResponse is a phase of a guided plunge, a phase cut short by stopping the plunge isn't completed.
+	PlungePhaseResponse struct {
+		Number          int       `json:"number"`
+		Name            string    `json:"name"`
+		Kind            string    `json:"kind"`
+		DurationSeconds int       `json:"duration_seconds"`
+		StartedAt       time.Time `json:"started_at"`
+		EndedAt         time.Time `json:"ended_at"`
+		Completed       bool      `json:"completed"`
+	}
+
+	// ProtocolResponse is a plunge protocol, DurationSeconds is the length of every round.
+	ProtocolResponse struct {
+		ID        uuid.UUID `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		protocol.Protocol
+		DurationSeconds float64 `json:"duration_seconds"`
+	}
+
+	// PlungeTemperatureSample is the water and room temperature in Fahrenheit at a point in the plunge.
+	PlungeTemperatureSample struct {
+		ReadAt    time.Time `json:"read_at"`
+		WaterTemp *float64  `json:"water_temp"`
+		RoomTemp  *float64  `json:"room_temp"`
+	}
+
+	// PlungeStatsResponse summarizes the completed plunges that started in a window.
+	// Streaks are counted in days, a current streak is kept alive until a day without a plunge has passed.
+	PlungeStatsResponse struct {
+		From              time.Time `json:"from"`
+		To                time.Time `json:"to"`
+		Sessions          int       `json:"sessions"`
+		TotalMinutes      float64   `json:"total_minutes"`
+		SessionsPerWeek   float64   `json:"sessions_per_week"`
+		AvgWaterTemp      float64   `json:"average_water_temp"`
+		CurrentStreakDays int       `json:"current_streak_days"`
+		LongestStreakDays int       `json:"longest_streak_days"`
+
+		// ColdDose totals the cold dose of the plunges below the 'below' temperature, or the configured threshold.
+		ColdDose ColdDose `json:"cold_dose"`
+
+		// Goals are the user's weekly goals and their progress this week.
+		Goals []GoalStatus `json:"goals"`
+
+		// Personal bests, LongestBelow is the longest plunge with the water below the 'below' temperature.
+		Longest      *PlungeBest `json:"longest,omitempty"`
+		Coldest      *PlungeBest `json:"coldest,omitempty"`
+		BelowTemp    *float64    `json:"below_temp,omitempty"`
+		LongestBelow *PlungeBest `json:"longest_below,omitempty"`
+	}
+
+	// PlungeLeaderboardResponse compares the completed plunges of every user in a window, most cold exposure first.
+	PlungeLeaderboardResponse struct {
+		From  time.Time                `json:"from"`
+		To    time.Time                `json:"to"`
+		Users []PlungeLeaderboardEntry `json:"users"`
+	}
+
+	PlungeLeaderboardEntry struct {
+		UserID         uuid.UUID `json:"user_id"`
+		Email          string    `json:"email"`
+		Sessions       int64     `json:"sessions"`
+		TotalMinutes   float64   `json:"total_minutes"`
+		LongestSeconds float64   `json:"longest_seconds"`
+		LastPlunge     time.Time `json:"last_plunge"`
+	}
+
+	// PlungeBest identifies the plunge that set a personal best.
+	PlungeBest struct {
+		ID              uuid.UUID `json:"id"`
+		StartTime       time.Time `json:"start_time"`
+		DurationSeconds float64   `json:"duration_seconds"`
+		WaterTemp       float64   `json:"water_temp"`
+	}
+
+	// GoalStore reads the goals of a user and the plunges they are tracked with.
+	GoalStore interface {
+		GetPlungeGoals(ctx context.Context, userID uuid.UUID) ([]database.PlungeGoal, error)
+		GetCompletedPlunges(ctx context.Context, arg database.GetCompletedPlungesParams) ([]database.Plunge, error)
+		GetCompletedPlungeTemperatures(ctx context.Context, arg database.GetCompletedPlungeTemperaturesParams) ([]database.PlungeTemperature, error)
 	}
 
 	PlungeStore interface {
-		FindMostRecentTemperatures(ctx context.Context) (database.Temperature, error)
+		GoalStore
+		GetLatestTemperatureByRole(ctx context.Context, deviceRole string) (database.TemperatureReading, error)
 		GetLatestPlunge(ctx context.Context) (database.Plunge, error)
 		GetPlungeByID(ctx context.Context, id uuid.UUID) (database.Plunge, error)
-		GetPlunges(ctx context.Context) ([]database.Plunge, error)
+		GetPlunges(ctx context.Context, arg database.GetPlungesParams) ([]database.Plunge, error)
+		CountPlunges(ctx context.Context, arg database.CountPlungesParams) (int64, error)
+		GetPlungeLeaderboard(ctx context.Context, arg database.GetPlungeLeaderboardParams) ([]database.GetPlungeLeaderboardRow, error)
+		GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error)
 		StartPlunge(ctx context.Context, arg database.StartPlungeParams) (database.Plunge, error)
-		UpdatePlungeAvgTemp(ctx context.Context, arg database.UpdatePlungeAvgTempParams) (database.Plunge, error)
+		GetPlungeTemperatures(ctx context.Context, plungeID uuid.UUID) ([]database.PlungeTemperature, error)
 		StopPlunge(ctx context.Context, arg database.StopPlungeParams) (database.Plunge, error)
+		GetPlungePhases(ctx context.Context, plungeID uuid.UUID) ([]database.PlungePhase, error)
+		GetPlungeProtocols(ctx context.Context) ([]database.PlungeProtocol, error)
+		GetPlungeProtocol(ctx context.Context, id uuid.UUID) (database.PlungeProtocol, error)
+		CreatePlungeProtocol(ctx context.Context, arg database.CreatePlungeProtocolParams) (database.PlungeProtocol, error)
+		UpdatePlungeProtocol(ctx context.Context, arg database.UpdatePlungeProtocolParams) (database.PlungeProtocol, error)
+		DeletePlungeProtocol(ctx context.Context, id uuid.UUID) (int64, error)
+		UpdatePlungeJournal(ctx context.Context, arg database.UpdatePlungeJournalParams) (database.Plunge, error)
+		DeletePlungeHeartRates(ctx context.Context, plungeID uuid.UUID) error
+		SavePlungeHeartRates(ctx context.Context, arg database.SavePlungeHeartRatesParams) (int64, error)
+		GetPlungeHeartRates(ctx context.Context, plungeID uuid.UUID) ([]database.PlungeHeartRate, error)
+		CreatePlungeGoal(ctx context.Context, arg database.CreatePlungeGoalParams) (database.PlungeGoal, error)
+		UpdatePlungeGoal(ctx context.Context, arg database.UpdatePlungeGoalParams) (database.PlungeGoal, error)
+		DeletePlungeGoal(ctx context.Context, arg database.DeletePlungeGoalParams) (int64, error)
+
+		// ExecTx runs fn with a store whose queries are made in one transaction, it is rolled back if fn fails.
+		ExecTx(ctx context.Context, fn func(PlungeStore) error) error
+	}
+
+	// Store is the PlungeStore for the database connection.
+	Store struct {
+		*database.Queries
+		db *sql.DB
+	}
+
+	// Guard checks an action against the interlock rules before it is taken.
+	Guard interface {
+		CheckAction(ctx context.Context, action string) error
+	}
+
+	// Timer completes a running plunge when its expected duration passes.
+	Timer interface {
+		StartPlungeTimer(p database.Plunge)
+		StopPlungeTimer(id uuid.UUID)
 	}
 
 	Handler struct {
 		store   PlungeStore
 		sensors sensor.Sensors
+		guard   Guard
+		timer   Timer
+
+		// coldDoseThreshold is the water temperature in Fahrenheit the cold dose is measured below.
+		coldDoseThreshold float64
 	}
 )
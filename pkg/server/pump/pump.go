@@ -4,12 +4,15 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 )
 
-func NewHandler(pump PumpSensor) *Handler {
+func NewHandler(pump PumpSensor, guard Guard) *Handler {
 	return &Handler{
 		pump,
+		guard,
 	}
 }
 
@@ -22,9 +25,9 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 func (h *Handler) handlerPumpGet(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("handlerPumpGet")
 
-	pumpOn, err := h.pump.IsPumpOn()
+	pumpOn, err := h.pump.IsPumpOn(r.Context())
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "could not start the ozone timer", err)
+		utils.RespondWithError(w, sensorErrorStatus(err), "could not start the ozone timer", err)
 		return
 	}
 
@@ -39,9 +42,19 @@ func (h *Handler) handlerPumpGet(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) handlerPumpStart(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("handlerPumpStart")
-	err := h.pump.TurnPumpOn()
+
+	err := h.guard.CheckAction(r.Context(), interlock.ACTION_PUMP_ON)
+	if interlock.IsViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, err.Error(), err)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, sensorErrorStatus(err), "failed to check the interlock state", err)
+		return
+	}
+
+	err = h.pump.TurnPumpOn(r.Context())
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "failed to turn on the pump", err)
+		utils.RespondWithError(w, sensorErrorStatus(err), "failed to turn on the pump", err)
 		return
 	}
 
@@ -51,11 +64,20 @@ func (h *Handler) handlerPumpStart(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handlerPumpStop(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("handlerPumpStop")
 
-	err := h.pump.TurnPumpOff()
+	err := h.pump.TurnPumpOff(r.Context())
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "failed to turn off the pump", err)
+		utils.RespondWithError(w, sensorErrorStatus(err), "failed to turn off the pump", err)
 		return
 	}
 
 	utils.RespondWithNoContent(w, http.StatusNoContent)
 }
+
+// sensorErrorStatus reports a timed out device as a gateway timeout so clients can tell it apart from other failures.
+func sensorErrorStatus(err error) int {
+	if sensor.IsTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
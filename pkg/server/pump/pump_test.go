@@ -1,10 +1,14 @@
 package pump
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/interlock"
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 )
 
@@ -14,7 +18,7 @@ func TestPumpStatusIsOn(t *testing.T) {
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = nil
 
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/pump", nil, handler.handlerPumpGet)
@@ -29,7 +33,7 @@ func TestPumpStatusIsOn(t *testing.T) {
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = errors.New("failed to start pump")
 
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/pump", nil, handler.handlerPumpGet)
@@ -40,13 +44,29 @@ func TestPumpStatusIsOn(t *testing.T) {
 	})
 }
 
+func TestPumpTimeout(t *testing.T) {
+	t.Run("should report a timed out pump as a gateway timeout", func(t *testing.T) {
+		pumpSensor := mockPumpSensor{
+			on:  true,
+			err: &sensor.TimeoutError{DeviceID: "pump", Operation: "read switch", Timeout: 5 * time.Second},
+		}
+		handler := NewHandler(&pumpSensor, &mockGuard{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/pump", nil, handler.handlerPumpGet)
+
+		if rr.Code != http.StatusGatewayTimeout {
+			t.Errorf("expected status code %d, got %d", http.StatusGatewayTimeout, rr.Code)
+		}
+	})
+}
+
 func TestPumpPower(t *testing.T) {
 	t.Run("should turn pump on", func(t *testing.T) {
 		pumpSensor := mockPumpSensor{
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = nil
 
 		rr := utils.TestRequest(t, http.MethodPost, "/v1/pump", nil, handler.handlerPumpStart)
@@ -61,7 +81,7 @@ func TestPumpPower(t *testing.T) {
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = errors.New("failed")
 
 		rr := utils.TestRequest(t, http.MethodPost, "/v1/pump", nil, handler.handlerPumpStart)
@@ -76,7 +96,7 @@ func TestPumpPower(t *testing.T) {
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = nil
 
 		rr := utils.TestRequest(t, http.MethodPost, "/v1/pump", nil, handler.handlerPumpStop)
@@ -91,7 +111,7 @@ func TestPumpPower(t *testing.T) {
 			on:  true,
 			err: nil,
 		}
-		handler := NewHandler(&pumpSensor)
+		handler := NewHandler(&pumpSensor, &mockGuard{})
 		pumpSensor.err = errors.New("failed")
 
 		rr := utils.TestRequest(t, http.MethodPost, "/v1/pump", nil, handler.handlerPumpStop)
@@ -102,21 +122,45 @@ func TestPumpPower(t *testing.T) {
 	})
 }
 
+func TestPumpInterlock(t *testing.T) {
+	t.Run("should refuse to turn the pump on during a leak", func(t *testing.T) {
+		pumpSensor := mockPumpSensor{}
+		guard := mockGuard{state: interlock.State{Leaks: []interlock.LeakZone{{Name: "under tub", ShutOff: []string{interlock.DEVICE_PUMP}}}}}
+		handler := NewHandler(&pumpSensor, &guard)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/pump/start", nil, handler.handlerPumpStart)
+		utils.TestExpectedStatus(t, rr, http.StatusConflict)
+		utils.TestExpectedMessage(t, rr, "the pump can't run while a leak is detected")
+
+		if pumpSensor.on {
+			t.Errorf("expected the pump to stay off")
+		}
+	})
+}
+
+type mockGuard struct {
+	state interlock.State
+}
+
+func (m *mockGuard) CheckAction(ctx context.Context, action string) error {
+	return interlock.Check(m.state, action)
+}
+
 type mockPumpSensor struct {
 	on  bool
 	err error
 }
 
-func (m *mockPumpSensor) IsPumpOn() (bool, error) {
+func (m *mockPumpSensor) IsPumpOn(ctx context.Context) (bool, error) {
 	return m.on, m.err
 }
 
-func (m *mockPumpSensor) TurnPumpOn() error {
+func (m *mockPumpSensor) TurnPumpOn(ctx context.Context) error {
 	m.on = true
 	return m.err
 }
 
-func (m *mockPumpSensor) TurnPumpOff() error {
+func (m *mockPumpSensor) TurnPumpOff(ctx context.Context) error {
 	m.on = false
 	return m.err
 }
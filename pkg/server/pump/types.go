@@ -1,11 +1,19 @@
 package pump
 
+import "context"
+
 type PumpSensor interface {
-	IsPumpOn() (bool, error)
-	TurnPumpOn() error
-	TurnPumpOff() error
+	IsPumpOn(ctx context.Context) (bool, error)
+	TurnPumpOn(ctx context.Context) error
+	TurnPumpOff(ctx context.Context) error
+}
+
+// Guard checks an action against the interlock rules before it is taken.
+type Guard interface {
+	CheckAction(ctx context.Context, action string) error
 }
 
 type Handler struct {
-	pump PumpSensor
+	pump  PumpSensor
+	guard Guard
 }
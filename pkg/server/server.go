@@ -11,21 +11,25 @@ import (
 
 	"github.com/KyleBrandon/plunger-server/config"
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/pkg/server/alerts"
+	"github.com/KyleBrandon/plunger-server/pkg/server/debug"
 	"github.com/KyleBrandon/plunger-server/pkg/server/filters"
 	"github.com/KyleBrandon/plunger-server/pkg/server/health"
 	"github.com/KyleBrandon/plunger-server/pkg/server/leaks"
 	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
+	"github.com/KyleBrandon/plunger-server/pkg/server/notifications"
 	"github.com/KyleBrandon/plunger-server/pkg/server/ozone"
 	"github.com/KyleBrandon/plunger-server/pkg/server/plunges"
 	"github.com/KyleBrandon/plunger-server/pkg/server/pump"
+	"github.com/KyleBrandon/plunger-server/pkg/server/simulator"
 	"github.com/KyleBrandon/plunger-server/pkg/server/status"
 	"github.com/KyleBrandon/plunger-server/pkg/server/temperatures"
+	"github.com/KyleBrandon/plunger-server/pkg/server/thermostat"
 	"github.com/KyleBrandon/plunger-server/pkg/server/users"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 	"github.com/joho/godotenv"
-	"github.com/nikoksr/notify"
-	"github.com/nikoksr/notify/service/twilio"
 )
 
 const (
@@ -35,8 +39,9 @@ const (
 
 // Used by "flag" to read command line argument
 var (
-	cmdLineFlagMockSensor bool
-	cmdLineFlagLogLevel   string
+	cmdLineFlagMockSensor        bool
+	cmdLineFlagLogLevel          string
+	cmdLineFlagSimulatorScenario string
 )
 
 type ServerConfig struct {
@@ -45,13 +50,15 @@ type ServerConfig struct {
 	ServerPort         string
 	DatabaseURL        string
 	UseMockSensor      bool
+	SimulatorScenario  string
 	LogFileLocation    string
 	ConfigFileLocation string
 	Logger             *slog.Logger
 	LoggerLevel        *slog.LevelVar
 	LogFile            *os.File
-	Notifier           *notify.Notify
+	Notifier           *notification.Router
 
+	Settings       config.Config
 	Sensors        sensor.Sensors
 	Queries        *database.Queries
 	DBConnection   *sql.DB
@@ -73,6 +80,12 @@ func init() {
 		config.DefaultLogLevel.String(),
 		"The log level to start the server at",
 	)
+	flag.StringVar(
+		&cmdLineFlagSimulatorScenario,
+		"simulator_scenario",
+		"",
+		"Path to a scenario file that drives the mock sensor simulator.",
+	)
 }
 
 // InitializeServer to start working
@@ -91,27 +104,27 @@ func InitializeServer() error {
 
 	config.mux = http.NewServeMux()
 
-	config.mctx = monitor.InitializeMonitorContext(config.Notifier, config.Queries, config.Sensors)
+	config.mctx = monitor.InitializeMonitorContext(config.Notifier, config.Queries, config.Sensors, config.Settings)
 
-	healthHandler := health.NewHandler(config.LoggerLevel, config.Logger)
+	healthHandler := health.NewHandler(config.LoggerLevel, config.Logger, config.Sensors)
 	healthHandler.RegisterRoutes(config.mux)
 
-	temperatureHandler := temperatures.NewHandler(config.mctx, config.Sensors)
+	temperatureHandler := temperatures.NewHandler(config.Sensors, config.Queries)
 	temperatureHandler.RegisterRoutes(config.mux)
 
-	userHandler := users.NewHandler(config.Queries)
+	userHandler := users.NewHandler(users.NewStore(config.DBConnection, config.Queries))
 	userHandler.RegisterRoutes(config.mux)
 
 	ozoneHandler := ozone.NewHandler(config.Queries, config.Sensors, config.mctx)
 	ozoneHandler.RegisterRoutes(config.mux)
 
-	leakHandler := leaks.NewHandler(config.Queries)
+	leakHandler := leaks.NewHandler(config.Queries, config.mctx)
 	leakHandler.RegisterRoutes(config.mux)
 
-	pumpHandler := pump.NewHandler(config.Sensors)
+	pumpHandler := pump.NewHandler(config.Sensors, config.mctx)
 	pumpHandler.RegisterRoutes(config.mux)
 
-	plungesHandler := plunges.NewHandler(config.Queries, config.Sensors)
+	plungesHandler := plunges.NewHandler(plunges.NewStore(config.DBConnection, config.Queries), config.Sensors, config.mctx, config.mctx, config.Settings.ColdDoseThresholdF)
 	plungesHandler.RegisterRoutes(config.mux)
 
 	statusHandler := status.NewHandler(
@@ -125,6 +138,27 @@ func InitializeServer() error {
 	filterHandler := filters.NewHandler(config.Queries)
 	filterHandler.RegisterRoutes(config.mux)
 
+	thermostatHandler := thermostat.NewHandler(config.Queries, config.mctx)
+	thermostatHandler.RegisterRoutes(config.mux)
+
+	alertHandler := alerts.NewHandler(config.Queries)
+	alertHandler.RegisterRoutes(config.mux)
+
+	notificationHandler := notifications.NewHandler(config.Queries)
+	notificationHandler.RegisterRoutes(config.mux)
+
+	// the captured notifications are only available when a capture channel is configured
+	if config.Notifier.Capturing() {
+		debugHandler := debug.NewHandler(config.Notifier)
+		debugHandler.RegisterRoutes(config.mux)
+	}
+
+	// the simulator admin endpoints are only available when running with mock sensors
+	if config.UseMockSensor {
+		simulatorHandler := simulator.NewHandler(sensor.DefaultSimulator)
+		simulatorHandler.RegisterRoutes(config.mux)
+	}
+
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
 	}()
@@ -182,7 +216,23 @@ func initializeServerConfig() (ServerConfig, error) {
 		os.Exit(1)
 	}
 
+	if sc.UseMockSensor && len(sc.SimulatorScenario) != 0 {
+		err = sensor.DefaultSimulator.LoadScenarioFile(sc.SimulatorScenario)
+		if err != nil {
+			slog.Error("failed to load the simulator scenario", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	notifier, err := newNotifier(config.Notifications, sc.UseMockSensor)
+	if err != nil {
+		slog.Error("failed to initialize the notification channels", "error", err)
+		os.Exit(1)
+	}
+
+	sc.Settings = config
 	sc.Sensors = sensors
+	sc.Notifier = notifier
 	sc.OriginPatterns = config.OriginPatterns
 	sc.openDatabase()
 
@@ -217,31 +267,51 @@ func (sc *ServerConfig) readEnvironmentVariables() {
 		sc.ConfigFileLocation = DEFAULT_CONFIG_FILE_LOCATION
 	}
 
-	// TODO: better encapsulation and error handling
-	// TODO: add the "to phone" to the user account, flesh that out
-	twilioAccountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-	twilioAuthToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	twilioFromPhone := os.Getenv("TWILIO_FROM_PHONE_NO")
-	twilioToPhone := os.Getenv("TWILIO_TO_PHONE_NO")
-	if len(twilioAccountSID) != 0 {
-		slog.Debug("Twilio account information present, configuring Notifier")
+	// mock sensor flag is a command line flag for debugging
+	sc.UseMockSensor = cmdLineFlagMockSensor
+	sc.SimulatorScenario = cmdLineFlagSimulatorScenario
+}
 
-		twilioService, err := twilio.New(twilioAccountSID, twilioAuthToken, twilioFromPhone)
-		if err != nil {
-			log.Fatalf("failed to initialize Twilio service: %v", err)
+// newNotifier creates the notification channels from the configuration file.
+// If no channels are configured, the mock sensor mode captures the notifications so they can be inspected offline,
+// otherwise the Twilio environment variables are used to send SMS messages to the subscribed users, and every message to
+// TWILIO_TO_PHONE_NO if it is set.
+func newNotifier(settings notification.Config, useMockSensor bool) (*notification.Router, error) {
+	twilioAccountSID := os.Getenv("TWILIO_ACCOUNT_SID")
+	if len(settings.Channels) == 0 && useMockSensor {
+		slog.Debug("running with mock sensors, configuring a capture notification channel")
+
+		settings.Channels = append(settings.Channels, notification.ChannelConfig{
+			Name: notification.CHANNELTYPE_CAPTURE,
+			Type: notification.CHANNELTYPE_CAPTURE,
+		})
+	} else if len(settings.Channels) == 0 && len(twilioAccountSID) != 0 {
+		slog.Debug("Twilio account information present, configuring a twilio notification channel")
+
+		settings.Channels = append(settings.Channels, notification.ChannelConfig{
+			Name:       notification.CHANNELTYPE_TWILIO,
+			Type:       notification.CHANNELTYPE_TWILIO,
+			AccountSID: twilioAccountSID,
+			AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+			From:       os.Getenv("TWILIO_FROM_PHONE_NO"),
+		})
+
+		// the number every message was texted to before users subscribed keeps receiving them
+		if to := os.Getenv("TWILIO_TO_PHONE_NO"); len(to) != 0 {
+			settings.Channels[0].To = []string{to}
 		}
+	}
 
-		// Set the Twilio sender phone number and recipient
-		twilioService.AddReceivers(twilioToPhone) // Replace with recipient's phone number
+	router, err := notification.NewRouter(settings)
+	if err != nil {
+		return nil, err
+	}
 
-		// Create a notifier
-		notifier := notify.New()
-		notifier.UseServices(twilioService)
-		sc.Notifier = notifier
+	if len(router.Channels()) == 0 {
+		slog.Warn("no notification channels are configured")
 	}
 
-	// mock sensor flag is a command line flag for debugging
-	sc.UseMockSensor = cmdLineFlagMockSensor
+	return router, nil
 }
 
 func (sc *ServerConfig) configureLogger() {
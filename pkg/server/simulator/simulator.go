@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+// NewHandler creates the admin handler used to drive the mock sensor simulator.
+func NewHandler(sim SimulatorController) *Handler {
+	return &Handler{
+		sim,
+	}
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/simulator", h.handleSimulatorGet)
+	mux.HandleFunc("PUT /v1/simulator", h.handleSimulatorUpdate)
+	mux.HandleFunc("POST /v1/simulator/scenario", h.handleSimulatorScenario)
+}
+
+func (h *Handler) handleSimulatorGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleSimulatorGet")
+	defer slog.Debug("<<handleSimulatorGet")
+
+	utils.RespondWithJSON(w, http.StatusOK, h.sim.State())
+}
+
+// handleSimulatorUpdate will change the simulated temperatures, leak state, switches and faults.
+func (h *Handler) handleSimulatorUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleSimulatorUpdate")
+	defer slog.Debug("<<handleSimulatorUpdate")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for simulator update", err)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var update sensor.SimulatorUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for simulator update", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, h.sim.Update(update))
+}
+
+// handleSimulatorScenario will replace the running scenario with the one in the body.
+func (h *Handler) handleSimulatorScenario(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleSimulatorScenario")
+	defer slog.Debug("<<handleSimulatorScenario")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for simulator scenario", err)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var scenario sensor.SimulatorScenario
+	if err := json.Unmarshal(body, &scenario); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for simulator scenario", err)
+		return
+	}
+
+	h.sim.LoadScenario(scenario)
+
+	utils.RespondWithJSON(w, http.StatusCreated, h.sim.State())
+}
@@ -0,0 +1,72 @@
+package simulator
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func TestSimulatorUpdate(t *testing.T) {
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		sim := mockSimulator{}
+		h := NewHandler(&sim)
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/simulator", bytes.NewBufferString("{"), h.handleSimulatorUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, "Invalid body for simulator update")
+	})
+
+	t.Run("should toggle the leak", func(t *testing.T) {
+		sim := mockSimulator{}
+		h := NewHandler(&sim)
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/simulator", bytes.NewBufferString(`{"leak_present": true}`), h.handleSimulatorUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if !sim.state.LeakPresent {
+			t.Errorf("expected the simulated leak to be present")
+		}
+	})
+}
+
+func TestSimulatorScenario(t *testing.T) {
+	t.Run("should load the scenario", func(t *testing.T) {
+		sim := mockSimulator{}
+		h := NewHandler(&sim)
+
+		body := `{"initial": {"water_temperature_c": 4.5}, "steps": [{"after_seconds": 30, "leak_present": true}]}`
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/simulator/scenario", bytes.NewBufferString(body), h.handleSimulatorScenario)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if len(sim.scenario.Steps) != 1 {
+			t.Errorf("expected %d scenario steps, got %d", 1, len(sim.scenario.Steps))
+		}
+
+		if sim.scenario.Steps[0].LeakPresent == nil || !*sim.scenario.Steps[0].LeakPresent {
+			t.Errorf("expected the scenario step to set a leak")
+		}
+	})
+}
+
+type mockSimulator struct {
+	state    sensor.SimulatorState
+	scenario sensor.SimulatorScenario
+}
+
+func (m *mockSimulator) State() sensor.SimulatorState {
+	return m.state
+}
+
+func (m *mockSimulator) Update(update sensor.SimulatorUpdate) sensor.SimulatorState {
+	if update.LeakPresent != nil {
+		m.state.LeakPresent = *update.LeakPresent
+	}
+	return m.state
+}
+
+func (m *mockSimulator) LoadScenario(scenario sensor.SimulatorScenario) {
+	m.scenario = scenario
+}
@@ -0,0 +1,17 @@
+package simulator
+
+import (
+	"github.com/KyleBrandon/plunger-server/internal/sensor"
+)
+
+type (
+	SimulatorController interface {
+		State() sensor.SimulatorState
+		Update(update sensor.SimulatorUpdate) sensor.SimulatorState
+		LoadScenario(scenario sensor.SimulatorScenario)
+	}
+
+	Handler struct {
+		sim SimulatorController
+	}
+)
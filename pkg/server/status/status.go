@@ -2,15 +2,16 @@ package status
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
+	"github.com/KyleBrandon/plunger-server/pkg/server/plunges"
 	"github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
 )
@@ -22,11 +23,10 @@ func NewHandler(
 	originPatterns []string,
 ) *Handler {
 	h := Handler{
-		mctx,
-		store,
-		sensors,
-		PlungeState{},
-		originPatterns,
+		mctx:           mctx,
+		store:          store,
+		sensors:        sensors,
+		originPatterns: originPatterns,
 	}
 
 	return &h
@@ -83,17 +83,18 @@ func (h *Handler) monitorStatus(ctx context.Context, c *websocket.Conn) {
 
 			h.mctx.Unlock()
 
-			leakDetected, err := h.sensors.IsLeakPresent()
-			if err != nil {
-				errorMessages = append(errorMessages, err.Error())
+			leakZones := h.mctx.LeakZones()
+			leakDetected := false
+			for _, z := range leakZones {
+				leakDetected = leakDetected || z.LeakDetected
 			}
 
-			pumpIsOn, err := h.sensors.IsPumpOn()
+			pumpIsOn, err := h.sensors.IsPumpOn(ctx)
 			if err != nil {
 				errorMessages = append(errorMessages, err.Error())
 			}
 
-			ps, err := h.buildPlungeStatus(ctx, roomTemp, waterTemp)
+			ps, err := h.buildPlungeStatus(ctx)
 			if err != nil {
 				errorMessages = append(errorMessages, err.Error())
 			}
@@ -108,6 +109,11 @@ func (h *Handler) monitorStatus(ctx context.Context, c *websocket.Conn) {
 				errorMessages = append(errorMessages, err.Error())
 			}
 
+			goals, err := h.buildGoalStatus(ctx)
+			if err != nil {
+				errorMessages = append(errorMessages, err.Error())
+			}
+
 			status := SystemStatus{
 				ErrorMessages: errorMessages,
 				PlungeStatus:  ps,
@@ -115,8 +121,14 @@ func (h *Handler) monitorStatus(ctx context.Context, c *websocket.Conn) {
 				WaterTemp:     waterTemp,
 				RoomTemp:      roomTemp,
 				LeakDetected:  leakDetected,
+				LeakAlarm:     h.mctx.LeakAlarm(),
+				LeakZones:     leakZones,
 				PumpOn:        pumpIsOn,
 				FilterStatus:  fs,
+				Devices:       h.sensors.DeviceHealth(),
+				Goals:         goals,
+
+				ThermostatStatus: h.mctx.ThermostatStatus(),
 			}
 
 			err = wsjson.Write(ctx, c, status)
@@ -137,11 +149,7 @@ func (h *Handler) monitorStatus(ctx context.Context, c *websocket.Conn) {
 	}
 }
 
-func (h *Handler) buildPlungeStatus(
-	ctx context.Context,
-	roomTemp float64,
-	waterTemp float64,
-) (PlungeStatus, error) {
+func (h *Handler) buildPlungeStatus(ctx context.Context) (PlungeStatus, error) {
 	p, err := h.store.GetLatestPlunge(ctx)
 	if err != nil {
 		// failed to read the plunge status.
@@ -161,40 +169,6 @@ func (h *Handler) buildPlungeStatus(
 		remaining = 0
 	}
 
-	avgWaterTemp, err := strconv.ParseFloat(p.AvgWaterTemp, 64)
-	if err != nil {
-		avgWaterTemp = 0.0
-	}
-
-	avgRoomTemp, err := strconv.ParseFloat(p.AvgRoomTemp, 64)
-	if err != nil {
-		avgRoomTemp = 0.0
-	}
-
-	// while the plunge is running, track live average temperatures, otherwise display what's stored in the database
-	if p.Running {
-		h.state.MU.Lock()
-		avgRoomTemp, avgWaterTemp = h.updateAverageTemperatures(roomTemp, waterTemp)
-		h.state.MU.Unlock()
-
-		arg := database.UpdatePlungeAvgTempParams{
-			ID:           p.ID,
-			AvgRoomTemp:  fmt.Sprintf("%f", avgRoomTemp),
-			AvgWaterTemp: fmt.Sprintf("%f", avgWaterTemp),
-		}
-		_, err = h.store.UpdatePlungeAvgTemp(ctx, arg)
-		if err != nil {
-			slog.Error("Failed to update current plunge avgerage temperature", "error", err)
-		}
-	} else {
-		// If it's not running, the reset the average temperature tracker
-		h.state.MU.Lock()
-		h.state.WaterTempTotal = 0.0
-		h.state.RoomTempTotal = 0.0
-		h.state.TempReadCount = 0
-		h.state.MU.Unlock()
-	}
-
 	ps := PlungeStatus{
 		StartTime:        p.StartTime.Time,
 		StartWaterTemp:   p.StartWaterTemp,
@@ -206,12 +180,63 @@ func (h *Handler) buildPlungeStatus(
 		ExpectedDuration: int32(duration.Seconds()),
 		Remaining:        remaining.Seconds(),
 		ElapsedTime:      elapsedTime.Seconds(),
-		AvgWaterTemp:     avgWaterTemp,
-		AvgRoomTemp:      avgRoomTemp,
+		// the monitor keeps these up to date from the temperatures sampled while the plunge is running
+		AvgWaterTemp: parseTemperature(p.AvgWaterTemp),
+		MinWaterTemp: parseTemperature(p.MinWaterTemp),
+		MaxWaterTemp: parseTemperature(p.MaxWaterTemp),
+		AvgRoomTemp:  parseTemperature(p.AvgRoomTemp),
+	}
+
+	if p.Running {
+		ps.Phase = h.mctx.PlungePhase()
 	}
+
 	return ps, nil
 }
 
+// buildGoalStatus tracks the goals of the user of the latest plunge, a plunge without a user has no goals.
+// The goals are read again once they are older than GOAL_STATUS_INTERVAL.
+func (h *Handler) buildGoalStatus(ctx context.Context) ([]plunges.GoalStatus, error) {
+	h.goals.Lock()
+	defer h.goals.Unlock()
+
+	now := time.Now()
+	if now.Sub(h.goals.readAt) < GOAL_STATUS_INTERVAL {
+		return h.goals.goals, nil
+	}
+
+	goals := make([]plunges.GoalStatus, 0)
+
+	p, err := h.store.GetLatestPlunge(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.goals.readAt, h.goals.goals = now, goals
+		return goals, nil
+	} else if err != nil {
+		return goals, err
+	}
+
+	if p.UserID.Valid {
+		goals, err = plunges.TrackGoals(ctx, h.store, p.UserID.UUID, now, time.Local)
+		if err != nil {
+			return make([]plunges.GoalStatus, 0), err
+		}
+	}
+
+	h.goals.readAt, h.goals.goals = now, goals
+
+	return goals, nil
+}
+
+// parseTemperature returns zero for a temperature that can't be parsed.
+func parseTemperature(s string) float64 {
+	t, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0.0
+	}
+
+	return t
+}
+
 func (h *Handler) buildOzoneStatus(ctx context.Context) (OzoneStatus, error) {
 	ozone, err := h.store.GetLatestOzoneEntry(ctx)
 	if err != nil {
@@ -255,17 +280,3 @@ func (h *Handler) buildFilterStatus(ctx context.Context) (FilterStatus, error) {
 
 	return fs, nil
 }
-
-// Update the temperatures
-func (h *Handler) updateAverageTemperatures(
-	roomTemp float64,
-	waterTemp float64,
-) (float64, float64) {
-	h.state.WaterTempTotal += waterTemp
-	h.state.RoomTempTotal += roomTemp
-	h.state.TempReadCount++
-	avgWaterTemp := h.state.WaterTempTotal / float64(h.state.TempReadCount)
-	avgRoomTemp := h.state.RoomTempTotal / float64(h.state.TempReadCount)
-
-	return avgRoomTemp, avgWaterTemp
-}
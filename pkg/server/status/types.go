@@ -7,9 +7,14 @@ import (
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
 	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
+	"github.com/KyleBrandon/plunger-server/pkg/server/plunges"
 )
 
+// GOAL_STATUS_INTERVAL is how long the goals in the status are kept before they are read again.
+const GOAL_STATUS_INTERVAL = 30 * time.Second
+
 type (
 	OzoneStatus struct {
 		Running     bool      `json:"running"`
@@ -32,7 +37,12 @@ type (
 		Remaining        float64 `json:"remaining_time"`
 		ElapsedTime      float64 `json:"elapsed_time"`
 		AvgWaterTemp     float64 `json:"average_water_temp"`
+		MinWaterTemp     float64 `json:"min_water_temp"`
+		MaxWaterTemp     float64 `json:"max_water_temp"`
 		AvgRoomTemp      float64 `json:"average_room_temp"`
+
+		// Phase is the phase of the protocol the running plunge follows.
+		Phase *monitor.PlungePhaseStatus `json:"phase,omitempty"`
 	}
 
 	FilterStatus struct {
@@ -42,28 +52,36 @@ type (
 	}
 
 	SystemStatus struct {
-		AlertMessages []string     `json:"alert_messages"`
-		ErrorMessages []string     `json:"error_messages"`
-		WaterTemp     float64      `json:"water_temp"`
-		RoomTemp      float64      `json:"room_temp"`
-		LeakDetected  bool         `json:"leak_detected"`
-		PumpOn        bool         `json:"pump_on"`
-		PlungeStatus  PlungeStatus `json:"plunge"`
-		OzoneStatus   OzoneStatus  `json:"ozone"`
-		FilterStatus  FilterStatus `json:"filter"`
+		AlertMessages []string                 `json:"alert_messages"`
+		ErrorMessages []string                 `json:"error_messages"`
+		WaterTemp     float64                  `json:"water_temp"`
+		RoomTemp      float64                  `json:"room_temp"`
+		LeakDetected  bool                     `json:"leak_detected"`
+		LeakAlarm     bool                     `json:"leak_alarm"`
+		LeakZones     []monitor.LeakZoneStatus `json:"leak_zones"`
+		PumpOn        bool                     `json:"pump_on"`
+		PlungeStatus  PlungeStatus             `json:"plunge"`
+		OzoneStatus   OzoneStatus              `json:"ozone"`
+		FilterStatus  FilterStatus             `json:"filter"`
+
+		ThermostatStatus thermostat.Status `json:"thermostat"`
+
+		Devices []sensor.DeviceHealth `json:"devices"`
+
+		// Goals are the weekly goals of the user of the latest plunge.
+		Goals []plunges.GoalStatus `json:"goals"`
 	}
 
-	PlungeState struct {
-		MU             sync.Mutex
-		WaterTempTotal float64
-		RoomTempTotal  float64
-		TempReadCount  int64
+	// goalCache shares the goals between the status connections so they aren't read every second.
+	goalCache struct {
+		sync.Mutex
+		readAt time.Time
+		goals  []plunges.GoalStatus
 	}
 
 	StatusStore interface {
-		FindMostRecentTemperatures(ctx context.Context) (database.Temperature, error)
+		plunges.GoalStore
 		GetLatestPlunge(ctx context.Context) (database.Plunge, error)
-		UpdatePlungeAvgTemp(ctx context.Context, arg database.UpdatePlungeAvgTempParams) (database.Plunge, error)
 		GetLatestOzoneEntry(ctx context.Context) (database.Ozone, error)
 		GetLatestFilterChange(ctx context.Context) (database.Filter, error)
 	}
@@ -72,7 +90,7 @@ type (
 		mctx           *monitor.MonitorContext
 		store          StatusStore
 		sensors        sensor.Sensors
-		state          PlungeState
 		originPatterns []string
+		goals          goalCache
 	}
 )
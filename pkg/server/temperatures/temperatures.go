@@ -2,31 +2,37 @@ package temperatures
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
+	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
-	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 )
 
-func NewHandler(mctx *monitor.MonitorContext, sensors sensor.Sensors) *Handler {
+func NewHandler(sensors sensor.Sensors, store TemperatureStore) *Handler {
 	return &Handler{
-		mctx,
 		sensors,
+		store,
 	}
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /v1/temperatures", h.handlerTemperaturesGet)
+	mux.HandleFunc("GET /v1/temperatures/history", h.handlerTemperaturesHistory)
+	mux.HandleFunc("GET /v1/temperatures/history/aggregate", h.handlerTemperaturesAggregate)
 	mux.HandleFunc("POST /v1/temperatures/notify", h.handerTemperatureNotify)
 }
 
 func (h *Handler) handlerTemperaturesGet(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("handlerTemperaturesGet")
 
-	tr := h.sensors.ReadTemperatures()
+	tr := h.sensors.ReadTemperatures(r.Context())
 
 	results := make([]TemperatureReading, 0, len(tr))
 	for _, t := range tr {
@@ -36,6 +42,148 @@ func (h *Handler) handlerTemperaturesGet(w http.ResponseWriter, r *http.Request)
 	utils.RespondWithJSON(w, http.StatusOK, results)
 }
 
+// handlerTemperaturesHistory returns a page of the stored readings for a device, or every device, over a time range.
+func (h *Handler) handlerTemperaturesHistory(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerTemperaturesHistory")
+	defer slog.Debug("<<handlerTemperaturesHistory")
+
+	from, to, err := utils.ParseTimeRange(r, DefaultHistoryRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	page, err := utils.ParsePagination(r, DefaultHistoryLimit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	device := r.URL.Query().Get("device")
+	dbReadings, err := h.store.GetTemperatureReadings(r.Context(), database.GetTemperatureReadingsParams{
+		Device:    device,
+		FromTime:  from,
+		ToTime:    to,
+		RowLimit:  int32(page.Limit),
+		RowOffset: int32(page.Offset),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the temperature history", err)
+		return
+	}
+
+	total, err := h.store.CountTemperatureReadings(r.Context(), database.CountTemperatureReadingsParams{
+		Device:   device,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to count the temperature history", err)
+		return
+	}
+
+	results := make([]TemperatureHistoryEntry, 0, len(dbReadings))
+	for _, db := range dbReadings {
+		results = append(results, databaseReadingToHistoryEntry(db))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, utils.NewPage(results, page, total))
+}
+
+// handlerTemperaturesAggregate returns the min/avg/max of the readings in each interval over a time range.
+// The interval is widened until the number of buckets fits in max_points so long ranges can still be charted.
+func (h *Handler) handlerTemperaturesAggregate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handlerTemperaturesAggregate")
+	defer slog.Debug("<<handlerTemperaturesAggregate")
+
+	from, to, err := utils.ParseTimeRange(r, DefaultHistoryRange)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' or 'to' parameter", err)
+		return
+	}
+
+	maxPoints := DefaultMaxPoints
+	if maxStr := r.URL.Query().Get("max_points"); maxStr != "" {
+		maxPoints, err = strconv.Atoi(maxStr)
+		if err != nil || maxPoints <= 0 || maxPoints > MaxMaxPoints {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("'max_points' must be between 1 and %d", MaxMaxPoints), err)
+			return
+		}
+	}
+
+	interval, err := selectInterval(r.URL.Query().Get("interval"), to.Sub(from), maxPoints)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid 'interval' parameter", err)
+		return
+	}
+
+	arg := database.GetTemperatureAggregatesParams{
+		BucketInterval: interval,
+		Device:         r.URL.Query().Get("device"),
+		FromTime:       from,
+		ToTime:         to,
+	}
+
+	rows, err := h.store.GetTemperatureAggregates(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to read the temperature history", err)
+		return
+	}
+
+	response := TemperatureAggregateResponse{
+		From:     from,
+		To:       to,
+		Interval: interval,
+		Buckets:  make([]TemperatureAggregate, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		response.Buckets = append(response.Buckets, TemperatureAggregate{
+			Bucket:      row.Bucket,
+			Name:        row.DeviceName,
+			Role:        row.DeviceRole,
+			MinC:        row.MinC,
+			AvgC:        row.AvgC,
+			MaxC:        row.MaxC,
+			MinF:        row.MinF,
+			AvgF:        row.AvgF,
+			MaxF:        row.MaxF,
+			SampleCount: row.SampleCount,
+		})
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// selectInterval returns the requested interval, or the finest one if none was requested, widened until
+// the range fits in maxPoints buckets. The coarsest interval is returned if nothing fits.
+func selectInterval(requested string, span time.Duration, maxPoints int) (string, error) {
+	start := 0
+	if requested != "" {
+		start = -1
+		for i, in := range intervals {
+			if in.name == requested {
+				start = i
+				break
+			}
+		}
+
+		if start < 0 {
+			return "", fmt.Errorf("unknown interval '%s'", requested)
+		}
+	}
+
+	for _, in := range intervals[start:] {
+		// round up so a partial bucket at the end of the range is counted
+		buckets := (span + in.duration - 1) / in.duration
+		if int(buckets) <= maxPoints {
+			return in.name, nil
+		}
+	}
+
+	return intervals[len(intervals)-1].name, nil
+}
+
 func (h *Handler) handerTemperatureNotify(w http.ResponseWriter, r *http.Request) {
 	slog.Debug(">>handlerTemperatureNotify")
 	defer slog.Debug("<<handlerTemperatureNotify")
@@ -54,7 +202,33 @@ func (h *Handler) handerTemperatureNotify(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	h.mctx.TempMonitorCh <- monitor.TemperatureTask{TargetTemperature: tnr.TargetTemperature}
+	// notify once when the water reaches the target by creating a one-shot alert rule
+	rule := alerts.Rule{
+		Name:       TargetTemperatureRuleName,
+		Device:     sensor.ROLE_WATER,
+		Condition:  alerts.CONDITION_TARGET,
+		ThresholdF: tnr.TargetTemperature,
+	}
+
+	if err := rule.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	arg := database.CreateAlertRuleParams{
+		Name:       rule.Name,
+		Device:     rule.Device,
+		Condition:  rule.Condition,
+		ThresholdF: fmt.Sprintf("%f", rule.ThresholdF),
+		Enabled:    true,
+		OneShot:    true,
+	}
+
+	_, err = h.store.CreateAlertRule(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "failed to create the temperature notification", err)
+		return
+	}
 
 	utils.RespondWithNoContent(w, http.StatusCreated)
 }
@@ -68,8 +242,25 @@ func convertFromSensorTemperatureReading(tr sensor.TemperatureReading) Temperatu
 		Name:         tr.Name,
 		Description:  tr.Description,
 		Address:      tr.Address,
+		Role:         tr.Role,
 		TemperatureC: tr.TemperatureC,
 		TemperatureF: tr.TemperatureF,
 		Err:          errorMessage,
+		TimedOut:     sensor.IsTimeout(tr.Err),
+	}
+}
+
+func databaseReadingToHistoryEntry(db database.TemperatureReading) TemperatureHistoryEntry {
+	// the values are written by the monitor, so a parse failure leaves the temperature at zero
+	tempC, _ := strconv.ParseFloat(db.TemperatureC, 64)
+	tempF, _ := strconv.ParseFloat(db.TemperatureF, 64)
+
+	return TemperatureHistoryEntry{
+		ReadAt:       db.ReadAt,
+		Name:         db.DeviceName,
+		Address:      db.DeviceAddress,
+		Role:         db.DeviceRole,
+		TemperatureC: tempC,
+		TemperatureF: tempF,
 	}
 }
@@ -2,11 +2,14 @@ package temperatures
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/KyleBrandon/plunger-server/internal/alerts"
 	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
@@ -21,7 +24,7 @@ func TestReadTemperatures(t *testing.T) {
 			},
 		}
 
-		h := NewHandler(nil, &s)
+		h := NewHandler(&s, &mockStore{})
 
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures", nil, h.handlerTemperaturesGet)
 
@@ -36,7 +39,7 @@ func TestReadTemperatures(t *testing.T) {
 
 	t.Run("should read temperature sensors", func(t *testing.T) {
 		sensor := mockSensors{}
-		h := NewHandler(nil, &sensor)
+		h := NewHandler(&sensor, &mockStore{})
 
 		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures", nil, h.handlerTemperaturesGet)
 
@@ -46,51 +49,281 @@ func TestReadTemperatures(t *testing.T) {
 	})
 }
 
+func TestTemperatureHistory(t *testing.T) {
+	t.Run("should fail with an invalid time range", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history?from=yesterday", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail when 'from' is after 'to'", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history?limit=1000", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, utils.ErrInvalidLimit.Error())
+	})
+
+	t.Run("should fail with an offset the database can't take", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history?offset=2147483648", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, utils.ErrInvalidOffset.Error())
+	})
+
+	t.Run("should fail if the store fails", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{err: errors.New("database error")})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+	})
+
+	t.Run("should default to a page of the readings", func(t *testing.T) {
+		store := mockStore{}
+		h := NewHandler(&mockSensors{}, &store)
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.arg.RowLimit != DefaultHistoryLimit || store.arg.RowOffset != 0 {
+			t.Errorf("expected the first %d readings, got %+v", DefaultHistoryLimit, store.arg)
+		}
+	})
+
+	t.Run("should return a page of the readings for the device and range", func(t *testing.T) {
+		store := mockStore{
+			readings: []database.TemperatureReading{
+				{DeviceName: "Water", DeviceAddress: "28-0001", DeviceRole: "water", TemperatureC: "4.50", TemperatureF: "40.10"},
+			},
+			count: 7,
+		}
+		h := NewHandler(&mockSensors{}, &store)
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history?device=28-0001&from=2024-01-01T00:00:00Z&to=2024-01-02T00:00:00Z&limit=1&offset=6", nil, h.handlerTemperaturesHistory)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.arg.Device != "28-0001" || store.countArg.Device != "28-0001" {
+			t.Errorf("expected device %s, got %s", "28-0001", store.arg.Device)
+		}
+
+		if store.arg.RowLimit != 1 || store.arg.RowOffset != 6 {
+			t.Errorf("unexpected page %+v", store.arg)
+		}
+
+		if !store.arg.FromTime.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !store.arg.ToTime.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected time range %v - %v", store.arg.FromTime, store.arg.ToTime)
+		}
+
+		var page utils.Page[TemperatureHistoryEntry]
+		if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+
+		if page.Total != 7 || page.Limit != 1 || page.Offset != 6 {
+			t.Errorf("unexpected page %+v", page)
+		}
+
+		if len(page.Items) != 1 || page.Items[0].TemperatureF != 40.1 || page.Items[0].Role != "water" {
+			t.Errorf("unexpected history %+v", page.Items)
+		}
+	})
+}
+
+func TestTemperatureAggregate(t *testing.T) {
+	t.Run("should fail with an unknown interval", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history/aggregate?interval=week", nil, h.handlerTemperaturesAggregate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail with an invalid max_points", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history/aggregate?max_points=0", nil, h.handlerTemperaturesAggregate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail if the store fails", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{err: errors.New("database error")})
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history/aggregate", nil, h.handlerTemperaturesAggregate)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+	})
+
+	t.Run("should downsample a 90 day range to daily buckets", func(t *testing.T) {
+		store := mockStore{
+			aggregates: []database.GetTemperatureAggregatesRow{
+				{DeviceName: "Water", DeviceRole: "water", MinF: 38.5, AvgF: 39.2, MaxF: 40.1, SampleCount: 2880},
+			},
+		}
+		h := NewHandler(&mockSensors{}, &store)
+
+		rr := utils.TestRequest(t, http.MethodGet, "/v1/temperatures/history/aggregate?interval=minute&from=2024-01-01T00:00:00Z&to=2024-03-31T00:00:00Z", nil, h.handlerTemperaturesAggregate)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if store.aggregateArg.BucketInterval != INTERVAL_DAY {
+			t.Errorf("expected interval %s, got %s", INTERVAL_DAY, store.aggregateArg.BucketInterval)
+		}
+
+		var response TemperatureAggregateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+
+		if response.Interval != INTERVAL_DAY || len(response.Buckets) != 1 || response.Buckets[0].MaxF != 40.1 {
+			t.Errorf("unexpected response %+v", response)
+		}
+	})
+}
+
+func TestSelectInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		span      time.Duration
+		maxPoints int
+		expected  string
+	}{
+		{"should default to minutes for a short range", "", time.Hour, DefaultMaxPoints, INTERVAL_MINUTE},
+		{"should widen to hours for a day", "", 24 * time.Hour, DefaultMaxPoints, INTERVAL_HOUR},
+		{"should widen to days for 90 days", "", 90 * 24 * time.Hour, DefaultMaxPoints, INTERVAL_DAY},
+		{"should keep the requested interval when it fits", INTERVAL_DAY, time.Hour, DefaultMaxPoints, INTERVAL_DAY},
+		{"should fall back to days when nothing fits", INTERVAL_MINUTE, 3650 * 24 * time.Hour, 10, INTERVAL_DAY},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			interval, err := selectInterval(tc.requested, tc.span, tc.maxPoints)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if interval != tc.expected {
+				t.Errorf("expected interval %s, got %s", tc.expected, interval)
+			}
+		})
+	}
+}
+
+func TestTemperatureNotify(t *testing.T) {
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		h := NewHandler(&mockSensors{}, &mockStore{})
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/temperatures/notify", strings.NewReader("{"), h.handerTemperatureNotify)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail with a target that is out of range", func(t *testing.T) {
+		store := mockStore{}
+		h := NewHandler(&mockSensors{}, &store)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/temperatures/notify", strings.NewReader(`{"temperature_target": 1000}`), h.handerTemperatureNotify)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, alerts.ErrInvalidThreshold.Error())
+
+		if len(store.rule.Name) != 0 {
+			t.Errorf("expected no rule to be created, got %+v", store.rule)
+		}
+	})
+
+	t.Run("should create a one-shot target rule for the water", func(t *testing.T) {
+		store := mockStore{}
+		h := NewHandler(&mockSensors{}, &store)
+
+		rr := utils.TestRequest(t, http.MethodPost, "/v1/temperatures/notify", strings.NewReader(`{"temperature_target": 45}`), h.handerTemperatureNotify)
+		utils.TestExpectedStatus(t, rr, http.StatusCreated)
+
+		if !store.rule.OneShot || store.rule.Condition != alerts.CONDITION_TARGET || store.rule.Device != sensor.ROLE_WATER {
+			t.Errorf("unexpected rule %+v", store.rule)
+		}
+	})
+}
+
 type mockStore struct {
-	temperature database.Temperature
-	err         error
+	rule         database.CreateAlertRuleParams
+	readings     []database.TemperatureReading
+	arg          database.GetTemperatureReadingsParams
+	count        int64
+	countArg     database.CountTemperatureReadingsParams
+	aggregates   []database.GetTemperatureAggregatesRow
+	aggregateArg database.GetTemperatureAggregatesParams
+	err          error
 }
 
-func (m *mockStore) FindMostRecentTemperatures(ctx context.Context) (database.Temperature, error) {
-	return m.temperature, m.err
+func (m *mockStore) CountTemperatureReadings(ctx context.Context, arg database.CountTemperatureReadingsParams) (int64, error) {
+	m.countArg = arg
+	return m.count, m.err
 }
 
-func (m *mockStore) SaveTemperature(ctx context.Context, arg database.SaveTemperatureParams) (database.Temperature, error) {
-	return m.temperature, m.err
+func (m *mockStore) CreateAlertRule(ctx context.Context, arg database.CreateAlertRuleParams) (database.AlertRule, error) {
+	m.rule = arg
+	return database.AlertRule{}, m.err
+}
+
+func (m *mockStore) GetTemperatureAggregates(ctx context.Context, arg database.GetTemperatureAggregatesParams) ([]database.GetTemperatureAggregatesRow, error) {
+	m.aggregateArg = arg
+	return m.aggregates, m.err
+}
+
+func (m *mockStore) GetTemperatureReadings(ctx context.Context, arg database.GetTemperatureReadingsParams) ([]database.TemperatureReading, error) {
+	m.arg = arg
+	return m.readings, m.err
 }
 
 type mockSensors struct {
 	temperatures []sensor.TemperatureReading
 }
 
-func (m *mockSensors) ReadTemperatures() []sensor.TemperatureReading {
+func (m *mockSensors) ReadTemperatures(ctx context.Context) []sensor.TemperatureReading {
 	return m.temperatures
 }
 
-func (m *mockSensors) ReadRoomAndWaterTemperature() (sensor.TemperatureReading, sensor.TemperatureReading) {
-	return sensor.TemperatureReading{}, sensor.TemperatureReading{}
+func (m *mockSensors) ReadLeakSensors(ctx context.Context) []sensor.LeakReading {
+	return nil
 }
 
-func (m *mockSensors) IsLeakPresent() (bool, error) {
-	return false, nil
+func (m *mockSensors) TurnOzoneOn(ctx context.Context) error {
+	return nil
 }
 
-func (m *mockSensors) TurnOzoneOn() error {
+func (m *mockSensors) TurnOzoneOff(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) TurnOzoneOff() error {
+func (m *mockSensors) IsPumpOn(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *mockSensors) TurnPumpOn(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockSensors) IsPumpOn() (bool, error) {
-	return true, nil
+func (m *mockSensors) TurnPumpOff(ctx context.Context) error {
+	return nil
 }
 
-func (m *mockSensors) TurnPumpOn() error {
+func (m *mockSensors) IsDeviceOn(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockSensors) TurnDeviceOn(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *mockSensors) TurnPumpOff() error {
+func (m *mockSensors) TurnDeviceOff(ctx context.Context, id string) error {
 	return nil
 }
+
+func (m *mockSensors) DeviceHealth() []sensor.DeviceHealth {
+	return []sensor.DeviceHealth{}
+}
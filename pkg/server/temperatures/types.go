@@ -1,23 +1,89 @@
 package temperatures
 
 import (
+	"context"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/internal/sensor"
-	"github.com/KyleBrandon/plunger-server/pkg/server/monitor"
 )
 
+const (
+	TargetTemperatureRuleName = "Target temperature"
+
+	DefaultHistoryRange = 24 * time.Hour
+
+	// DefaultHistoryLimit is the page size of the temperature history when no limit is given.
+	DefaultHistoryLimit = 500
+	DefaultMaxPoints    = 500
+	MaxMaxPoints        = 5000
+
+	INTERVAL_MINUTE = "minute"
+	INTERVAL_HOUR   = "hour"
+	INTERVAL_DAY    = "day"
+)
+
+// intervals are ordered from the finest to the coarsest bucket
+var intervals = []struct {
+	name     string
+	duration time.Duration
+}{
+	{INTERVAL_MINUTE, time.Minute},
+	{INTERVAL_HOUR, time.Hour},
+	{INTERVAL_DAY, 24 * time.Hour},
+}
+
 type (
 	TemperatureReading struct {
 		Name         string  `json:"name,omitempty"`
 		Description  string  `json:"description,omitempty"`
 		Address      string  `json:"address,omitempty"`
+		Role         string  `json:"role,omitempty"`
 		TemperatureC float64 `json:"temperature_c,omitempty"`
 		TemperatureF float64 `json:"temperature_f,omitempty"`
 		Err          string  `json:"err,omitempty"`
+		TimedOut     bool    `json:"timed_out,omitempty"`
+	}
+
+	TemperatureHistoryEntry struct {
+		ReadAt       time.Time `json:"read_at"`
+		Name         string    `json:"name"`
+		Address      string    `json:"address"`
+		Role         string    `json:"role"`
+		TemperatureC float64   `json:"temperature_c"`
+		TemperatureF float64   `json:"temperature_f"`
+	}
+
+	TemperatureAggregate struct {
+		Bucket      time.Time `json:"bucket"`
+		Name        string    `json:"name"`
+		Role        string    `json:"role"`
+		MinC        float64   `json:"min_c"`
+		AvgC        float64   `json:"avg_c"`
+		MaxC        float64   `json:"max_c"`
+		MinF        float64   `json:"min_f"`
+		AvgF        float64   `json:"avg_f"`
+		MaxF        float64   `json:"max_f"`
+		SampleCount int64     `json:"sample_count"`
+	}
+
+	TemperatureAggregateResponse struct {
+		From     time.Time              `json:"from"`
+		To       time.Time              `json:"to"`
+		Interval string                 `json:"interval"`
+		Buckets  []TemperatureAggregate `json:"buckets"`
+	}
+
+	TemperatureStore interface {
+		CountTemperatureReadings(ctx context.Context, arg database.CountTemperatureReadingsParams) (int64, error)
+		CreateAlertRule(ctx context.Context, arg database.CreateAlertRuleParams) (database.AlertRule, error)
+		GetTemperatureAggregates(ctx context.Context, arg database.GetTemperatureAggregatesParams) ([]database.GetTemperatureAggregatesRow, error)
+		GetTemperatureReadings(ctx context.Context, arg database.GetTemperatureReadingsParams) ([]database.TemperatureReading, error)
 	}
 
 	Handler struct {
-		mctx    *monitor.MonitorContext
 		sensors sensor.Sensors
+		store   TemperatureStore
 	}
 
 	TemperatureNotifyRequest struct {
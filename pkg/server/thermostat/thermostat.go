@@ -0,0 +1,100 @@
+package thermostat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func NewHandler(store ThermostatStore, controller ThermostatController) *Handler {
+	return &Handler{
+		store,
+		controller,
+	}
+}
+
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/thermostat", h.handleThermostatGet)
+	mux.HandleFunc("PUT /v1/thermostat", h.handleThermostatUpdate)
+}
+
+func (h *Handler) handleThermostatGet(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleThermostatGet")
+	defer slog.Debug("<<handleThermostatGet")
+
+	utils.RespondWithJSON(w, http.StatusOK, h.controller.ThermostatStatus())
+}
+
+// handleThermostatUpdate will save the changed settings and apply them to the running thermostat.
+func (h *Handler) handleThermostatUpdate(w http.ResponseWriter, r *http.Request) {
+	slog.Debug(">>handleThermostatUpdate")
+	defer slog.Debug("<<handleThermostatUpdate")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for thermostat update", err)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var request UpdateThermostatRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid body for thermostat update", err)
+		return
+	}
+
+	settings := applyUpdate(h.controller.ThermostatStatus().Settings, request)
+	if err := settings.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	arg := database.SaveThermostatSettingsParams{
+		Mode:             settings.Mode,
+		SetpointF:        fmt.Sprintf("%f", settings.SetpointF),
+		HysteresisF:      fmt.Sprintf("%f", settings.HysteresisF),
+		MinOnSeconds:     int32(settings.MinOnSeconds),
+		MinOffSeconds:    int32(settings.MinOffSeconds),
+		MaxCyclesPerHour: int32(settings.MaxCyclesPerHour),
+	}
+
+	_, err = h.store.SaveThermostatSettings(r.Context(), arg)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save the thermostat settings", err)
+		return
+	}
+
+	h.controller.SetThermostat(settings)
+
+	utils.RespondWithJSON(w, http.StatusOK, h.controller.ThermostatStatus())
+}
+
+func applyUpdate(settings thermostat.Settings, request UpdateThermostatRequest) thermostat.Settings {
+	if request.Mode != nil {
+		settings.Mode = *request.Mode
+	}
+	if request.SetpointF != nil {
+		settings.SetpointF = *request.SetpointF
+	}
+	if request.HysteresisF != nil {
+		settings.HysteresisF = *request.HysteresisF
+	}
+	if request.MinOnSeconds != nil {
+		settings.MinOnSeconds = *request.MinOnSeconds
+	}
+	if request.MinOffSeconds != nil {
+		settings.MinOffSeconds = *request.MinOffSeconds
+	}
+	if request.MaxCyclesPerHour != nil {
+		settings.MaxCyclesPerHour = *request.MaxCyclesPerHour
+	}
+
+	return settings
+}
@@ -0,0 +1,92 @@
+package thermostat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func TestThermostatUpdate(t *testing.T) {
+	t.Run("should fail with an invalid body", func(t *testing.T) {
+		h := NewHandler(&mockStore{}, newMockController())
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/thermostat", bytes.NewBufferString("{"), h.handleThermostatUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, "Invalid body for thermostat update")
+	})
+
+	t.Run("should fail with an invalid mode", func(t *testing.T) {
+		h := NewHandler(&mockStore{}, newMockController())
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/thermostat", bytes.NewBufferString(`{"mode": "auto"}`), h.handleThermostatUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, thermostat.ErrInvalidMode.Error())
+	})
+
+	t.Run("should fail if the settings can't be saved", func(t *testing.T) {
+		controller := newMockController()
+		h := NewHandler(&mockStore{err: errors.New("database error")}, controller)
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/thermostat", bytes.NewBufferString(`{"mode": "cool"}`), h.handleThermostatUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+
+		if controller.status.Mode != thermostat.MODE_OFF {
+			t.Errorf("expected the thermostat to be unchanged, got mode %s", controller.status.Mode)
+		}
+	})
+
+	t.Run("should save and apply the changed settings", func(t *testing.T) {
+		store := mockStore{}
+		controller := newMockController()
+		h := NewHandler(&store, controller)
+
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/thermostat", bytes.NewBufferString(`{"mode": "cool", "setpoint_f": 38.5}`), h.handleThermostatUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if controller.status.Mode != thermostat.MODE_COOL || controller.status.SetpointF != 38.5 {
+			t.Errorf("expected cool mode at 38.5, got %+v", controller.status.Settings)
+		}
+
+		if controller.status.HysteresisF != thermostat.DefaultHysteresisF {
+			t.Errorf("expected the hysteresis to be unchanged, got %v", controller.status.HysteresisF)
+		}
+
+		if store.arg.Mode != thermostat.MODE_COOL {
+			t.Errorf("expected the settings to be saved, got %+v", store.arg)
+		}
+	})
+}
+
+type mockController struct {
+	status thermostat.Status
+}
+
+func newMockController() *mockController {
+	return &mockController{
+		status: thermostat.Status{Settings: thermostat.DefaultSettings(), Device: "chiller"},
+	}
+}
+
+func (m *mockController) ThermostatStatus() thermostat.Status {
+	return m.status
+}
+
+func (m *mockController) SetThermostat(settings thermostat.Settings) {
+	m.status.Settings = settings
+}
+
+type mockStore struct {
+	arg database.SaveThermostatSettingsParams
+	err error
+}
+
+func (m *mockStore) SaveThermostatSettings(ctx context.Context, arg database.SaveThermostatSettingsParams) (database.ThermostatSetting, error) {
+	m.arg = arg
+	return database.ThermostatSetting{}, m.err
+}
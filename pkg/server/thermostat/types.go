@@ -0,0 +1,34 @@
+package thermostat
+
+import (
+	"context"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/thermostat"
+)
+
+type (
+	// UpdateThermostatRequest changes any of the settings that are present.
+	UpdateThermostatRequest struct {
+		Mode             *string  `json:"mode,omitempty"`
+		SetpointF        *float64 `json:"setpoint_f,omitempty"`
+		HysteresisF      *float64 `json:"hysteresis_f,omitempty"`
+		MinOnSeconds     *int     `json:"min_on_seconds,omitempty"`
+		MinOffSeconds    *int     `json:"min_off_seconds,omitempty"`
+		MaxCyclesPerHour *int     `json:"max_cycles_per_hour,omitempty"`
+	}
+
+	ThermostatController interface {
+		ThermostatStatus() thermostat.Status
+		SetThermostat(settings thermostat.Settings)
+	}
+
+	ThermostatStore interface {
+		SaveThermostatSettings(ctx context.Context, arg database.SaveThermostatSettingsParams) (database.ThermostatSetting, error)
+	}
+
+	Handler struct {
+		store      ThermostatStore
+		controller ThermostatController
+	}
+)
@@ -0,0 +1,171 @@
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/KyleBrandon/plunger-server/internal/notification"
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+var (
+	ErrInvalidNotificationsBody = errors.New("Invalid body for notification preferences")
+	ErrInvalidPhoneNumber       = errors.New("'phone_number' must be in E.164 format, e.g. +15555550100")
+	ErrPhoneNumberRequired      = errors.New("'phone_number' is required to receive SMS notifications")
+	ErrInvalidEventType         = errors.New("unknown notification event type")
+	ErrInvalidMuteMinutes       = errors.New("'minutes' must be greater than zero")
+)
+
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// eventTypes are the notification types a user can subscribe to.
+var eventTypes = []string{
+	notification.TYPE_ANY,
+	notification.TYPE_LEAK,
+	notification.TYPE_OZONE,
+	notification.TYPE_ALERT,
+	notification.TYPE_INTERLOCK,
+	notification.TYPE_PLUNGE,
+	notification.TYPE_SYSTEM,
+}
+
+func (handler *Handler) handlerUserNotificationsUpdate(writer http.ResponseWriter, req *http.Request) {
+	slog.Debug(">>handlerUserNotificationsUpdate")
+	defer slog.Debug("<<handlerUserNotificationsUpdate")
+
+	user, err := handler.authorizedUser(req)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	request, err := parseNotificationsRequest(req)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// the preferences and subscriptions are replaced together so a failure can't leave the user half subscribed
+	err = handler.store.ExecTx(req.Context(), func(store UserStore) error {
+		user, err = store.UpdateUserNotifications(req.Context(), database.UpdateUserNotificationsParams{
+			ID:          user.ID,
+			PhoneNumber: sql.NullString{String: request.PhoneNumber, Valid: len(request.PhoneNumber) != 0},
+			NotifySms:   request.NotifySMS,
+			NotifyEmail: request.NotifyEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = store.DeleteUserSubscriptions(req.Context(), user.ID)
+		if err != nil || len(request.EventTypes) == 0 {
+			return err
+		}
+
+		return store.CreateUserSubscriptions(req.Context(), database.CreateUserSubscriptionsParams{
+			UserID:     user.ID,
+			EventTypes: request.EventTypes,
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusInternalServerError, "failed to update the notification preferences", err)
+		return
+	}
+
+	utils.RespondWithJSON(writer, http.StatusOK, databaseUserToUser(user, request.EventTypes))
+}
+
+func (handler *Handler) handlerUserMute(writer http.ResponseWriter, req *http.Request) {
+	slog.Debug(">>handlerUserMute")
+	defer slog.Debug("<<handlerUserMute")
+
+	user, err := handler.authorizedUser(req)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	var request MuteRequest
+	err = json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusBadRequest, "could not parse body", err)
+		return
+	}
+
+	if request.Minutes <= 0 {
+		utils.RespondWithError(writer, http.StatusBadRequest, ErrInvalidMuteMinutes.Error(), ErrInvalidMuteMinutes)
+		return
+	}
+
+	mutedUntil := time.Now().UTC().Add(time.Duration(request.Minutes) * time.Minute)
+	handler.updateMute(writer, req, user, sql.NullTime{Time: mutedUntil, Valid: true})
+}
+
+func (handler *Handler) handlerUserUnmute(writer http.ResponseWriter, req *http.Request) {
+	slog.Debug(">>handlerUserUnmute")
+	defer slog.Debug("<<handlerUserUnmute")
+
+	user, err := handler.authorizedUser(req)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusForbidden, "not authorized", err)
+		return
+	}
+
+	handler.updateMute(writer, req, user, sql.NullTime{})
+}
+
+func (handler *Handler) updateMute(writer http.ResponseWriter, req *http.Request, user database.User, mutedUntil sql.NullTime) {
+	user, err := handler.store.MuteUser(req.Context(), database.MuteUserParams{
+		ID:         user.ID,
+		MutedUntil: mutedUntil,
+	})
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusInternalServerError, "failed to update the notification mute", err)
+		return
+	}
+
+	eventTypes, err := handler.store.GetUserSubscriptions(req.Context(), user.ID)
+	if err != nil {
+		utils.RespondWithError(writer, http.StatusInternalServerError, "failed to read the notification subscriptions", err)
+		return
+	}
+
+	utils.RespondWithJSON(writer, http.StatusOK, databaseUserToUser(user, eventTypes))
+}
+
+func parseNotificationsRequest(req *http.Request) (NotificationsRequest, error) {
+	var request NotificationsRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		return request, ErrInvalidNotificationsBody
+	}
+
+	if len(request.PhoneNumber) != 0 && !phoneNumberPattern.MatchString(request.PhoneNumber) {
+		return request, ErrInvalidPhoneNumber
+	}
+
+	if request.NotifySMS && len(request.PhoneNumber) == 0 {
+		return request, ErrPhoneNumberRequired
+	}
+
+	unique := make([]string, 0, len(request.EventTypes))
+	for _, eventType := range request.EventTypes {
+		if !slices.Contains(eventTypes, eventType) {
+			return request, ErrInvalidEventType
+		}
+
+		if !slices.Contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+	request.EventTypes = unique
+
+	return request, nil
+}
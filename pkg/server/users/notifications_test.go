@@ -0,0 +1,115 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KyleBrandon/plunger-server/pkg/utils"
+)
+
+func TestUpdateUserNotifications(t *testing.T) {
+	userStore := mockUserStore{apiKey: "12345"}
+	handler := NewHandler(&userStore)
+
+	headers := map[string][]string{
+		"Authorization": {"ApiKey 12345"},
+	}
+
+	send := func(t *testing.T, request NotificationsRequest) *httptest.ResponseRecorder {
+		marshalled, err := json.Marshal(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return utils.TestRequestWithHeaders(t, http.MethodPut, "/v1/users/notifications", headers, bytes.NewBuffer(marshalled), handler.handlerUserNotificationsUpdate)
+	}
+
+	t.Run("should fail without an API key", func(t *testing.T) {
+		rr := utils.TestRequest(t, http.MethodPut, "/v1/users/notifications", bytes.NewBufferString("{}"), handler.handlerUserNotificationsUpdate)
+		utils.TestExpectedStatus(t, rr, http.StatusForbidden)
+	})
+
+	t.Run("should fail with an invalid phone number", func(t *testing.T) {
+		rr := send(t, NotificationsRequest{PhoneNumber: "555-1212", NotifySMS: true})
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail to enable SMS without a phone number", func(t *testing.T) {
+		rr := send(t, NotificationsRequest{NotifySMS: true})
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should fail with an unknown event type", func(t *testing.T) {
+		rr := send(t, NotificationsRequest{NotifyEmail: true, EventTypes: []string{"weather"}})
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+	})
+
+	t.Run("should replace the subscriptions", func(t *testing.T) {
+		userStore.subscriptions = []string{"ozone"}
+
+		rr := send(t, NotificationsRequest{PhoneNumber: "+15555550100", NotifySMS: true, EventTypes: []string{"leak", "interlock", "leak"}})
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if len(userStore.subscriptions) != 2 || userStore.subscriptions[0] != "leak" || userStore.subscriptions[1] != "interlock" {
+			t.Errorf("unexpected subscriptions %v", userStore.subscriptions)
+		}
+
+		if userStore.user.PhoneNumber.String != "+15555550100" || !userStore.user.NotifySms {
+			t.Errorf("expected the SMS preferences to be saved, got %+v", userStore.user)
+		}
+	})
+
+	t.Run("should keep the preferences if the subscriptions can't be replaced", func(t *testing.T) {
+		userStore.err = errors.New("connection reset")
+		defer func() { userStore.err = nil }()
+
+		rr := send(t, NotificationsRequest{NotifyEmail: true, EventTypes: []string{"ozone"}})
+		utils.TestExpectedStatus(t, rr, http.StatusInternalServerError)
+
+		if len(userStore.subscriptions) != 2 || !userStore.user.NotifySms || userStore.user.NotifyEmail {
+			t.Errorf("expected the previous preferences and subscriptions, got %+v %v", userStore.user, userStore.subscriptions)
+		}
+	})
+}
+
+func TestMuteUser(t *testing.T) {
+	userStore := mockUserStore{apiKey: "12345"}
+	handler := NewHandler(&userStore)
+
+	headers := map[string][]string{
+		"Authorization": {"ApiKey 12345"},
+	}
+
+	t.Run("should fail with a zero duration", func(t *testing.T) {
+		rr := utils.TestRequestWithHeaders(t, http.MethodPost, "/v1/users/mute", headers, bytes.NewBufferString(`{"minutes":0}`), handler.handlerUserMute)
+		utils.TestExpectedStatus(t, rr, http.StatusBadRequest)
+		utils.TestExpectedMessage(t, rr, ErrInvalidMuteMinutes.Error())
+	})
+
+	t.Run("should mute the user", func(t *testing.T) {
+		rr := utils.TestRequestWithHeaders(t, http.MethodPost, "/v1/users/mute", headers, bytes.NewBufferString(`{"minutes":60}`), handler.handlerUserMute)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		if !userStore.user.MutedUntil.Valid {
+			t.Errorf("expected the user to be muted")
+		}
+	})
+
+	t.Run("should unmute the user", func(t *testing.T) {
+		rr := utils.TestRequestWithHeaders(t, http.MethodDelete, "/v1/users/mute", headers, nil, handler.handlerUserUnmute)
+		utils.TestExpectedStatus(t, rr, http.StatusOK)
+
+		var response UserResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if userStore.user.MutedUntil.Valid || response.MutedUntil != nil {
+			t.Errorf("expected the user to be unmuted")
+		}
+	})
+}
@@ -2,36 +2,84 @@ package users
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
+	"github.com/google/uuid"
 )
 
 type CreateUserRequest struct {
 	Email string `json:"email"`
 }
 
+// NotificationsRequest sets how the user is notified, EventTypes are the notification types to receive or "*" for every type.
+type NotificationsRequest struct {
+	PhoneNumber string   `json:"phone_number"`
+	NotifySMS   bool     `json:"notify_sms"`
+	NotifyEmail bool     `json:"notify_email"`
+	EventTypes  []string `json:"event_types"`
+}
+
+// MuteRequest mutes the user's notifications for a number of minutes.
+type MuteRequest struct {
+	Minutes int `json:"minutes"`
+}
+
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	ApiKey    string    `json:"api_key"`
-}
-
-func databaseUserToUser(user database.User) UserResponse {
-	return UserResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		ApiKey:    user.ApiKey,
+	ID          string     `json:"id"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ApiKey      string     `json:"api_key"`
+	PhoneNumber string     `json:"phone_number,omitempty"`
+	NotifySMS   bool       `json:"notify_sms"`
+	NotifyEmail bool       `json:"notify_email"`
+	MutedUntil  *time.Time `json:"muted_until,omitempty"`
+	EventTypes  []string   `json:"event_types"`
+}
+
+func databaseUserToUser(user database.User, eventTypes []string) UserResponse {
+	response := UserResponse{
+		ID:          user.ID.String(),
+		Email:       user.Email,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		ApiKey:      user.ApiKey,
+		PhoneNumber: user.PhoneNumber.String,
+		NotifySMS:   user.NotifySms,
+		NotifyEmail: user.NotifyEmail,
+		EventTypes:  eventTypes,
+	}
+
+	if response.EventTypes == nil {
+		response.EventTypes = []string{}
+	}
+
+	if user.MutedUntil.Valid {
+		response.MutedUntil = &user.MutedUntil.Time
 	}
+
+	return response
 }
 
 type UserStore interface {
 	GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error)
 	CreateUser(ctx context.Context, email string) (database.User, error)
+	UpdateUserNotifications(ctx context.Context, arg database.UpdateUserNotificationsParams) (database.User, error)
+	MuteUser(ctx context.Context, arg database.MuteUserParams) (database.User, error)
+	GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	DeleteUserSubscriptions(ctx context.Context, userID uuid.UUID) error
+	CreateUserSubscriptions(ctx context.Context, arg database.CreateUserSubscriptionsParams) error
+
+	// ExecTx runs fn with a store whose queries are made in one transaction, it is rolled back if fn fails.
+	ExecTx(ctx context.Context, fn func(UserStore) error) error
+}
+
+// Store is the UserStore for the database connection.
+type Store struct {
+	*database.Queries
+	db *sql.DB
 }
 
 type Handler struct {
@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -9,6 +10,7 @@ import (
 	"net/mail"
 
 	"github.com/KyleBrandon/plunger-server/internal/auth"
+	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
 )
 
@@ -18,27 +20,62 @@ func NewHandler(store UserStore) *Handler {
 	}
 }
 
+func NewStore(db *sql.DB, queries *database.Queries) *Store {
+	return &Store{
+		Queries: queries,
+		db:      db,
+	}
+}
+
+func (s *Store) ExecTx(ctx context.Context, fn func(UserStore) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(&Store{Queries: s.Queries.WithTx(tx), db: s.db}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (handler *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /v1/users", handler.handlerUserCreate)
 	mux.HandleFunc("GET /v1/users", handler.handlerUserGet)
+	mux.HandleFunc("PUT /v1/users/notifications", handler.handlerUserNotificationsUpdate)
+	mux.HandleFunc("POST /v1/users/mute", handler.handlerUserMute)
+	mux.HandleFunc("DELETE /v1/users/mute", handler.handlerUserUnmute)
+}
+
+// authorizedUser returns the user for the request's API key.
+func (handler *Handler) authorizedUser(req *http.Request) (database.User, error) {
+	apiKey, err := auth.ParseApiKey(req)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return handler.store.GetUserByApiKey(req.Context(), apiKey)
 }
 
 func (handler *Handler) handlerUserGet(writer http.ResponseWriter, req *http.Request) {
 	slog.Debug("handleUserGet")
 
-	apiKey, err := auth.ParseApiKey(req)
+	user, err := handler.authorizedUser(req)
 	if err != nil {
 		utils.RespondWithError(writer, http.StatusForbidden, "not authorized", err)
 		return
 	}
 
-	user, err := handler.store.GetUserByApiKey(req.Context(), apiKey)
+	eventTypes, err := handler.store.GetUserSubscriptions(req.Context(), user.ID)
 	if err != nil {
-		utils.RespondWithError(writer, http.StatusForbidden, "not authorized", err)
+		utils.RespondWithError(writer, http.StatusInternalServerError, "failed to read the notification subscriptions", err)
 		return
 	}
 
-	utils.RespondWithJSON(writer, http.StatusOK, databaseUserToUser(user))
+	utils.RespondWithJSON(writer, http.StatusOK, databaseUserToUser(user, eventTypes))
 }
 
 func validateEmail(email string) bool {
@@ -72,5 +109,5 @@ func (handler *Handler) handlerUserCreate(writer http.ResponseWriter, req *http.
 		return
 	}
 
-	utils.RespondWithJSON(writer, http.StatusCreated, databaseUserToUser(user))
+	utils.RespondWithJSON(writer, http.StatusCreated, databaseUserToUser(user, nil))
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/KyleBrandon/plunger-server/internal/database"
 	"github.com/KyleBrandon/plunger-server/pkg/utils"
+	"github.com/google/uuid"
 )
 
 func TestGetUser(t *testing.T) {
@@ -125,8 +126,22 @@ func TestCreateUser(t *testing.T) {
 }
 
 type mockUserStore struct {
-	apiKey string
-	user   database.User
+	apiKey        string
+	user          database.User
+	subscriptions []string
+	err           error
+}
+
+// ExecTx restores the user and subscriptions if fn fails, as rolling back the transaction would.
+func (m *mockUserStore) ExecTx(ctx context.Context, fn func(UserStore) error) error {
+	user, subscriptions := m.user, m.subscriptions
+
+	err := fn(m)
+	if err != nil {
+		m.user, m.subscriptions = user, subscriptions
+	}
+
+	return err
 }
 
 func (m *mockUserStore) GetUserByApiKey(ctx context.Context, apiKey string) (database.User, error) {
@@ -140,3 +155,33 @@ func (m *mockUserStore) CreateUser(ctx context.Context, email string) (database.
 	m.user.Email = email
 	return m.user, nil
 }
+
+func (m *mockUserStore) UpdateUserNotifications(ctx context.Context, arg database.UpdateUserNotificationsParams) (database.User, error) {
+	m.user.PhoneNumber = arg.PhoneNumber
+	m.user.NotifySms = arg.NotifySms
+	m.user.NotifyEmail = arg.NotifyEmail
+	return m.user, nil
+}
+
+func (m *mockUserStore) MuteUser(ctx context.Context, arg database.MuteUserParams) (database.User, error) {
+	m.user.MutedUntil = arg.MutedUntil
+	return m.user, nil
+}
+
+func (m *mockUserStore) GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return m.subscriptions, nil
+}
+
+func (m *mockUserStore) DeleteUserSubscriptions(ctx context.Context, userID uuid.UUID) error {
+	m.subscriptions = nil
+	return nil
+}
+
+func (m *mockUserStore) CreateUserSubscriptions(ctx context.Context, arg database.CreateUserSubscriptionsParams) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.subscriptions = append(m.subscriptions, arg.EventTypes...)
+	return nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// MaxPageLimit is the largest page that can be requested.
+const MaxPageLimit = 500
+
+var (
+	ErrInvalidLimit  = errors.New("'limit' must be between 1 and 500")
+	ErrInvalidOffset = errors.New("'offset' must be between 0 and 2147483647")
+)
+
+type (
+	// Pagination is the page of results requested with the 'limit' and 'offset' query parameters.
+	Pagination struct {
+		Limit  int
+		Offset int
+	}
+
+	// Page is a page of results with the total number of results available.
+	Page[T any] struct {
+		Items  []T   `json:"items"`
+		Limit  int   `json:"limit"`
+		Offset int   `json:"offset"`
+		Total  int64 `json:"total"`
+	}
+)
+
+// ParsePagination reads the 'limit' and 'offset' query parameters, the limit defaults to defaultLimit and the offset to zero.
+func ParsePagination(r *http.Request, defaultLimit int) (Pagination, error) {
+	p := Pagination{Limit: defaultLimit}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > MaxPageLimit {
+			return p, ErrInvalidLimit
+		}
+		p.Limit = limit
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		// the offset is passed to the database as an int32
+		if err != nil || offset < 0 || offset > math.MaxInt32 {
+			return p, ErrInvalidOffset
+		}
+		p.Offset = offset
+	}
+
+	return p, nil
+}
+
+// NewPage creates the page for the items read with the pagination.
+func NewPage[T any](items []T, p Pagination, total int64) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return Page[T]{
+		Items:  items,
+		Limit:  p.Limit,
+		Offset: p.Offset,
+		Total:  total,
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var ErrInvalidTimeRange = errors.New("'from' must be before 'to'")
+
+// ParseTimeRange reads the RFC3339 'from' and 'to' query parameters as UTC times.
+// When 'to' is missing it defaults to now, and when 'from' is missing it defaults to 'to' minus the default range.
+func ParseTimeRange(r *http.Request, defaultRange time.Duration) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = t.UTC()
+	}
+
+	from := to.Add(-defaultRange)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = t.UTC()
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, ErrInvalidTimeRange
+	}
+
+	return from, to, nil
+}
@@ -39,6 +39,22 @@ func TestRequestWithHeaders(t *testing.T, method string, url string, headers map
 	return w
 }
 
+func TestRequestWithPathValues(t *testing.T, method string, url string, values map[string]string, body io.Reader, handler func(http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range values {
+		req.SetPathValue(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	return w
+}
+
 func TestExpectedStatus(t *testing.T, rr *httptest.ResponseRecorder, statusCode int) {
 	if rr.Code != statusCode {
 		t.Errorf("expected status code %d, got %d", statusCode, rr.Code)